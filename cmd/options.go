@@ -3,6 +3,7 @@ package cmd
 import (
 	"crypto/tls"
 	"fmt"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
@@ -60,12 +61,21 @@ type fleOptions struct {
 //	oidcNoNonce bool
 //}
 
+type readWriteOptions struct {
+	readPreference string
+	readConcern    string
+	w              string
+	journal        bool
+	writeTimeout   int
+}
+
 type flagOptions struct {
 	baseOptions           baseOptions
 	authenticationOptions authenticationOptions
 	tlsOptions            tlsOptions
 	apiVersionOptions     apiVersionOptions
 	fleOptions            fleOptions
+	readWriteOptions      readWriteOptions
 	//oidcOptions           oidcOptions
 }
 
@@ -197,3 +207,48 @@ func applyFleConfig(clientOps *options.ClientOptions, flags fleOptions) error {
 
 	return nil
 }
+
+// applyReadWriteConfig returns the concerns of the connection string with those set by flags taking precedence.
+// journalSet tells whether --journal was passed as its false value cannot otherwise be told apart from unset
+func applyReadWriteConfig(clientOps *options.ClientOptions, flags readWriteOptions, journalSet bool) (mongoengine.Concerns, error) {
+	var readConcern, w, journal, writeTimeout string
+	if clientOps.ReadConcern != nil {
+		readConcern = clientOps.ReadConcern.Level
+	}
+	if wc := clientOps.WriteConcern; wc != nil {
+		if wc.W != nil {
+			w = fmt.Sprint(wc.W)
+		}
+		if wc.Journal != nil {
+			journal = yesNo(*wc.Journal)
+		}
+	}
+
+	if flags.readConcern != "" {
+		readConcern = flags.readConcern
+	}
+	if flags.w != "" {
+		w = flags.w
+	}
+	if journalSet {
+		journal = yesNo(flags.journal)
+	}
+	if flags.writeTimeout != 0 {
+		writeTimeout = fmt.Sprint(flags.writeTimeout)
+	}
+	concerns, err := mongoengine.ParseConcerns(flags.readPreference, readConcern, w, journal, writeTimeout)
+	if err != nil {
+		return mongoengine.Concerns{}, fmt.Errorf("invalid read or write concern: %w", err)
+	}
+	if flags.readPreference == "" { // Kept whole so that the tag sets and max staleness of the URI are not lost
+		concerns.ReadPreference = clientOps.ReadPreference
+	}
+	return concerns, nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -72,10 +72,12 @@ func genRootCmd() *cobra.Command {
 			applyApiVersionConfig(clientOps, flags.apiVersionOptions)
 			err = applyFleConfig(clientOps, flags.fleOptions)
 			cobra.CheckErr(err)
+			concerns, err := applyReadWriteConfig(clientOps, flags.readWriteOptions, cmd.Flags().Changed("journal"))
+			cobra.CheckErr(err)
 
 			client, err := mongo.Connect(clientOps)
 			cobra.CheckErr(err)
-			tui.Initialize(client)
+			tui.Initialize(client, concerns)
 		},
 	}
 
@@ -126,6 +128,14 @@ func genRootCmd() *cobra.Command {
 	//fleFlags.StringVar(&flags.fleOptions.kmsURL, "kmsURL", "", "Test parameter to override the URL of the KMS endpoint")
 	flagSets = append(flagSets, namedFlagSet{name: "FLE Options", flagset: fleFlags})
 
+	readWriteFlags := pflag.NewFlagSet("readWrite", pflag.ExitOnError)
+	readWriteFlags.StringVar(&flags.readWriteOptions.readPreference, "readPreference", "", "Read preference mode (primary, primaryPreferred, secondary, secondaryPreferred or nearest)")
+	readWriteFlags.StringVar(&flags.readWriteOptions.readConcern, "readConcern", "", "Read concern level (local, available, majority, linearizable or snapshot)")
+	readWriteFlags.StringVar(&flags.readWriteOptions.w, "w", "", "Write concern as a number of members, majority or a tag set name")
+	readWriteFlags.BoolVar(&flags.readWriteOptions.journal, "journal", false, "Require writes to be acknowledged once written to the on-disk journal")
+	readWriteFlags.IntVar(&flags.readWriteOptions.writeTimeout, "writeTimeout", 0, "Milliseconds to wait for a write before giving up on it. A write that times out may still have been applied")
+	flagSets = append(flagSets, namedFlagSet{name: "Read and Write Options", flagset: readWriteFlags})
+
 	//oidcFlags := pflag.NewFlagSet("oidc", pflag.ExitOnError)
 	//oidcFlags.StringVar(&flags.oidcOptions.oidcFlows, "oidcFlows", "", "Supported OIDC auth flows [auth-code,device-auth]")
 	//oidcFlags.StringVar(&flags.oidcOptions.oidcRedirectUri, "oidcRedirectUri", "http://localhost:27097/redirect", "Local auth code flow redirect URL")
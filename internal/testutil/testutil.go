@@ -0,0 +1,69 @@
+// The testutil package contains the fixtures shared by the tests of the components. The components are driven
+// through their Update functions in the same way as the bubbletea runtime would, against an engine backed by a
+// MemoryBackend rather than a MongoDB server
+
+package testutil
+
+import (
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+// NewEngine returns an engine over a MemoryBackend seeded with docs, keyed by the db.coll namespace they are
+// inserted into. Docs are inserted in order so that they are returned in that order
+func NewEngine(t *testing.T, docs map[string][]any) *mongoengine.Engine {
+	t.Helper()
+	backend := mongoengine.NewMemoryBackend()
+	for ns, nsDocs := range docs {
+		db, coll, _ := strings.Cut(ns, ".")
+		for _, doc := range nsDocs {
+			if _, err := backend.InsertOne(context.Background(), db, coll, doc); err != nil {
+				t.Fatalf("failed to seed backend: %v", err)
+			}
+		}
+	}
+	return mongoengine.New(backend)
+}
+
+// NumberedDocs returns n docs whose only field other than their _id is n, counting up from 0
+func NumberedDocs(n int) []any {
+	docs := make([]any, n)
+	for i := range n {
+		docs[i] = bson.D{{Key: "n", Value: i}}
+	}
+	return docs
+}
+
+// KeyPress returns the message sent when k is typed. Keys with a name, such as enter, are sent as that key
+func KeyPress(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	default:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+}
+
+// RunCmd runs a command along with every command batched within it and returns the resulting messages
+func RunCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, RunCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
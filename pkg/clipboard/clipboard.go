@@ -0,0 +1,84 @@
+// The clipboard package copies documents and their values to the clipboard of the machine the terminal runs on.
+// The native clipboard is used when one is available. Otherwise, and always over SSH where the native clipboard
+// would be that of the remote machine, an OSC 52 escape sequence asks the terminal to set its clipboard instead
+
+package clipboard
+
+import (
+	"fmt"
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"io"
+	"os"
+	"strings"
+)
+
+// CopiedMsg is sent once text was copied. Description names what was copied, such as the document or _id
+type CopiedMsg struct {
+	Description string
+}
+
+// CopyDocument copies the value at a dotted path within doc, or the whole doc when path is empty
+func CopyDocument(doc *bson.M, path string) tea.Cmd {
+	text, err := mongoengine.DocumentText(doc, path)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	description := "the document"
+	if path != "" {
+		description = path
+	}
+	return Copy(text, description)
+}
+
+// CopyFieldForm asks for the dotted path of the field within doc whose value should be copied
+func CopyFieldForm(doc *bson.M) tea.Cmd {
+	fields := []modal.FormField{{Label: "Field", Hint: "dotted path such as address.city"}}
+	return modal.DisplayFormModal("Copy the value of a field", fields, func(values []string) tea.Cmd {
+		path := strings.TrimSpace(values[0])
+		if path == "" {
+			return modal.DisplayErrorModal(fmt.Errorf("a field to copy is required"))
+		}
+		return CopyDocument(doc, path)
+	})
+}
+
+// Copy writes text to the clipboard
+func Copy(text, description string) tea.Cmd {
+	return func() tea.Msg {
+		if err := write(text, os.Stdout); err != nil {
+			return modal.ErrModalMsg{Err: fmt.Errorf("could not copy %s: %w", description, err)}
+		}
+		return CopiedMsg{Description: description}
+	}
+}
+
+func write(text string, terminal io.Writer) error {
+	if !isRemote() && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+	return writeOSC52(text, terminal)
+}
+
+func isRemote() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// writeOSC52 writes the escape sequence that sets the terminal's clipboard. tmux and screen only pass the sequence
+// on to the terminal they run in when it is wrapped in one of their own
+func writeOSC52(text string, terminal io.Writer) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(terminal)
+	return err
+}
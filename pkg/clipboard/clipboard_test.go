@@ -0,0 +1,32 @@
+package clipboard
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteOSC52(t *testing.T) {
+	tests := []struct {
+		name string
+		tmux string
+		term string
+		want string
+	}{
+		{name: "terminal", term: "xterm-256color", want: "\x1b]52;c;eyJhIjogMX0=\x07"},
+		{name: "tmux", tmux: "/tmp/tmux-1000/default,1,0", term: "tmux-256color", want: "\x1bPtmux;\x1b\x1b]52;c;eyJhIjogMX0=\x07\x1b\\"},
+		{name: "screen", term: "screen", want: "\x1bP\x1b]52;c;eyJhIjogMX0=\x07\x1b\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.tmux)
+			t.Setenv("TERM", tt.term)
+			var terminal bytes.Buffer
+			if err := writeOSC52(`{"a": 1}`, &terminal); err != nil {
+				t.Fatalf("writeOSC52() error = %v", err)
+			}
+			if got := terminal.String(); got != tt.want {
+				t.Errorf("writeOSC52() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kreulenk/mongotui/pkg/mainview/state"
 	"github.com/kreulenk/mongotui/pkg/mongoengine"
@@ -44,7 +45,8 @@ type Model struct {
 	engine *mongoengine.Engine
 }
 
-// New creates a new baseModel for the dbcoltable component
+// New creates a new baseModel for the dbcoltable component. Only the database names are fetched up front, the
+// collections are loaded by the command returned from Init
 func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
 	if err := engine.RefreshDbAndCollections(); err != nil {
 		fmt.Printf("could not initialize data: %v\n", err)
@@ -75,6 +77,12 @@ func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
 	return &m
 }
 
+// Init starts loading the collections of the highlighted database followed by every other database along with
+// the stats of the highlighted database
+func (m *Model) Init() tea.Cmd {
+	return tea.Batch(m.engine.LoadCollections(m.cursoredDatabase()), m.engine.LoadAllCollections(), m.loadStats())
+}
+
 // Focus enables key use on the dbcoltable so that the user can navigate the dbcoltable again. This signal would
 // be sent from another component
 func (m *Model) Focus() {
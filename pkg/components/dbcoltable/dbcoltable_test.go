@@ -0,0 +1,208 @@
+package dbcoltable
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestModel(t *testing.T) (*Model, *mongoengine.Engine) {
+	t.Helper()
+	engine := testutil.NewEngine(t, map[string][]any{
+		"shop.users":       {bson.M{"name": "users"}},
+		"shop.products":    {bson.M{"name": "products"}},
+		"analytics.events": {bson.M{"name": "events"}},
+	})
+	m := New(engine, state.DefaultState())
+	m.SetWidth(80, 120)
+	m.SetHeight(20)
+	testutil.RunCmd(m.Init())
+	return m, engine
+}
+
+func TestNavigation(t *testing.T) {
+	tests := []struct {
+		name           string
+		keys           []string
+		wantDatabase   string
+		wantCollection string
+		wantComponent  state.ActiveComponent
+	}{
+		{name: "starts on first database", wantDatabase: "analytics", wantComponent: state.DbColTable},
+		{name: "down moves to the next database", keys: []string{"j"}, wantDatabase: "shop", wantComponent: state.DbColTable},
+		{name: "down stops at the last database", keys: []string{"j", "j", "j"}, wantDatabase: "shop", wantComponent: state.DbColTable},
+		{name: "right enters the collections", keys: []string{"j", "l"}, wantDatabase: "shop", wantCollection: "products", wantComponent: state.DbColTable},
+		{name: "collections can be navigated", keys: []string{"j", "l", "j"}, wantDatabase: "shop", wantCollection: "users", wantComponent: state.DbColTable},
+		{name: "left returns to the databases", keys: []string{"j", "l", "j", "h"}, wantDatabase: "shop", wantComponent: state.DbColTable},
+		{name: "enter on a collection focuses the doclist", keys: []string{"l", "enter"}, wantDatabase: "analytics", wantCollection: "events", wantComponent: state.DocList},
+		{name: "filter databases", keys: []string{"/", "s", "h", "esc"}, wantDatabase: "shop", wantComponent: state.DbColTable},
+		{name: "enter on a filter moves into the collections", keys: []string{"/", "s", "h", "enter"}, wantDatabase: "shop", wantCollection: "products", wantComponent: state.DbColTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModel(t)
+			for _, k := range tt.keys {
+				var cmd tea.Cmd
+				m, cmd = m.Update(testutil.KeyPress(k))
+				for _, msg := range testutil.RunCmd(cmd) {
+					if errMsg, ok := msg.(modal.ErrModalMsg); ok {
+						t.Fatalf("key %s returned an error: %v", k, errMsg.Err)
+					}
+				}
+			}
+			if got := m.cursoredDatabase(); got != tt.wantDatabase {
+				t.Errorf("cursoredDatabase() = %q, want %q", got, tt.wantDatabase)
+			}
+			if got := m.cursoredCollection(); got != tt.wantCollection {
+				t.Errorf("cursoredCollection() = %q, want %q", got, tt.wantCollection)
+			}
+			if got := m.state.GetActiveComponent(); got != tt.wantComponent {
+				t.Errorf("active component = %v, want %v", got, tt.wantComponent)
+			}
+		})
+	}
+}
+
+func TestDrop(t *testing.T) {
+	tests := []struct {
+		name            string
+		msg             tea.Msg
+		wantDatabases   []string
+		wantCollections []string
+	}{
+		{
+			name:            "drop collection",
+			msg:             modal.ExecCollDrop{DbName: "shop", CollectionName: "users"},
+			wantDatabases:   []string{"analytics", "shop"},
+			wantCollections: []string{"products"},
+		},
+		{
+			name:          "drop database",
+			msg:           modal.ExecDbDrop{DbName: "analytics"},
+			wantDatabases: []string{"shop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			_, cmd := m.Update(testutil.KeyPress("j"))
+			testutil.RunCmd(cmd)
+			if _, ok := tt.msg.(modal.ExecCollDrop); ok {
+				m, _ = m.Update(testutil.KeyPress("l"))
+			}
+
+			_, cmd = m.Update(tt.msg)
+			if msg := cmd(); msg != (mongoengine.DatabasesRefreshedMsg{}) {
+				t.Fatalf("drop returned %#v", msg)
+			}
+			if got := engine.GetDatabases(); !slices.Equal(got, tt.wantDatabases) {
+				t.Errorf("GetDatabases() = %v, want %v", got, tt.wantDatabases)
+			}
+			if tt.wantCollections != nil {
+				if got := engine.GetSelectedCollections(); !slices.Equal(got, tt.wantCollections) {
+					t.Errorf("GetSelectedCollections() = %v, want %v", got, tt.wantCollections)
+				}
+			}
+		})
+	}
+}
+
+func TestStatsPane(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		wantInView []string
+	}{
+		{name: "database stats", wantInView: []string{"Collections  1", "Documents    1 (avg"}},
+		{name: "stats follow the database cursor", keys: []string{"j"}, wantInView: []string{"Collections  2", "Documents    2 (avg"}},
+		{name: "collection stats", keys: []string{"j", "l"}, wantInView: []string{"shop.products · collection", "Documents    1 (avg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModel(t)
+			for _, k := range tt.keys {
+				var cmd tea.Cmd
+				m, cmd = m.Update(testutil.KeyPress(k))
+				testutil.RunCmd(cmd)
+			}
+			view := m.View()
+			for _, want := range tt.wantInView {
+				if !strings.Contains(view, want) {
+					t.Errorf("View() does not contain %q:\n%s", want, view)
+				}
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name            string
+		toDb, toColl    string
+		dropTarget      bool
+		filter          string // Collection filter applied before the rename
+		wantErr         bool
+		wantDatabases   []string
+		wantCollections []string
+		wantFilter      string
+	}{
+		{name: "rename within the database", toDb: "shop", toColl: "customers", wantDatabases: []string{"analytics", "shop"}, wantCollections: []string{"customers", "products"}},
+		{name: "move to another database", toDb: "analytics", toColl: "users", wantDatabases: []string{"analytics", "shop"}, wantCollections: []string{"events", "users"}},
+		{name: "filter that still shows the collection is kept", toDb: "analytics", toColl: "users", filter: "s", wantDatabases: []string{"analytics", "shop"}, wantCollections: []string{"events", "users"}, wantFilter: "s"},
+		{name: "filter that hides the collection is cleared", toDb: "shop", toColl: "customers", filter: "user", wantDatabases: []string{"analytics", "shop"}, wantCollections: []string{"customers", "products"}},
+		{name: "existing target", toDb: "shop", toColl: "products", wantErr: true},
+		{name: "drop existing target", toDb: "shop", toColl: "products", dropTarget: true, wantDatabases: []string{"analytics", "shop"}, wantCollections: []string{"products"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			_, cmd := m.Update(testutil.KeyPress("j"))
+			testutil.RunCmd(cmd)
+			m, _ = m.Update(testutil.KeyPress("l"))
+			m, _ = m.Update(testutil.KeyPress("j")) // Collections are sorted so users is second
+			_, cmd = m.Update(testutil.KeyPress("r"))
+			if !slices.ContainsFunc(testutil.RunCmd(cmd), func(msg tea.Msg) bool { _, ok := msg.(modal.FormModalMsg); return ok }) {
+				t.Fatalf("rename key should display the rename form")
+			}
+
+			msg := engine.RenameCollection(m.cursoredDatabase(), m.cursoredCollection(), tt.toDb, tt.toColl, tt.dropTarget)()
+			if _, ok := msg.(modal.ErrModalMsg); ok != tt.wantErr {
+				t.Fatalf("RenameCollection() = %#v, wantErr %v", msg, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			m.collectionFilter = tt.filter
+			_, cmd = m.Update(msg)
+			testutil.RunCmd(cmd)
+			if m.collectionFilter != tt.wantFilter {
+				t.Errorf("collectionFilter = %q, want %q", m.collectionFilter, tt.wantFilter)
+			}
+			if dbName, collName := engine.GetSelectedCollection(); dbName != tt.toDb || collName != tt.toColl {
+				t.Errorf("GetSelectedCollection() = %s.%s, want %s.%s", dbName, collName, tt.toDb, tt.toColl)
+			}
+			if docs := engine.GetQueriedDocs(); len(docs) != 1 || (*docs[0])["name"] != "users" {
+				t.Errorf("GetQueriedDocs() = %v, want the docs of the renamed collection", docs)
+			}
+			if got := engine.GetDatabases(); !slices.Equal(got, tt.wantDatabases) {
+				t.Errorf("GetDatabases() = %v, want %v", got, tt.wantDatabases)
+			}
+			if got := engine.GetSelectedCollections(); !slices.Equal(got, tt.wantCollections) {
+				t.Errorf("GetSelectedCollections() = %v, want %v", got, tt.wantCollections)
+			}
+			if m.cursoredDatabase() != tt.toDb || m.cursoredCollection() != tt.toColl {
+				t.Errorf("cursor on %s.%s, want the renamed collection %s.%s", m.cursoredDatabase(), m.cursoredCollection(), tt.toDb, tt.toColl)
+			}
+		})
+	}
+}
@@ -17,8 +17,13 @@ type keyMap struct {
 	Right                         key.Binding
 	Left                          key.Binding
 	Insert                        key.Binding
+	CreateWithOptions             key.Binding
 	Enter                         key.Binding
 	Drop                          key.Binding
+	Rename                        key.Binding
+	Copy                          key.Binding
+	Indexes                       key.Binding
+	Import                        key.Binding
 	StartSearch                   key.Binding
 	StopSearch                    key.Binding
 	StopSearchAndEnterHighlighted key.Binding
@@ -57,6 +62,10 @@ var keys = keyMap{
 		key.WithKeys("i"),
 		key.WithHelp("i", "insert"),
 	),
+	CreateWithOptions: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "create with options"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "select collection"),
@@ -65,6 +74,22 @@ var keys = keyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "drop"),
 	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy"),
+	),
+	Indexes: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "indexes"),
+	),
+	Import: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "import"),
+	),
 	StartSearch: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
@@ -97,7 +122,7 @@ func (m *Model) searchHelpView() string {
 
 // ShortHelp implements the keyMap interface.
 func (km keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{km.Quit, km.LineUp, km.LineDown, km.Right, km.Left, km.Drop, km.Insert, km.StartSearch}
+	return []key.Binding{km.Quit, km.LineUp, km.LineDown, km.Right, km.Left, km.Drop, km.Rename, km.Copy, km.Insert, km.CreateWithOptions, km.Indexes, km.Import, km.StartSearch}
 }
 
 // FullHelp is required to satisfy the keyMap interface
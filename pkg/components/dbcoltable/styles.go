@@ -7,6 +7,8 @@ type Styles struct {
 	Header   lipgloss.Style
 	Cell     lipgloss.Style
 	Selected lipgloss.Style
+	Status   lipgloss.Style
+	Stats    lipgloss.Style
 }
 
 func defaultStyles() Styles {
@@ -27,5 +29,12 @@ func defaultStyles() Styles {
 			BorderBottom(true),
 		Cell: lipgloss.NewStyle().
 			Inline(true),
+		Status: lipgloss.NewStyle().
+			Inline(true).
+			Foreground(lipgloss.Color("240")),
+		Stats: lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			BorderTop(true),
 	}
 }
@@ -1,52 +1,80 @@
 package dbcoltable
 
 import (
+	"fmt"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
 	"github.com/kreulenk/mongotui/pkg/renderutils"
-	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"strings"
 )
 
-// Update is the Bubble Tea update loop.
+// Update is the Bubble Tea update loop. The stats of whatever ends up highlighted are loaded after every update
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	cmd := m.update(msg)
+	return m, tea.Batch(cmd, m.loadStats())
+}
+
+func (m *Model) update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if m.filterEnabled {
-			return m, m.handleSearchUpdate(msg)
+			return m.handleSearchUpdate(msg)
 		}
 
 		switch {
 		case key.Matches(msg, keys.LineUp):
-			return m, m.MoveUp(1)
+			return m.MoveUp(1)
 		case key.Matches(msg, keys.LineDown):
-			return m, m.MoveDown(1)
+			return m.MoveDown(1)
 		case key.Matches(msg, keys.GotoTop):
-			return m, m.GotoTop()
+			return m.GotoTop()
 		case key.Matches(msg, keys.GotoBottom):
-			return m, m.GotoBottom()
+			return m.GotoBottom()
 		case key.Matches(msg, keys.Right):
-			return m, m.MoveRight()
+			return m.MoveRight()
 		case key.Matches(msg, keys.Left):
 			m.MoveLeft()
-			return m, nil
+			return nil
 		case key.Matches(msg, keys.Insert):
 			if m.cursorColumn == databasesColumn {
-				return m, modal.DisplayDbCollInsertModal("")
+				return modal.DisplayDbCollInsertModal("")
 			} else {
-				return m, modal.DisplayDbCollInsertModal(m.cursoredDatabase())
+				return modal.DisplayDbCollInsertModal(m.cursoredDatabase())
 			}
+		case key.Matches(msg, keys.CreateWithOptions):
+			m.state.SetActiveComponent(state.CollectionCreate)
+			return nil
 		case key.Matches(msg, keys.Enter):
 			if m.cursorColumn == collectionsColumn {
 				m.blur()
 			}
-			return m, nil
+			return nil
 		case key.Matches(msg, keys.Drop):
 			if m.cursorColumn == databasesColumn {
-				return m, modal.DisplayDatabaseDropModal(m.cursoredDatabase())
+				return modal.DisplayDatabaseDropModal(m.cursoredDatabase())
 			} else {
-				return m, modal.DisplayCollectionDropModal(m.cursoredDatabase(), m.cursoredCollection())
+				return modal.DisplayCollectionDropModal(m.cursoredDatabase(), m.cursoredCollection())
+			}
+		case key.Matches(msg, keys.Rename):
+			if m.cursorColumn == collectionsColumn && m.cursoredCollection() != "" {
+				return m.renameForm(m.cursoredDatabase(), m.cursoredCollection())
+			}
+		case key.Matches(msg, keys.Copy):
+			if m.cursoredDatabase() != "" {
+				return m.copyForm(m.cursoredDatabase(), m.cursoredCollection())
 			}
+		case key.Matches(msg, keys.Indexes):
+			if m.cursorColumn == collectionsColumn && m.cursoredCollection() != "" {
+				m.engine.SetSelectedCollection(m.cursoredDatabase(), m.cursoredCollection())
+				m.state.SetActiveComponent(state.IndexList)
+				return m.engine.LoadIndexes()
+			}
+		case key.Matches(msg, keys.Import):
+			return m.importForm()
 		case key.Matches(msg, keys.StartSearch):
 			if m.cursorColumn == databasesColumn {
 				m.searchBar.SetValue(m.databaseFilter)
@@ -61,7 +89,7 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		if len(m.getFilteredCollections()) == 1 { // If we are about to drop last collection making db disappear
 			m.cursorColumn = databasesColumn
 		}
-		return m, m.engine.DropCollection(msg.DbName, msg.CollectionName)
+		return m.engine.DropCollection(msg.DbName, msg.CollectionName)
 	case modal.ExecDbDrop:
 		m.cursorDatabase = renderutils.Max(0, m.cursorDatabase-1)
 		m.cursorCollection = renderutils.Max(0, m.cursorCollection-1)
@@ -70,9 +98,107 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		} else {
 			m.engine.SetSelectedDatabase(m.getFilteredDbs()[m.cursorDatabase])
 		}
-		return m, m.engine.DropDatabase(msg.DbName)
+		return m.engine.DropDatabase(msg.DbName)
+	case mongoengine.DatabasesRefreshedMsg:
+		return m.engine.LoadAllCollections()
+	case mongoengine.CollectionRenamedMsg:
+		return tea.Batch(m.cursorOn(msg.DbName, msg.CollectionName), m.engine.LoadAllCollections())
+	case mongoengine.JobFinishedMsg:
+		if msg.DatabasesRefreshed {
+			return m.engine.LoadAllCollections()
+		}
 	}
-	return m, nil
+	return nil
+}
+
+// renameForm asks for the new name of a collection. Changing the database moves the collection into it
+func (m *Model) renameForm(dbName, collName string) tea.Cmd {
+	fields := []modal.FormField{
+		{Label: "Database", Value: dbName},
+		{Label: "Collection", Value: collName},
+		{Label: "Drop target", Value: "no", Hint: "yes replaces a collection that already has the new name"},
+	}
+	return modal.DisplayFormModal(fmt.Sprintf("Rename %s.%s", dbName, collName), fields, func(values []string) tea.Cmd {
+		toDbName, toCollName := strings.TrimSpace(values[0]), strings.TrimSpace(values[1])
+		if toDbName == "" || toCollName == "" {
+			return modal.DisplayErrorModal(fmt.Errorf("a database and collection to rename to are required"))
+		}
+		dropTarget, err := modal.ParseYesNo("drop target", values[2])
+		if err != nil {
+			return modal.DisplayErrorModal(err)
+		}
+		return m.engine.RenameCollection(dbName, collName, toDbName, toCollName, dropTarget)
+	})
+}
+
+// copyForm asks where to copy a collection, or every collection of a database when collName is empty
+func (m *Model) copyForm(dbName, collName string) tea.Cmd {
+	title := fmt.Sprintf("Copy the database %s", dbName)
+	fields := []modal.FormField{
+		{Label: "Filter", Hint: "empty copies every document"},
+		{Label: "Target database", Value: dbName + "_copy"},
+		{Label: "Connection string", Hint: "empty copies within this server"},
+		{Label: "Indexes", Value: "yes", Hint: "yes recreates the indexes on the copy"},
+	}
+	if collName != "" {
+		title = fmt.Sprintf("Copy %s.%s", dbName, collName)
+		fields[1].Value = dbName
+		fields = slices.Insert(fields, 2, modal.FormField{Label: "Target collection", Value: collName + "_copy"})
+	}
+	return modal.DisplayFormModal(title, fields, func(values []string) tea.Cmd {
+		targetColl := ""
+		if collName != "" {
+			targetColl, values = values[2], slices.Delete(values, 2, 3)
+		}
+		opts, err := mongoengine.ParseCopyOptions(dbName, collName, values[0], values[1], targetColl, values[2], values[3])
+		if err != nil {
+			return modal.DisplayErrorModal(err)
+		}
+		return m.engine.Copy(opts)
+	})
+}
+
+// cursorOn moves the cursor onto a collection, clearing the filters that hide it, and queries its documents so that
+// the document list shows the collection under its new name
+func (m *Model) cursorOn(dbName, collName string) tea.Cmd {
+	if len(filterBySearch([]string{dbName}, m.databaseFilter)) == 0 {
+		m.databaseFilter = ""
+	}
+	if len(filterBySearch([]string{collName}, m.collectionFilter)) == 0 {
+		m.collectionFilter = ""
+	}
+	m.engine.SetSelectedCollection(dbName, collName)
+	m.cursorColumn = collectionsColumn
+	m.cursorDatabase = renderutils.Max(0, slices.Index(m.getFilteredDbs(), dbName))
+	m.cursorCollection = renderutils.Max(0, slices.Index(m.getFilteredCollections(), collName))
+	return m.engine.QueryCollection(mongoengine.Query{})
+}
+
+// importForm asks which file to import and where to. The highlighted collection is the default destination
+func (m *Model) importForm() tea.Cmd {
+	collName := ""
+	if m.cursorColumn == collectionsColumn {
+		collName = m.cursoredCollection()
+	}
+	fields := []modal.FormField{
+		{Label: "Database", Value: m.cursoredDatabase()},
+		{Label: "Collection", Value: collName},
+		{Label: "File", Hint: "path to a JSON, NDJSON or CSV file"},
+		{Label: "Format", Value: "auto", Hint: "auto, json, ndjson or csv"},
+		{Label: "Ordered", Value: "no", Hint: "yes stops at the first document that fails"},
+		{Label: "Upsert keys", Hint: "comma separated fields, empty to insert"},
+	}
+	return modal.DisplayFormModal("Import documents into a collection", fields, func(values []string) tea.Cmd {
+		dbName, collName := strings.TrimSpace(values[0]), strings.TrimSpace(values[1])
+		if dbName == "" || collName == "" {
+			return modal.DisplayErrorModal(fmt.Errorf("a database and collection to import into are required"))
+		}
+		opts, err := mongoengine.ParseImportOptions(values[2], values[3], values[4], values[5])
+		if err != nil {
+			return modal.DisplayErrorModal(err)
+		}
+		return m.engine.Import(dbName, collName, opts)
+	})
 }
 
 func (m *Model) handleSearchUpdate(msg tea.KeyMsg) tea.Cmd {
@@ -98,6 +224,7 @@ func (m *Model) handleSearchUpdate(msg tea.KeyMsg) tea.Cmd {
 			m.cursorDatabase = dbMaxIndex
 		}
 		m.engine.SetSelectedDatabase(m.cursoredDatabase())
+		return m.engine.LoadCollections(m.cursoredDatabase())
 	} else {
 		originalCollection := m.cursoredCollection()
 		m.searchBar, _ = m.searchBar.Update(msg)
@@ -115,22 +242,32 @@ func (m *Model) handleSearchUpdate(msg tea.KeyMsg) tea.Cmd {
 
 		if originalCollection != m.cursoredCollection() {
 			m.engine.SetSelectedCollection(m.getFilteredDbs()[m.cursorDatabase], m.getFilteredCollections()[m.cursorCollection])
-			return m.engine.QueryCollection(bson.D{})
+			return m.engine.QueryCollection(mongoengine.Query{})
 		}
 	}
 	return nil
 }
 
+// loadStats loads the stats of the highlighted database as well as the highlighted collection. Stats that are
+// already cached are not fetched again
+func (m *Model) loadStats() tea.Cmd {
+	return tea.Batch(
+		m.engine.LoadDatabaseStats(m.cursoredDatabase()),
+		m.engine.LoadCollectionStats(m.cursoredDatabase(), m.cursoredCollection()),
+	)
+}
+
 // MoveUp moves the selection up by any number of rows.
 // It can not go above the first row.
 func (m *Model) MoveUp(n int) tea.Cmd {
 	if m.cursorColumn == databasesColumn {
 		m.cursorDatabase = renderutils.Clamp(m.cursorDatabase-n, 0, len(m.getFilteredDbs())-1)
 		m.engine.SetSelectedDatabase(m.getFilteredDbs()[m.cursorDatabase])
-	} else {
+		return m.engine.LoadCollections(m.getFilteredDbs()[m.cursorDatabase])
+	} else if len(m.getFilteredCollections()) > 0 {
 		m.cursorCollection = renderutils.Clamp(m.cursorCollection-n, 0, len(m.getFilteredCollections())-1)
 		m.engine.SetSelectedCollection(m.getFilteredDbs()[m.cursorDatabase], m.getFilteredCollections()[m.cursorCollection])
-		return m.engine.QueryCollection(bson.D{})
+		return m.engine.QueryCollection(mongoengine.Query{})
 	}
 	return nil
 }
@@ -141,10 +278,11 @@ func (m *Model) MoveDown(n int) tea.Cmd {
 	if m.cursorColumn == databasesColumn {
 		m.cursorDatabase = renderutils.Clamp(m.cursorDatabase+n, 0, len(m.getFilteredDbs())-1)
 		m.engine.SetSelectedDatabase(m.getFilteredDbs()[m.cursorDatabase])
-	} else {
+		return m.engine.LoadCollections(m.getFilteredDbs()[m.cursorDatabase])
+	} else if len(m.getFilteredCollections()) > 0 {
 		m.cursorCollection = renderutils.Clamp(m.cursorCollection+n, 0, len(m.getFilteredCollections())-1)
 		m.engine.SetSelectedCollection(m.getFilteredDbs()[m.cursorDatabase], m.getFilteredCollections()[m.cursorCollection])
-		return m.engine.QueryCollection(bson.D{})
+		return m.engine.QueryCollection(mongoengine.Query{})
 	}
 	return nil
 }
@@ -154,10 +292,13 @@ func (m *Model) MoveRight() tea.Cmd {
 	if m.cursorColumn == collectionsColumn {
 		m.blur()
 	} else if m.cursorColumn == databasesColumn {
+		if len(m.getFilteredCollections()) == 0 { // Still loading or failed to load, so retry if it failed
+			return m.engine.LoadCollections(m.cursoredDatabase())
+		}
 		m.cursorColumn = collectionsColumn
 		m.cursorCollection = 0
 		m.engine.SetSelectedCollection(m.getFilteredDbs()[m.cursorDatabase], m.getFilteredCollections()[m.cursorCollection])
-		return m.engine.QueryCollection(bson.D{})
+		return m.engine.QueryCollection(mongoengine.Query{})
 	}
 	return nil
 }
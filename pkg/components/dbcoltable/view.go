@@ -3,14 +3,18 @@ package dbcoltable
 import (
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
 	"github.com/kreulenk/mongotui/pkg/renderutils"
 	"github.com/mattn/go-runewidth"
+	"strings"
 )
 
+const statsPaneHeight = 6 // Top border plus the lines rendered by statsView
+
 // View renders the component.
 func (m *Model) View() string {
 	m.updateViewport()
-	return m.styles.Table.Render(m.headersView() + "\n" + m.viewport.View())
+	return m.styles.Table.Render(m.headersView() + "\n" + m.viewport.View() + "\n" + m.statsView())
 }
 
 // updateViewport renders all of the cells for the databases and collections that are displayed within the dbcoltable
@@ -39,6 +43,9 @@ func (m *Model) updateViewport() {
 	for i := m.collectionStart; i < m.collectionEnd; i++ {
 		renderedCollectionCells = append(renderedCollectionCells, m.renderCollectionCell(i))
 	}
+	if len(renderedCollectionCells) == 0 {
+		renderedCollectionCells = append(renderedCollectionCells, m.renderCollectionsStatus())
+	}
 	renderedCollectionColumn := lipgloss.JoinVertical(lipgloss.Top, renderedCollectionCells...)
 
 	m.viewport.SetContent(
@@ -54,7 +61,7 @@ func (m *Model) SetWidth(dbColWidth, fullTermWidth int) {
 
 // SetHeight sets the height of the viewport of the dbcoltable.
 func (m *Model) SetHeight(h int) {
-	m.viewport.Height = h - lipgloss.Height(m.headersView())
+	m.viewport.Height = h - lipgloss.Height(m.headersView()) - statsPaneHeight
 }
 
 func (m *Model) columnWidth() int {
@@ -73,7 +80,14 @@ func (m *Model) headersView() string {
 
 func (m *Model) renderDatabaseCell(r int) string {
 	m.styles.Cell = m.styles.Cell.Width(m.columnWidth()).MaxWidth(m.columnWidth())
-	renderedCell := m.styles.Cell.Render(runewidth.Truncate(m.getFilteredDbs()[r], m.columnWidth(), "…"))
+	dbName := m.getFilteredDbs()[r]
+	switch collectionsState, _ := m.engine.GetCollectionsState(dbName); collectionsState {
+	case mongoengine.CollectionsLoading:
+		dbName += " ⋯"
+	case mongoengine.CollectionsFailed:
+		dbName += " ✗"
+	}
+	renderedCell := m.styles.Cell.Render(runewidth.Truncate(dbName, m.columnWidth(), "…"))
 	if r == m.cursorDatabase {
 		renderedCell = m.styles.Selected.Render(renderedCell)
 	}
@@ -89,3 +103,107 @@ func (m *Model) renderCollectionCell(r int) string {
 	}
 	return renderedCell
 }
+
+// renderCollectionsStatus is displayed in place of the collections while they are loading or if they failed to load
+func (m *Model) renderCollectionsStatus() string {
+	m.styles.Status = m.styles.Status.Width(m.columnWidth()).MaxWidth(m.columnWidth())
+	collectionsState, err := m.engine.GetCollectionsState(m.cursoredDatabase())
+	switch collectionsState {
+	case mongoengine.CollectionsNotLoaded, mongoengine.CollectionsLoading:
+		return m.styles.Status.Render("loading collections…")
+	case mongoengine.CollectionsFailed:
+		return m.styles.Status.Render(runewidth.Truncate(err.Error(), m.columnWidth(), "…"))
+	default:
+		return ""
+	}
+}
+
+// statsView renders the stats of the highlighted collection, or of the highlighted database while the cursor is
+// in the databases column
+func (m *Model) statsView() string {
+	var lines []string
+	if m.cursorColumn == collectionsColumn && m.cursoredCollection() != "" {
+		lines = m.collectionStatsLines()
+	} else if m.cursoredDatabase() != "" {
+		lines = m.databaseStatsLines()
+	}
+	for i, line := range lines {
+		lines[i] = runewidth.Truncate(line, m.viewport.Width, "…")
+	}
+	return m.styles.Stats.Width(m.viewport.Width).Height(statsPaneHeight - 1).Render(strings.Join(lines, "\n"))
+}
+
+func (m *Model) databaseStatsLines() []string {
+	dbName := m.cursoredDatabase()
+	stats, ok := m.engine.GetDatabaseStats(dbName)
+	if status, loaded := m.statsStatus(ok && !stats.Loading, stats.Err); !loaded {
+		return []string{dbName, status}
+	}
+	collections := fmt.Sprintf("%d", stats.Collections)
+	if stats.Views > 0 {
+		collections += fmt.Sprintf(" (%d views)", stats.Views)
+	}
+	return []string{
+		dbName,
+		statsLine("Collections", collections),
+		statsLine("Documents", documentsText(stats.Objects, stats.AvgObjSize)),
+		statsLine("Storage", storageText(stats.StorageSize, stats.DataSize)),
+		statsLine("Index size", renderutils.FormatBytes(stats.IndexSize)),
+	}
+}
+
+func (m *Model) collectionStatsLines() []string {
+	dbName, collName := m.cursoredDatabase(), m.cursoredCollection()
+	stats, ok := m.engine.GetCollectionStats(dbName, collName)
+	title := dbName + "." + collName
+	if ok && !stats.Loading {
+		title += " · " + collectionTypeText(stats)
+	}
+	if stats.Type == "view" {
+		return []string{title, m.styles.Status.Render("views have no storage stats")}
+	}
+	if status, loaded := m.statsStatus(ok && !stats.Loading, stats.Err); !loaded {
+		return []string{title, status}
+	}
+	return []string{
+		title,
+		statsLine("Documents", documentsText(stats.Count, stats.AvgObjSize)),
+		statsLine("Storage", storageText(stats.StorageSize, stats.Size)),
+		statsLine("Index size", renderutils.FormatBytes(stats.IndexSize)),
+	}
+}
+
+// statsStatus renders a placeholder for stats that are still loading or failed to load
+func (m *Model) statsStatus(loaded bool, err error) (string, bool) {
+	switch {
+	case err != nil:
+		return m.styles.Status.Render(err.Error()), false
+	case !loaded:
+		return m.styles.Status.Render("loading stats…"), false
+	default:
+		return "", true
+	}
+}
+
+func statsLine(label, value string) string {
+	return fmt.Sprintf("%-12s %s", label, value)
+}
+
+func documentsText(count, avgSize int64) string {
+	return fmt.Sprintf("%d (avg %s)", count, renderutils.FormatBytes(avgSize))
+}
+
+func storageText(storageSize, dataSize int64) string {
+	return fmt.Sprintf("%s (data %s)", renderutils.FormatBytes(storageSize), renderutils.FormatBytes(dataSize))
+}
+
+func collectionTypeText(stats mongoengine.CollectionStats) string {
+	switch {
+	case stats.Type == "view" && stats.ViewOn != "":
+		return "view on " + stats.ViewOn
+	case stats.Capped:
+		return "capped " + stats.Type
+	default:
+		return stats.Type
+	}
+}
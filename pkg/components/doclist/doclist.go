@@ -20,6 +20,9 @@ type Model struct {
 	cursor   int
 	viewport viewport.Model
 
+	following  bool // If the change events of the collection are displayed rather than its docs
+	followTail bool // If the cursor moves to each new change event as it arrives
+
 	engine *mongoengine.Engine
 }
 
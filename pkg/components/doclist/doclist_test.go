@@ -0,0 +1,322 @@
+package doclist
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+const docCount = 30
+
+func newTestModel(t *testing.T) (*Model, *mongoengine.Engine) {
+	t.Helper()
+	engine := testutil.NewEngine(t, map[string][]any{"shop.items": testutil.NumberedDocs(docCount)})
+	engine.SetSelectedCollection("shop", "items")
+	if msg := engine.QueryCollection(mongoengine.Query{})(); msg != (mongoengine.RedrawMessage{}) {
+		t.Fatalf("initial query returned %#v", msg)
+	}
+
+	s := state.DefaultState()
+	s.SetActiveComponent(state.DocList)
+	m := New(engine, s)
+	m.SetWidth(80)
+	m.SetHeight(40)
+	m.Focus()
+	return m, engine
+}
+
+// firstN returns the n field of the first doc on the current page
+func firstN(engine *mongoengine.Engine) int32 {
+	docs := engine.GetQueriedDocs()
+	if len(docs) == 0 {
+		return -1
+	}
+	return (*docs[0])["n"].(int32)
+}
+
+func TestQueriesAndPagination(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		wantErr       bool
+		wantDocs      int
+		wantDocCount  int64
+		wantSkip      int64
+		wantFirstN    int32
+		wantComponent state.ActiveComponent
+	}{
+		{name: "first page", wantDocs: 25, wantDocCount: docCount, wantFirstN: 0, wantComponent: state.DocList},
+		{name: "next page", keys: []string{"]"}, wantDocs: 5, wantDocCount: docCount, wantSkip: 25, wantFirstN: 25, wantComponent: state.DocList},
+		{name: "next page stops at the last page", keys: []string{"]", "]"}, wantErr: true, wantDocs: 5, wantDocCount: docCount, wantSkip: 25, wantFirstN: 25, wantComponent: state.DocList},
+		{name: "previous page on the first page", keys: []string{"["}, wantErr: true, wantDocs: 25, wantDocCount: docCount, wantFirstN: 0, wantComponent: state.DocList},
+		{name: "filter", keys: []string{"k", `"n": {"$gte": 20}`, "enter"}, wantDocs: 10, wantDocCount: 10, wantFirstN: 20, wantComponent: state.DocList},
+		{name: "sort and limit", keys: []string{"k", "tab", `{"n": -1}`, "tab", "tab", "5", "enter"}, wantDocs: 5, wantDocCount: 5, wantFirstN: 29, wantComponent: state.DocList},
+		{name: "invalid filter", keys: []string{"k", "oops", "enter"}, wantErr: true, wantDocs: 25, wantDocCount: docCount, wantFirstN: 0, wantComponent: state.DocList},
+		{name: "aggregation", keys: []string{"a", `{"$match": {"n": {"$lt": 3}}}, {"$sort": {"n": -1}}`, "enter"}, wantDocs: 3, wantDocCount: 3, wantFirstN: 2, wantComponent: state.DocList},
+		{name: "keyset next page", keys: []string{"p", "]"}, wantDocs: 5, wantDocCount: docCount, wantSkip: 25, wantFirstN: 25, wantComponent: state.DocList},
+		{name: "keyset previous page", keys: []string{"p", "]", "["}, wantDocs: 25, wantDocCount: docCount, wantFirstN: 0, wantComponent: state.DocList},
+		{name: "keyset with a sort", keys: []string{"k", "tab", `{"n": -1}`, "enter", "p", "]"}, wantDocs: 5, wantDocCount: docCount, wantSkip: 25, wantFirstN: 4, wantComponent: state.DocList},
+		{name: "left returns to the dbcoltable", keys: []string{"h"}, wantDocs: 25, wantDocCount: docCount, wantFirstN: 0, wantComponent: state.DbColTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			var gotErr bool
+			for _, k := range tt.keys {
+				var cmd tea.Cmd
+				m, cmd = m.Update(testutil.KeyPress(k))
+				if cmd == nil {
+					continue
+				}
+				if _, ok := cmd().(modal.ErrModalMsg); ok {
+					gotErr = true
+				}
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("got error = %v, want %v", gotErr, tt.wantErr)
+			}
+			if got := len(engine.GetDocumentSummaries()); got != tt.wantDocs {
+				t.Errorf("len(GetDocumentSummaries()) = %d, want %d", got, tt.wantDocs)
+			}
+			if engine.DocCount != tt.wantDocCount {
+				t.Errorf("DocCount = %d, want %d", engine.DocCount, tt.wantDocCount)
+			}
+			if engine.Skip != tt.wantSkip {
+				t.Errorf("Skip = %d, want %d", engine.Skip, tt.wantSkip)
+			}
+			if got := firstN(engine); got != tt.wantFirstN {
+				t.Errorf("first doc n = %d, want %d", got, tt.wantFirstN)
+			}
+			if got := m.state.GetActiveComponent(); got != tt.wantComponent {
+				t.Errorf("active component = %v, want %v", got, tt.wantComponent)
+			}
+		})
+	}
+}
+
+func TestCountModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		wantTracker string
+	}{
+		{name: "no filter uses the estimated count", wantTracker: "viewing documents 1-25 of ~30 (estimated)"},
+		{name: "exact count", keys: []string{"k", `"n": {"$gte": 20}`, "enter"}, wantTracker: "viewing documents 1-10 of 10"},
+		{name: "capped count below the cap is exact", keys: []string{"k", `"n": {"$gte": 20}`, "enter", "#"}, wantTracker: "viewing documents 1-10 of 10"},
+		{name: "no count", keys: []string{"k", `"n": {"$gte": 20}`, "enter", "#", "#"}, wantTracker: "viewing documents 1-10 (not counted)"},
+		{name: "next page without a count", keys: []string{"k", `"n": {"$gte": 0}`, "enter", "#", "#", "]"}, wantTracker: "viewing documents 26-30 (not counted)"},
+		{name: "count is cycled back to exact", keys: []string{"k", `"n": {"$gte": 0}`, "enter", "#", "#", "#"}, wantTracker: "viewing documents 1-25 of 30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModel(t)
+			for _, k := range tt.keys {
+				var cmd tea.Cmd
+				m, cmd = m.Update(testutil.KeyPress(k))
+				if cmd == nil {
+					continue
+				}
+				if msg, ok := cmd().(modal.ErrModalMsg); ok {
+					t.Fatalf("key %s returned an error: %v", k, msg.Err)
+				}
+			}
+			if got := m.paginationTracker(); got != tt.wantTracker {
+				t.Errorf("paginationTracker() = %q, want %q", got, tt.wantTracker)
+			}
+		})
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	m, engine := newTestModel(t)
+	m.MoveDown(2)
+
+	_, cmd := m.Update(testutil.KeyPress("d"))
+	if _, ok := cmd().(modal.DocDeleteModalMsg); !ok {
+		t.Fatalf("delete key should display the delete modal")
+	}
+	_, cmd = m.Update(modal.ExecDocDelete{Doc: engine.GetSelectedDocument()})
+	if msg := cmd(); msg != (mongoengine.RedrawMessage{}) {
+		t.Fatalf("delete returned %#v", msg)
+	}
+	if engine.DocCount != docCount-1 {
+		t.Errorf("DocCount = %d, want %d", engine.DocCount, docCount-1)
+	}
+	if m.cursor != 1 {
+		t.Errorf("cursor = %d, want 1", m.cursor)
+	}
+}
+
+func TestCloneDocument(t *testing.T) {
+	m, engine := newTestModel(t)
+	m.MoveDown(2)
+
+	if _, cmd := m.Update(testutil.KeyPress("c")); cmd != nil {
+		t.Fatalf("clone returned %#v", cmd())
+	}
+	if got := m.state.GetActiveComponent(); got != state.DocClone {
+		t.Fatalf("active component = %v, want DocClone", got)
+	}
+	if n := (*engine.GetSelectedDocument())["n"]; n != int32(2) {
+		t.Errorf("selected doc has n = %v, want the highlighted doc 2", n)
+	}
+}
+
+// TestWritesRefusedForPartialDocs checks that docs which are not whole stored documents can not be written back
+func TestWritesRefusedForPartialDocs(t *testing.T) {
+	tests := []struct {
+		name  string
+		query mongoengine.Query
+		key   string
+	}{
+		{name: "edit under a projection", query: mongoengine.Query{Projection: bson.D{{Key: "n", Value: 1}}}, key: "e"},
+		{name: "edit aggregation results", query: mongoengine.Query{Pipeline: []bson.D{}}, key: "e"},
+		{name: "delete aggregation results", query: mongoengine.Query{Pipeline: []bson.D{}}, key: "d"},
+		{name: "clone aggregation results", query: mongoengine.Query{Pipeline: []bson.D{}}, key: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			if msg := engine.QueryCollection(tt.query)(); msg != (mongoengine.RedrawMessage{}) {
+				t.Fatalf("query returned %#v", msg)
+			}
+			_, cmd := m.Update(testutil.KeyPress(tt.key))
+			if cmd == nil {
+				t.Fatalf("%s should display an error", tt.key)
+			}
+			if _, ok := cmd().(modal.ErrModalMsg); !ok {
+				t.Errorf("%s returned %#v, want an error", tt.key, cmd())
+			}
+			if got := m.state.GetActiveComponent(); got != state.DocList {
+				t.Errorf("active component = %v, want DocList", got)
+			}
+		})
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []string
+		wantModal bool
+		wantCount int64
+	}{
+		{name: "filter", keys: []string{"k", `"n": {"$gte": 20}`, "enter", "D"}, wantModal: true, wantCount: 10},
+		{name: "no filter", keys: []string{"D"}, wantModal: true, wantCount: docCount},
+		{name: "nothing matches", keys: []string{"k", `"n": {"$gte": 100}`, "enter", "D"}},
+		{name: "aggregation", keys: []string{"a", `{"$match": {"n": {"$gte": 20}}}`, "enter", "D"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			var msg tea.Msg
+			for _, k := range tt.keys {
+				var cmd tea.Cmd
+				m, cmd = m.Update(testutil.KeyPress(k))
+				if cmd != nil {
+					msg = cmd()
+				}
+			}
+			if _, ok := msg.(modal.DocDeleteManyModalMsg); ok != tt.wantModal {
+				t.Fatalf("delete matching returned %#v, want the modal = %v", msg, tt.wantModal)
+			}
+			if !tt.wantModal {
+				if _, ok := msg.(modal.ErrModalMsg); !ok {
+					t.Errorf("delete matching returned %#v, want an error", msg)
+				}
+				return
+			}
+
+			query, _ := m.searchBar.GetValue()
+			_, cmd := m.Update(modal.ExecDocDeleteMany{DbName: "shop", CollectionName: "items", Filter: query.Filter})
+			deleted, ok := cmd().(mongoengine.DocsDeletedMsg)
+			if !ok || deleted.Count != tt.wantCount {
+				t.Fatalf("delete returned %#v, want %d deleted", deleted, tt.wantCount)
+			}
+			_, cmd = m.Update(deleted)
+			if msg := cmd(); msg != (mongoengine.RedrawMessage{}) {
+				t.Fatalf("query after the deletion returned %#v", msg)
+			}
+			if len(engine.GetQueriedDocs()) != 0 {
+				t.Errorf("%d docs still match the filter", len(engine.GetQueriedDocs()))
+			}
+		})
+	}
+}
+
+func TestUpdateMatching(t *testing.T) {
+	m, engine := newTestModel(t)
+	m.Update(testutil.KeyPress("a"))
+	m.Update(testutil.KeyPress("enter"))
+	if _, cmd := m.Update(testutil.KeyPress("U")); cmd == nil || m.state.GetActiveComponent() != state.DocList {
+		t.Errorf("update matching should not open the editor for an aggregation")
+	}
+
+	m, engine = newTestModel(t)
+	if m.Update(testutil.KeyPress("U")); m.state.GetActiveComponent() != state.DocUpdateMany {
+		t.Fatalf("active component = %v, want DocUpdateMany", m.state.GetActiveComponent())
+	}
+	m.state.SetActiveComponent(state.DocList) // The editor is opened by the mainview
+
+	filter := bson.D{{Key: "n", Value: bson.D{{Key: "$lt", Value: 5}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "flagged", Value: true}}}}
+	_, cmd := m.Update(modal.ExecDocUpdateMany{DbName: "shop", CollectionName: "items", Filter: filter, Update: update})
+	updated, ok := cmd().(mongoengine.DocsUpdatedMsg)
+	if !ok || updated.Matched != 5 || updated.Modified != 5 {
+		t.Fatalf("update returned %#v, want 5 matched and modified", updated)
+	}
+	_, cmd = m.Update(updated)
+	if msg := cmd(); msg != (mongoengine.RedrawMessage{}) {
+		t.Fatalf("query after the update returned %#v", msg)
+	}
+	if flagged := (*engine.GetQueriedDocs()[0])["flagged"]; flagged != true {
+		t.Errorf("first doc flagged = %v, want true", flagged)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		msg           tea.Msg
+		wantFollowing bool
+		wantErr       bool
+		wantInView    string
+	}{
+		{name: "follow", keys: []string{"f"}, wantFollowing: true, wantInView: "following shop.items (0 events)"},
+		{name: "stop following", keys: []string{"f", "f"}, wantInView: "viewing documents 1-25"},
+		{name: "stream fails", keys: []string{"f"}, msg: mongoengine.FollowStoppedMsg{Err: fmt.Errorf("not a replica set")}, wantErr: true, wantInView: "viewing documents 1-25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			defer engine.StopFollowing()
+			for _, k := range tt.keys {
+				m.Update(testutil.KeyPress(k)) // The command is not run as it would block waiting for a change
+			}
+			if tt.msg != nil {
+				engine.StopFollowing() // The engine stops following before the stream reports the failure
+				_, cmd := m.Update(tt.msg)
+				if _, isErr := cmd().(modal.ErrModalMsg); isErr != tt.wantErr {
+					t.Errorf("%T returned an error = %v, want %v", tt.msg, isErr, tt.wantErr)
+				}
+			}
+			if m.following != tt.wantFollowing {
+				t.Errorf("following = %v, want %v", m.following, tt.wantFollowing)
+			}
+			if view := m.View(); !strings.Contains(view, tt.wantInView) {
+				t.Errorf("View() does not contain %q:\n%s", tt.wantInView, view)
+			}
+		})
+	}
+}
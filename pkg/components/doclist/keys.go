@@ -15,9 +15,25 @@ type keyMap struct {
 	PrevPage   key.Binding // pagination
 	Left       key.Binding
 	Insert     key.Binding
+	Clone      key.Binding
 	Edit       key.Binding
 	View       key.Binding
 	Delete     key.Binding
+	DeleteMany key.Binding
+	UpdateMany key.Binding
+	Undo       key.Binding
+	Aggregate  key.Binding
+	Pagination key.Binding
+	CountMode  key.Binding
+	Explain    key.Binding
+	Schema     key.Binding
+	Follow     key.Binding
+	Stage      key.Binding
+	Review     key.Binding
+	Export     key.Binding
+	YankDoc    key.Binding
+	YankId     key.Binding
+	YankField  key.Binding
 }
 
 // HelpView is a helper method for rendering the help menu from the keymap.
@@ -28,7 +44,7 @@ func (m *Model) HelpView() string {
 }
 
 func (km keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{km.Quit, km.LineUp, km.LineDown, km.PrevPage, km.NextPage, km.Delete, km.Insert, km.Edit, km.View}
+	return []key.Binding{km.Quit, km.LineUp, km.LineDown, km.PrevPage, km.NextPage, km.Delete, km.DeleteMany, km.Insert, km.Clone, km.Edit, km.UpdateMany, km.Undo, km.View, km.Aggregate, km.Pagination, km.CountMode, km.Explain, km.Schema, km.Follow, km.Stage, km.Review, km.Export, km.YankDoc, km.YankId, km.YankField}
 }
 
 // FullHelp is needed to satisfy the keyMap interface
@@ -75,6 +91,10 @@ var keys = keyMap{
 		key.WithKeys("i"),
 		key.WithHelp("i", "insert"),
 	),
+	Clone: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "clone"),
+	),
 	Edit: key.NewBinding(
 		key.WithKeys("e"),
 		key.WithHelp("e", "edit"),
@@ -87,4 +107,64 @@ var keys = keyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete"),
 	),
+	DeleteMany: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "delete matching"),
+	),
+	UpdateMany: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "update matching"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Aggregate: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "find/aggregate"),
+	),
+	Pagination: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "skip/keyset pages"),
+	),
+	CountMode: key.NewBinding(
+		key.WithKeys("#"),
+		key.WithHelp("#", "count mode"),
+	),
+	Explain: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "explain"),
+	),
+	Schema: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "schema"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "follow changes"),
+	),
+	Stage: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "toggle staging"),
+	),
+	Review: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "staged changes"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export"),
+	),
+	YankDoc: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy doc"),
+	),
+	YankId: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy _id"),
+	),
+	YankField: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "copy field"),
+	),
 }
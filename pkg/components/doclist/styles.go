@@ -7,6 +7,8 @@ type Styles struct {
 	Doc         lipgloss.Style
 	SelectedDoc lipgloss.Style
 	DocText     lipgloss.Style
+
+	SelectedEvent lipgloss.Style
 }
 
 func defaultStyles() Styles {
@@ -22,5 +24,8 @@ func defaultStyles() Styles {
 			BorderForeground(lipgloss.Color("240")),
 		DocText: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("71")),
+		SelectedEvent: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("57")),
 	}
 }
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/clipboard"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
 	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
 	"github.com/kreulenk/mongotui/pkg/renderutils"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"strings"
@@ -26,6 +28,9 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.following {
+			return m, m.updateFollowing(msg)
+		}
 		switch {
 		case key.Matches(msg, keys.LineUp):
 			m.MoveUp(1)
@@ -47,10 +52,23 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 			m.state.SetActiveComponent(state.DbColTable)
 			m.blur()
 			m.searchBar.ResetValue()
-			return m, m.engine.QueryCollection(bson.D{})
+			return m, m.engine.QueryCollection(mongoengine.Query{})
 		case key.Matches(msg, keys.Insert):
 			m.state.SetActiveComponent(state.DocInsert)
+		case key.Matches(msg, keys.Clone):
+			if err := m.engine.CheckDocsWritable("clone"); err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
+			if len(m.engine.GetQueriedDocs()) > 0 {
+				m.engine.SetSelectedDocument(m.engine.GetQueriedDocs()[m.cursor])
+				m.state.SetActiveComponent(state.DocClone)
+			} else {
+				return m, modal.DisplayErrorModal(fmt.Errorf("cannot clone a document as none is selected"))
+			}
 		case key.Matches(msg, keys.Edit):
+			if err := m.engine.CheckDocsWritable("edit"); err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
 			if len(m.engine.GetDocumentSummaries()) > 0 {
 				m.EditDoc()
 			} else {
@@ -62,18 +80,136 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 			} else {
 				return m, modal.DisplayErrorModal(fmt.Errorf("cannot view a document as none is selected"))
 			}
+		case key.Matches(msg, keys.Aggregate):
+			m.searchBar.ToggleAggregationMode()
+			m.searchBar.Focus()
+			m.cursor = 0
+		case key.Matches(msg, keys.Pagination):
+			m.cursor = 0
+			return m, m.engine.TogglePaginationMode()
+		case key.Matches(msg, keys.CountMode):
+			return m, m.engine.CycleCountMode()
+		case key.Matches(msg, keys.Explain):
+			query, err := m.searchBar.GetValue()
+			if err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
+			return m, m.engine.Explain(query)
+		case key.Matches(msg, keys.Schema):
+			return m, m.engine.InferSchema()
+		case key.Matches(msg, keys.Follow):
+			m.following, m.followTail = true, true
+			m.cursor = 0
+			return m, m.engine.Follow()
 		case key.Matches(msg, keys.Delete):
+			if err := m.engine.CheckDocsWritable("delete"); err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
 			m.engine.SetSelectedDocument(m.engine.GetQueriedDocs()[m.cursor])
 			return m, modal.DisplayDocDeleteModal(m.engine.GetSelectedDocument())
+		case key.Matches(msg, keys.DeleteMany):
+			query, err := m.searchBar.GetValue()
+			if err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
+			return m, m.engine.PreviewDeleteMatching(query)
+		case key.Matches(msg, keys.Undo):
+			return m, m.engine.PreviewUndo()
+		case key.Matches(msg, keys.UpdateMany):
+			query, err := m.searchBar.GetValue()
+			if err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
+			if query.IsAggregation() {
+				return m, modal.DisplayErrorModal(fmt.Errorf("cannot update the documents matched by an aggregation, switch to a find filter first"))
+			}
+			if m.engine.IsStaging() {
+				return m, modal.DisplayErrorModal(fmt.Errorf("updating the documents matching a filter can not be staged, stop staging first"))
+			}
+			m.state.SetActiveComponent(state.DocUpdateMany)
+		case key.Matches(msg, keys.Stage):
+			return m, m.engine.ToggleStaging()
+		case key.Matches(msg, keys.Review):
+			m.state.SetActiveComponent(state.StagedChanges)
+		case key.Matches(msg, keys.Export):
+			query, err := m.searchBar.GetValue()
+			if err != nil {
+				return m, modal.DisplayErrorModal(err)
+			}
+			return m, m.exportForm(query)
+		case key.Matches(msg, keys.YankDoc, keys.YankId, keys.YankField):
+			if len(m.engine.GetQueriedDocs()) == 0 {
+				return m, modal.DisplayErrorModal(fmt.Errorf("cannot copy a document as none is selected"))
+			}
+			return m, yank(msg, m.engine.GetQueriedDocs()[m.cursor])
+		}
+	case mongoengine.ExplainReadyMsg:
+		m.state.SetActiveComponent(state.ExplainViewer)
+	case mongoengine.SchemaReadyMsg:
+		m.state.SetActiveComponent(state.SchemaViewer)
+	case mongoengine.ChangeEventMsg:
+		if m.followTail {
+			m.cursor = len(m.engine.GetChangeEvents()) - 1
+		}
+		return m, m.engine.NextChangeEvent(msg)
+	case mongoengine.FollowStoppedMsg:
+		m.following = m.engine.IsFollowing()
+		if msg.Err != nil {
+			return m, modal.DisplayErrorModal(msg.Err)
 		}
 	case modal.ExecDocDelete:
 		m.cursor = renderutils.Max(0, m.cursor-1)
 		return m, m.engine.DeleteDocument(msg.Doc)
+	case modal.ExecDocDeleteMany:
+		return m, m.engine.DeleteMatchingDocs(msg.DbName, msg.CollectionName, msg.Filter)
+	case mongoengine.DocsDeletedMsg:
+		return m, m.ExecuteQuery()
+	case modal.ExecDocUpdateMany:
+		return m, m.engine.UpdateMatchingDocs(msg.DbName, msg.CollectionName, msg.Filter, msg.Update)
+	case mongoengine.DocsUpdatedMsg, mongoengine.StagedCommittedMsg:
+		return m, m.engine.RerunLastCollectionQuery()
 	}
 
 	return m, nil
 }
 
+// updateFollowing handles the keys that apply to the list of change events displayed while following
+func (m *Model) updateFollowing(msg tea.KeyMsg) tea.Cmd {
+	lastEvent := len(m.engine.GetChangeEvents()) - 1
+	switch {
+	case key.Matches(msg, keys.LineUp):
+		m.cursor = renderutils.Clamp(m.cursor-1, 0, lastEvent)
+	case key.Matches(msg, keys.LineDown):
+		m.cursor = renderutils.Clamp(m.cursor+1, 0, lastEvent)
+	case key.Matches(msg, keys.GotoTop):
+		m.cursor = 0
+	case key.Matches(msg, keys.GotoBottom):
+		m.cursor = renderutils.Max(0, lastEvent)
+	case key.Matches(msg, keys.View):
+		if events := m.engine.GetChangeEvents(); m.cursor < len(events) {
+			m.state.SetActiveComponent(state.SingleDocViewer)
+			m.engine.SetSelectedDocument(events[m.cursor].Doc)
+		}
+	case key.Matches(msg, keys.Follow):
+		m.stopFollowing()
+		return m.engine.RerunLastCollectionQuery()
+	case key.Matches(msg, keys.Left):
+		m.stopFollowing()
+		m.state.SetActiveComponent(state.DbColTable)
+		m.blur()
+		m.searchBar.ResetValue()
+		return m.engine.QueryCollection(mongoengine.Query{})
+	}
+	m.followTail = m.cursor >= lastEvent
+	return nil
+}
+
+func (m *Model) stopFollowing() {
+	m.engine.StopFollowing()
+	m.following = false
+	m.cursor = 0
+}
+
 // MoveUp moves the selection up by any number of rows.
 // It can not go above the first row.
 func (m *Model) MoveUp(n int) {
@@ -111,6 +247,54 @@ func (m *Model) ExecuteQuery() tea.Cmd {
 	return m.engine.QueryCollection(val)
 }
 
+// GetQuery returns the query entered in the search bar, which may not have been run yet
+func (m *Model) GetQuery() (mongoengine.Query, error) {
+	return m.searchBar.GetValue()
+}
+
+// yank copies doc, its _id or the value of one of its fields depending on which of the yank keys was pressed
+func yank(msg tea.KeyMsg, doc *bson.M) tea.Cmd {
+	switch {
+	case key.Matches(msg, keys.YankId):
+		return clipboard.CopyDocument(doc, "_id")
+	case key.Matches(msg, keys.YankField):
+		return clipboard.CopyFieldForm(doc)
+	default:
+		return clipboard.CopyDocument(doc, "")
+	}
+}
+
+// exportForm asks where and how every document returned by query should be exported
+func (m *Model) exportForm(query mongoengine.Query) tea.Cmd {
+	_, collName := m.engine.GetSelectedCollection()
+	fields := []modal.FormField{
+		{Label: "File", Value: collName + ".json"},
+		{Label: "Format", Value: "json", Hint: "json, ndjson or csv"},
+		{Label: "JSON mode", Value: "relaxed", Hint: "plain, relaxed or canonical"},
+	}
+	return modal.DisplayFormModal("Export the documents matching the query", fields, func(values []string) tea.Cmd {
+		opts, err := mongoengine.ParseExportOptions(values[0], values[1], values[2])
+		if err != nil {
+			return modal.DisplayErrorModal(err)
+		}
+		if !opts.FileExists() {
+			return m.engine.Export(query, opts)
+		}
+		confirm := []modal.FormField{{Label: "Overwrite", Value: "no", Hint: "yes or no"}}
+		return modal.DisplayFormModal(opts.Path+" already exists", confirm, func(values []string) tea.Cmd {
+			overwrite, err := modal.ParseYesNo("overwrite", values[0])
+			if err != nil {
+				return modal.DisplayErrorModal(err)
+			}
+			if !overwrite {
+				return nil
+			}
+			opts.Overwrite = true
+			return m.engine.Export(query, opts)
+		})
+	})
+}
+
 func (m *Model) EditDoc() {
 	m.state.SetActiveComponent(state.SingleDocEditor)
 	m.engine.SetSelectedDocument(m.engine.GetQueriedDocs()[m.cursor])
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
 	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
 )
 
 // View renders the component.
@@ -31,9 +34,13 @@ func (m *Model) IsDocSelected() bool {
 // updateViewport updates the list content based on the previously defined
 // columns and rows.
 func (m *Model) updateViewport() {
+	if m.following {
+		m.viewport.SetContent(m.changeEventsView())
+		return
+	}
 	renderedRows := make([]string, 0, len(m.engine.GetDocumentSummaries()))
 	var startDocIndex = m.getStartIndex()
-	heightLeft := m.viewport.Height - 2 // 2 to account for search bar and pagination info
+	heightLeft := m.viewport.Height - m.searchBar.Height() - 1 // 1 to account for pagination info
 
 	for i := startDocIndex; i < len(m.engine.GetDocumentSummaries()) && heightLeft >= 3; i++ { // 3 as one cell is minimum 3 lines
 		newRow, heightUsed := m.renderDocSummary(i, heightLeft)
@@ -45,17 +52,9 @@ func (m *Model) updateViewport() {
 		joinedRows = "\nNo documents found" // TODO make this centered
 	}
 
-	if m.engine.DocCount > 0 {
-		var paginationTracker string
-		if m.engine.DocCount == 1 {
-			paginationTracker = "viewing document 1 of 1"
-		} else if m.engine.Skip+mongoengine.Limit > m.engine.DocCount {
-			paginationTracker = fmt.Sprintf("viewing documents %d-%d of %d", m.engine.Skip+1, m.engine.DocCount, m.engine.DocCount)
-		} else {
-			paginationTracker = fmt.Sprintf("viewing documents %d-%d of %d", m.engine.Skip+1, m.engine.Skip+mongoengine.Limit, m.engine.DocCount)
-		}
+	if len(m.engine.GetDocumentSummaries()) > 0 {
 		m.viewport.SetContent(
-			lipgloss.JoinVertical(lipgloss.Top, m.searchBar.View(), lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Right, paginationTracker), joinedRows))
+			lipgloss.JoinVertical(lipgloss.Top, m.searchBar.View(), lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Right, m.paginationTracker()), joinedRows))
 	} else {
 		m.viewport.SetContent(
 			lipgloss.JoinVertical(lipgloss.Top, m.searchBar.View(), joinedRows),
@@ -63,6 +62,36 @@ func (m *Model) updateViewport() {
 	}
 }
 
+// paginationTracker describes which docs are displayed out of the total, along with how that total was counted
+func (m *Model) paginationTracker() string {
+	first := m.engine.Skip + 1
+	last := m.engine.Skip + int64(len(m.engine.GetDocumentSummaries()))
+	var tracker string
+	if first == last {
+		tracker = fmt.Sprintf("viewing document %d", first)
+	} else {
+		tracker = fmt.Sprintf("viewing documents %d-%d", first, last)
+	}
+
+	switch countKind := m.engine.GetDocCountKind(); countKind {
+	case mongoengine.CountExact:
+		tracker += fmt.Sprintf(" of %d", m.engine.DocCount)
+	case mongoengine.CountEstimated:
+		tracker += fmt.Sprintf(" of ~%d (%s)", m.engine.DocCount, countKind)
+	case mongoengine.CountCapped:
+		tracker += fmt.Sprintf(" of %d+ (%s)", m.engine.DocCount, countKind)
+	case mongoengine.CountNone:
+		tracker += fmt.Sprintf(" (%s)", countKind)
+	}
+
+	if mode, keysetPaginated := m.engine.GetPaginationMode(); keysetPaginated {
+		tracker += " (keyset)"
+	} else if mode == mongoengine.KeysetPagination { // The sort of the query can not be paginated by keyset
+		tracker += " (skip fallback)"
+	}
+	return tracker
+}
+
 func (m *Model) renderDocSummary(docIndex, heightLeft int) (string, int) {
 	heightLeft -= 2 // To account for the space between rows
 	doc := m.engine.GetDocumentSummaries()[docIndex]
@@ -98,7 +127,7 @@ func (m *Model) getStartIndex() int {
 	if len(m.engine.GetDocumentSummaries()) == 0 {
 		return 0
 	}
-	heightLeft := m.viewport.Height - 3 // 1 to account for the search bar and top/bottom borders
+	heightLeft := m.viewport.Height - m.searchBar.Height() - 2 // 2 to account for the top/bottom borders
 	startIndex := m.cursor
 	for i := m.cursor; i >= 0 && heightLeft > 0; i-- {
 		heightLeft -= 2 // To account for the space between rows from borders
@@ -113,3 +142,29 @@ func (m *Model) getStartIndex() int {
 	}
 	return startIndex
 }
+
+// changeEventsView lists the change events received while following the collection with one event per line
+func (m *Model) changeEventsView() string {
+	dbName, collName := m.engine.GetSelectedCollection()
+	events := m.engine.GetChangeEvents()
+	header := fmt.Sprintf("following %s.%s (%d events)", dbName, collName, len(events))
+	rows := []string{lipgloss.PlaceHorizontal(m.viewport.Width, lipgloss.Right, header)}
+	if len(events) == 0 {
+		rows = append(rows, "\nWaiting for changes…")
+	}
+
+	rowsHeight := m.viewport.Height - 3 // Borders and header
+	start := renderutils.Clamp(m.cursor-rowsHeight+1, 0, m.cursor)
+	for i := start; i < len(events) && i < start+rowsHeight; i++ {
+		event := events[i]
+		documentKey, _ := bson.MarshalExtJSON(event.DocumentKey, false, false)
+		line := runewidth.Truncate(
+			fmt.Sprintf("%s  %-8s %s", event.Time.Format(time.TimeOnly), event.OperationType, documentKey),
+			m.viewport.Width-2, "…")
+		if i == m.cursor && m.focused {
+			line = m.styles.SelectedEvent.Width(m.viewport.Width - 2).Render(line)
+		}
+		rows = append(rows, line)
+	}
+	return lipgloss.JoinVertical(lipgloss.Top, rows...)
+}
@@ -4,6 +4,7 @@
 package editor
 
 import (
+	"bytes"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
@@ -32,26 +33,61 @@ func (e Editor) EditDoc() tea.Cmd {
 	if err != nil {
 		return modal.DisplayErrorModal(err)
 	}
-	newDoc, err := e.openFileInEditor(oldDoc)
-	if err != nil {
-		return modal.DisplayErrorModal(err)
-	}
-
 	var oldDocBson bson.M
 	if err := bson.UnmarshalExtJSON(oldDoc, false, &oldDocBson); err != nil {
 		return modal.DisplayErrorModal(fmt.Errorf("failed to parse the original document needed for the replacement: %w", err))
 	}
+	return e.editAgainst(oldDocBson, oldDoc)
+}
+
+// ReopenDoc opens the edits that conflicted with a change made by someone else in the editor again. Once saved,
+// they replace currentDoc, the version of the document that was made by someone else
+func (e Editor) ReopenDoc(currentDoc, newDoc bson.M) tea.Cmd {
+	newDocBytes, err := bson.MarshalExtJSONIndent(newDoc, false, false, "", "  ")
+	if err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to marshal your edits: %w", err))
+	}
+	return e.editAgainst(currentDoc, newDocBytes)
+}
+
+// editAgainst opens doc in the editor and asks the user to confirm that the result should replace oldDoc
+func (e Editor) editAgainst(oldDoc bson.M, doc []byte) tea.Cmd {
+	newDoc, err := e.openFileInEditor(doc)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+
 	var newDocBson bson.M
 	if err := bson.UnmarshalExtJSON(newDoc, false, &newDocBson); err != nil {
 		return modal.DisplayErrorModal(fmt.Errorf("failed to parse the new document needed for the replacement: %w", err))
 	}
 
-	return modal.DisplayDocEditModal(oldDocBson, newDocBson)
+	return modal.DisplayDocEditModal(oldDoc, newDocBson)
 }
 
 func (e Editor) InsertDoc() tea.Cmd {
 	newDoc := make(bson.M)
 	newDoc["_id"] = bson.NewObjectID()
+	return e.insertFrom(newDoc)
+}
+
+// CloneDoc opens a copy of the selected document with a new _id in the editor so that it can be tweaked before it
+// is inserted
+func (e Editor) CloneDoc() tea.Cmd {
+	selectedDoc, err := e.engine.GetSelectedDocumentMarshalled()
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	var clone bson.M
+	if err := bson.UnmarshalExtJSON(selectedDoc, false, &clone); err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to parse the document to clone: %w", err))
+	}
+	clone["_id"] = bson.NewObjectID()
+	return e.insertFrom(clone)
+}
+
+// insertFrom opens newDoc in the editor and asks the user to confirm that the result should be inserted
+func (e Editor) insertFrom(newDoc bson.M) tea.Cmd {
 	newDocBytes, err := bson.MarshalExtJSONIndent(newDoc, false, false, "", "  ")
 	if err != nil {
 		return modal.DisplayErrorModal(fmt.Errorf("failed to marshal new document: %w", err))
@@ -70,6 +106,144 @@ func (e Editor) InsertDoc() tea.Cmd {
 	return modal.DisplayDocInsertModal(editedDoc)
 }
 
+// CreateIndex opens a template index spec in the editor. Any option of the createIndexes command, such as
+// expireAfterSeconds or partialFilterExpression, can be added to the spec
+func (e Editor) CreateIndex() tea.Cmd {
+	template := bson.D{
+		{Key: "key", Value: bson.D{}},
+		{Key: "name", Value: ""},
+		{Key: "unique", Value: false},
+		{Key: "sparse", Value: false},
+	}
+	templateBytes, err := bson.MarshalExtJSONIndent(template, false, false, "", "  ")
+	if err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to marshal index template: %w", err))
+	}
+
+	editedSpecBytes, err := e.openFileInEditor(templateBytes)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+
+	var editedSpec bson.D
+	if err := bson.UnmarshalExtJSON(editedSpecBytes, false, &editedSpec); err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to unmarshal index spec: %w", err))
+	}
+
+	return modal.DisplayIndexCreateModal(editedSpec)
+}
+
+// CreateCollection opens a template of the options of the create command in the editor. Options left as null are
+// not sent. Setting viewOn and pipeline creates a view rather than a collection
+func (e Editor) CreateCollection() tea.Cmd {
+	dbName, _ := e.engine.GetSelectedCollection()
+	template := bson.D{
+		{Key: "database", Value: dbName},
+		{Key: "collection", Value: ""},
+		{Key: "options", Value: bson.D{
+			{Key: "capped", Value: nil},
+			{Key: "size", Value: nil},
+			{Key: "max", Value: nil},
+			{Key: "timeseries", Value: bson.D{
+				{Key: "timeField", Value: nil},
+				{Key: "metaField", Value: nil},
+				{Key: "granularity", Value: nil},
+			}},
+			{Key: "expireAfterSeconds", Value: nil},
+			{Key: "clusteredIndex", Value: bson.D{
+				{Key: "key", Value: nil},
+				{Key: "unique", Value: nil},
+			}},
+			{Key: "validator", Value: nil},
+			{Key: "validationLevel", Value: nil},
+			{Key: "validationAction", Value: nil},
+			{Key: "viewOn", Value: nil},
+			{Key: "pipeline", Value: nil},
+		}},
+	}
+	templateBytes, err := bson.MarshalExtJSONIndent(template, false, false, "", "  ")
+	if err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to marshal collection template: %w", err))
+	}
+
+	editedBytes, err := e.openFileInEditor(templateBytes)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+
+	var edited struct {
+		Database   string `bson:"database"`
+		Collection string `bson:"collection"`
+		Options    bson.D `bson:"options"`
+	}
+	if err := bson.UnmarshalExtJSON(editedBytes, false, &edited); err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to unmarshal collection options: %w", err))
+	}
+	if edited.Database == "" || edited.Collection == "" {
+		return modal.DisplayErrorModal(fmt.Errorf("a database and collection name are required"))
+	}
+
+	return modal.DisplayCollectionCreateModal(edited.Database, edited.Collection, edited.Options)
+}
+
+// UpdateMatching opens a template update document in the editor. Operators left empty are dropped. The document can
+// be replaced with an array of stages for a pipeline update. The update is previewed before it is confirmed
+func (e Editor) UpdateMatching(query mongoengine.Query) tea.Cmd {
+	template := bson.D{
+		{Key: "$set", Value: bson.D{}},
+		{Key: "$unset", Value: bson.D{}},
+		{Key: "$inc", Value: bson.D{}},
+		{Key: "$rename", Value: bson.D{}},
+	}
+	templateBytes, err := bson.MarshalExtJSONIndent(template, false, false, "", "  ")
+	if err != nil {
+		return modal.DisplayErrorModal(fmt.Errorf("failed to marshal update template: %w", err))
+	}
+
+	editedBytes, err := e.openFileInEditor(templateBytes)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+
+	update, err := parseUpdate(editedBytes)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return e.engine.PreviewUpdateMatching(query, update)
+}
+
+// parseUpdate parses either a document of update operators or an update pipeline
+func parseUpdate(data []byte) (any, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var wrapped struct {
+			Pipeline []bson.D `bson:"pipeline"`
+		}
+		if err := bson.UnmarshalExtJSON([]byte(fmt.Sprintf(`{"pipeline": %s}`, trimmed)), false, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal update pipeline: %w", err)
+		}
+		if len(wrapped.Pipeline) == 0 {
+			return nil, fmt.Errorf("the update pipeline is empty")
+		}
+		return wrapped.Pipeline, nil
+	}
+
+	var operators bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &operators); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal update: %w", err)
+	}
+	var update bson.D
+	for _, op := range operators {
+		if fields, ok := op.Value.(bson.D); ok && len(fields) == 0 {
+			continue
+		}
+		update = append(update, op)
+	}
+	if len(update) == 0 {
+		return nil, fmt.Errorf("the update is empty")
+	}
+	return update, nil
+}
+
 func (e Editor) openFileInEditor(doc []byte) ([]byte, error) {
 	file := filepath.Join(os.TempDir(), "mongoEdit.json")
 	if err := os.WriteFile(file, doc, 0600); err != nil {
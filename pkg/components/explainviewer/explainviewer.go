@@ -0,0 +1,134 @@
+// The explainviewer package displays the plan of the last query run through mongoengine.Explain. A summary of
+// how the docs were found is displayed above the winning plan
+
+package explainviewer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/quick"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Model struct {
+	state    *state.MainViewState
+	Viewport viewport.Model
+	Help     help.Model
+	styles   Styles
+
+	engine *mongoengine.Engine
+}
+
+func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
+	return &Model{
+		state:    state,
+		Viewport: viewport.New(0, 0),
+		Help:     help.New(),
+		styles:   defaultStyles(),
+		engine:   engine,
+	}
+}
+
+// Focus renders the plan that was last cached by the engine
+func (m *Model) Focus() error {
+	m.Viewport.GotoTop()
+	explain := m.engine.GetExplain()
+	if explain == nil {
+		return fmt.Errorf("no query has been explained")
+	}
+
+	plan, err := bson.MarshalExtJSONIndent(explain.WinningPlan, false, false, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not parse winning plan: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := quick.Highlight(buf, string(plan), "json", "terminal256", "dracula"); err != nil {
+		return fmt.Errorf("could not highlight json: %v", err)
+	}
+
+	renderedContent := lipgloss.NewStyle().
+		Width(m.Viewport.Width).
+		Render(lipgloss.JoinVertical(lipgloss.Top,
+			m.styles.Header.Render("Summary"),
+			m.summaryView(explain.Summary),
+			"",
+			m.styles.Header.Render("Winning plan"),
+			buf.String(),
+		))
+	m.Viewport.SetContent(renderedContent)
+	return nil
+}
+
+// summaryView highlights whether the query had to scan the entire collection as well as how many docs were
+// examined to find the ones that were returned
+func (m *Model) summaryView(summary mongoengine.ExplainSummary) string {
+	var scan string
+	switch {
+	case summary.CollectionScan:
+		scan = m.styles.CollectionScan.Render("COLLSCAN")
+	case len(summary.Indexes) > 0:
+		scan = m.styles.IndexScan.Render("IXSCAN")
+	case len(summary.Stages) > 0:
+		scan = summary.Stages[len(summary.Stages)-1]
+	}
+
+	indexes := "none"
+	if len(summary.Indexes) > 0 {
+		indexes = strings.Join(summary.Indexes, ", ")
+	}
+
+	examined := fmt.Sprintf("%d docs, %d keys", summary.DocsExamined, summary.KeysExamined)
+	if summary.DocsExamined > summary.DocsReturned { // Some of the docs were read only to be filtered out
+		examined = m.styles.Warning.Render(examined)
+	}
+
+	rows := [][2]string{
+		{"Scan", scan},
+		{"Index", indexes},
+		{"Stages", strings.Join(summary.Stages, " → ")},
+		{"Examined", examined},
+		{"Returned", fmt.Sprintf("%d docs", summary.DocsReturned)},
+		{"Time", fmt.Sprintf("%dms", summary.ExecutionTimeMillis)},
+	}
+	var renderedRows []string
+	for _, row := range rows {
+		renderedRows = append(renderedRows, m.styles.Label.Render(row[0])+row[1])
+	}
+	return lipgloss.JoinVertical(lipgloss.Top, renderedRows...)
+}
+
+func (m *Model) SetWidth(w int) {
+	m.Viewport.Width = w - 1
+}
+
+func (m *Model) SetHeight(h int) {
+	m.Viewport.Height = h - 1 // 1 line for help menu
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.state.SetActiveComponent(state.DocList)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Viewport, cmd = m.Viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) View() string {
+	return lipgloss.JoinVertical(lipgloss.Top, m.Viewport.View(), m.Help.View(keys))
+}
@@ -0,0 +1,37 @@
+package explainviewer
+
+import "github.com/charmbracelet/lipgloss"
+
+type Styles struct {
+	Header         lipgloss.Style
+	Label          lipgloss.Style
+	CollectionScan lipgloss.Style
+	IndexScan      lipgloss.Style
+	Warning        lipgloss.Style
+}
+
+func defaultStyles() Styles {
+	return Styles{
+		Header: lipgloss.NewStyle().
+			Bold(true).
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			BorderBottom(true),
+		Label: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("57")).
+			Bold(true).
+			Width(10),
+		CollectionScan: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("160")).
+			Bold(true).
+			Padding(0, 1),
+		IndexScan: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("28")).
+			Bold(true).
+			Padding(0, 1),
+		Warning: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")),
+	}
+}
@@ -0,0 +1,83 @@
+// The indexlist package lists the indexes of the collection cursored in the dbcoltable and allows them to be
+// created and dropped
+
+package indexlist
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+)
+
+type Model struct {
+	state  *state.MainViewState
+	Help   help.Model
+	styles Styles
+
+	width  int
+	height int
+	cursor int
+
+	engine *mongoengine.Engine
+}
+
+func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
+	return &Model{
+		state:  state,
+		Help:   help.New(),
+		styles: defaultStyles(),
+		engine: engine,
+	}
+}
+
+// Focus resets the cursor whenever the indexes of a different collection are about to be displayed
+func (m *Model) Focus() {
+	m.cursor = 0
+}
+
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	m.Help.Width = w
+}
+
+func (m *Model) SetHeight(h int) {
+	m.height = h
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.state.SetActiveComponent(state.DbColTable)
+		case key.Matches(msg, keys.LineUp):
+			m.cursor = renderutils.Max(0, m.cursor-1)
+		case key.Matches(msg, keys.LineDown):
+			m.cursor = renderutils.Clamp(m.cursor+1, 0, len(m.engine.GetIndexes())-1)
+		case key.Matches(msg, keys.Create):
+			m.state.SetActiveComponent(state.IndexCreate)
+		case key.Matches(msg, keys.Drop):
+			if index, ok := m.cursoredIndex(); ok {
+				return m, modal.DisplayIndexDropModal(index.Name)
+			}
+		case key.Matches(msg, keys.Refresh):
+			return m, m.engine.LoadIndexes()
+		}
+	case modal.ExecIndexDrop:
+		m.cursor = renderutils.Max(0, m.cursor-1)
+		return m, m.engine.DropIndex(msg.IndexName)
+	}
+	return m, nil
+}
+
+func (m *Model) cursoredIndex() (mongoengine.Index, bool) {
+	indexes := m.engine.GetIndexes()
+	if m.cursor < 0 || m.cursor >= len(indexes) {
+		return mongoengine.Index{}, false
+	}
+	return indexes[m.cursor], true
+}
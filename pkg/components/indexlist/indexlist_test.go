@@ -0,0 +1,93 @@
+package indexlist
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+func newTestModel(t *testing.T) (*Model, *mongoengine.Engine) {
+	t.Helper()
+	engine := testutil.NewEngine(t, map[string][]any{"shop.users": {bson.M{"name": "Kevin"}}})
+	engine.SetSelectedCollection("shop", "users")
+
+	s := state.DefaultState()
+	s.SetActiveComponent(state.IndexList)
+	m := New(engine, s)
+	m.SetWidth(160)
+	m.SetHeight(20)
+	return m, engine
+}
+
+func TestIndexes(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        bson.D
+		drop        string
+		wantErr     bool
+		wantIndexes []string
+		wantInView  string
+	}{
+		{name: "only the _id index", wantIndexes: []string{"_id_"}, wantInView: `{"_id":1}`},
+		{
+			name:        "create with a generated name",
+			spec:        bson.D{{Key: "key", Value: bson.D{{Key: "name", Value: 1}, {Key: "age", Value: -1}}}, {Key: "unique", Value: true}},
+			wantIndexes: []string{"_id_", "name_1_age_-1"},
+			wantInView:  "unique",
+		},
+		{
+			name:        "create a TTL index",
+			spec:        bson.D{{Key: "key", Value: bson.D{{Key: "createdAt", Value: 1}}}, {Key: "name", Value: "expiry"}, {Key: "expireAfterSeconds", Value: 3600}},
+			wantIndexes: []string{"_id_", "expiry"},
+			wantInView:  "TTL 3600s",
+		},
+		{name: "create without keys", spec: bson.D{{Key: "key", Value: bson.D{}}}, wantErr: true, wantIndexes: []string{"_id_"}},
+		{name: "drop", spec: bson.D{{Key: "key", Value: bson.D{{Key: "name", Value: 1}}}}, drop: "name_1", wantIndexes: []string{"_id_"}},
+		{name: "drop the _id index", drop: "_id_", wantErr: true, wantIndexes: []string{"_id_"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, engine := newTestModel(t)
+			var msgs []tea.Msg
+			msgs = append(msgs, engine.LoadIndexes()())
+			if tt.spec != nil {
+				msgs = append(msgs, engine.CreateIndex(tt.spec)())
+			}
+			if tt.drop != "" {
+				m.cursor = len(engine.GetIndexes()) - 1
+				_, cmd := m.Update(testutil.KeyPress("d"))
+				if _, ok := cmd().(modal.IndexDropModalMsg); !ok {
+					t.Fatalf("drop key should display the drop modal")
+				}
+				_, cmd = m.Update(modal.ExecIndexDrop{IndexName: tt.drop})
+				msgs = append(msgs, cmd())
+			}
+
+			var gotErr bool
+			for _, msg := range msgs {
+				if _, ok := msg.(modal.ErrModalMsg); ok {
+					gotErr = true
+				}
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("got error = %v, want %v", gotErr, tt.wantErr)
+			}
+			var gotIndexes []string
+			for _, index := range engine.GetIndexes() {
+				gotIndexes = append(gotIndexes, index.Name)
+			}
+			if strings.Join(gotIndexes, ",") != strings.Join(tt.wantIndexes, ",") {
+				t.Errorf("GetIndexes() = %v, want %v", gotIndexes, tt.wantIndexes)
+			}
+			if view := m.View(); !strings.Contains(view, tt.wantInView) {
+				t.Errorf("View() does not contain %q:\n%s", tt.wantInView, view)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package indexlist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// columns are the headers of the index table along with the share of the width each column takes up
+var columns = []struct {
+	title string
+	share int
+}{
+	{"Name", 20},
+	{"Keys", 25},
+	{"Options", 25},
+	{"Size", 10},
+	{"Usage", 20},
+}
+
+func (m *Model) View() string {
+	dbName, collName := m.engine.GetSelectedCollection()
+	indexes := m.engine.GetIndexes()
+	title := m.styles.Title.Render(fmt.Sprintf("Indexes of %s.%s (%d)", dbName, collName, len(indexes)))
+
+	var headers []string
+	for i, column := range columns {
+		headers = append(headers, m.styles.Header.Width(m.columnWidth(i)).Render(column.title))
+	}
+
+	rowsHeight := m.height - 6 // Borders, title, headers and help
+	start := renderutils.Clamp(m.cursor-rowsHeight+1, 0, m.cursor)
+	rows := []string{title, lipgloss.JoinHorizontal(lipgloss.Top, headers...)}
+	for i := start; i < len(indexes) && i < start+rowsHeight; i++ {
+		rows = append(rows, m.renderIndex(i, indexes[i]))
+	}
+	table := lipgloss.NewStyle().Height(m.height - 3).Render(lipgloss.JoinVertical(lipgloss.Top, rows...))
+	return lipgloss.JoinVertical(lipgloss.Top, m.styles.Table.Render(table), m.Help.View(keys))
+}
+
+func (m *Model) renderIndex(i int, index mongoengine.Index) string {
+	cells := []string{index.Name, marshalCompact(index.Keys), optionsText(index), sizeText(index.Size), usageText(index)}
+	var renderedCells []string
+	for c, cell := range cells {
+		width := m.columnWidth(c)
+		renderedCells = append(renderedCells, m.styles.Cell.Width(width).Render(runewidth.Truncate(cell, width-2, "…")))
+	}
+	row := lipgloss.JoinHorizontal(lipgloss.Top, renderedCells...)
+	if i == m.cursor {
+		row = m.styles.Selected.Render(row)
+	}
+	return row
+}
+
+func (m *Model) columnWidth(i int) int {
+	return (m.width - 2) * columns[i].share / 100
+}
+
+// optionsText lists the options that change how an index behaves
+func optionsText(index mongoengine.Index) string {
+	var options []string
+	if index.Unique {
+		options = append(options, "unique")
+	}
+	if index.Sparse {
+		options = append(options, "sparse")
+	}
+	if index.ExpireAfterSeconds != nil {
+		options = append(options, fmt.Sprintf("TTL %ds", *index.ExpireAfterSeconds))
+	}
+	if len(index.PartialFilterExpression) > 0 {
+		options = append(options, "partial "+marshalCompact(index.PartialFilterExpression))
+	}
+	return strings.Join(options, ", ")
+}
+
+func sizeText(size int64) string {
+	if size < 0 {
+		return "-"
+	}
+	return renderutils.FormatBytes(size)
+}
+
+func usageText(index mongoengine.Index) string {
+	if !index.HasUsage {
+		return "-"
+	}
+	return fmt.Sprintf("%d ops since %s", index.Ops, index.Since.Format("2006-01-02"))
+}
+
+func marshalCompact(doc bson.D) string {
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return fmt.Sprintf("%v", doc)
+	}
+	return string(data)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/clipboard"
 	"github.com/kreulenk/mongotui/pkg/mainview/state"
 	"github.com/kreulenk/mongotui/pkg/mongoengine"
 )
@@ -18,6 +19,7 @@ type Model struct {
 	state    *state.MainViewState
 	Viewport viewport.Model
 	Help     help.Model
+	status   string // Shown beside the help menu until the next key press, such as after a value is copied
 
 	engine *mongoengine.Engine
 }
@@ -35,6 +37,7 @@ func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
 
 func (m *Model) Focus() error {
 	m.Viewport.GotoTop()
+	m.status = ""
 	selectedDoc, err := m.engine.GetSelectedDocumentMarshalled()
 	if err != nil {
 		return fmt.Errorf("could not fetch selected document: %v", err)
@@ -65,11 +68,21 @@ func (m *Model) SetHeight(h int) {
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.status = ""
 		switch {
 		case key.Matches(msg, keys.Back):
 			m.state.SetActiveComponent(state.DocList)
 			return m, nil
+		case key.Matches(msg, keys.YankDoc):
+			return m, clipboard.CopyDocument(m.engine.GetSelectedDocument(), "")
+		case key.Matches(msg, keys.YankId):
+			return m, clipboard.CopyDocument(m.engine.GetSelectedDocument(), "_id")
+		case key.Matches(msg, keys.YankField):
+			return m, clipboard.CopyFieldForm(m.engine.GetSelectedDocument())
 		}
+	case clipboard.CopiedMsg:
+		m.status = fmt.Sprintf(" (copied %s to the clipboard)", msg.Description)
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -78,5 +91,5 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
-	return lipgloss.JoinVertical(lipgloss.Top, m.Viewport.View(), m.Help.View(keys))
+	return lipgloss.JoinVertical(lipgloss.Top, m.Viewport.View(), lipgloss.JoinHorizontal(lipgloss.Left, m.Help.View(keys), m.status))
 }
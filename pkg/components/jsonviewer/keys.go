@@ -5,13 +5,16 @@ import "github.com/charmbracelet/bubbles/key"
 // keyMap defines keybindings. It satisfies to the help.KeyMap interface, which
 // is used to render the help menu.
 type keyMap struct {
-	Back     key.Binding
-	LineUp   key.Binding
-	LineDown key.Binding
+	Back      key.Binding
+	LineUp    key.Binding
+	LineDown  key.Binding
+	YankDoc   key.Binding
+	YankId    key.Binding
+	YankField key.Binding
 }
 
 func (km keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{km.LineUp, km.LineDown, km.Back}
+	return []key.Binding{km.LineUp, km.LineDown, km.YankDoc, km.YankId, km.YankField, km.Back}
 }
 
 // FullHelp is only used to satisfy the interface as we do not actually use this
@@ -34,4 +37,16 @@ var keys = keyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "down"),
 	),
+	YankDoc: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy doc"),
+	),
+	YankId: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy _id"),
+	),
+	YankField: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "copy field"),
+	),
 }
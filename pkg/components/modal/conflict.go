@@ -0,0 +1,123 @@
+package modal
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const conflictDiffHeight = 15 // The number of diff lines shown at once, the rest are reached by scrolling
+
+// conflictDiffLines lists every field path that differs between the loaded document and either their version or
+// yours. Each version is marked + when it added the path, - when it removed it and ~ when it changed its value.
+// Lines longer than the modal are wrapped rather than truncated
+func conflictDiffLines(msg *DocConflictModalMsg) []string {
+	base, theirs := flattenDoc(msg.loadedDoc), flattenDoc(msg.currentDoc)
+	var yours map[string]string
+	if msg.newDoc != nil {
+		yours = flattenDoc(msg.newDoc)
+	}
+
+	var paths []string
+	for _, fields := range []map[string]string{base, theirs, yours} {
+		for path := range fields {
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	var lines []string
+	for _, path := range paths {
+		theirsMark, yoursMark := diffMark(base, theirs, path), diffMark(base, yours, path)
+		if theirsMark == " " && (msg.newDoc == nil || yoursMark == " ") {
+			continue
+		}
+		lines = append(lines, path, "    base    "+fieldText(base, path), "  "+theirsMark+" theirs  "+fieldText(theirs, path))
+		if msg.newDoc != nil {
+			lines = append(lines, "  "+yoursMark+" yours   "+fieldText(yours, path))
+		}
+	}
+	if len(lines) == 0 { // Only the order of the fields changed
+		lines = append(lines, "The fields were reordered without changing their values")
+	}
+
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, strings.Split(runewidth.Wrap(line, previewWidth-2), "\n")...)
+	}
+	return wrapped
+}
+
+// diffMark describes how the version changed path compared to the loaded document
+func diffMark(base, version map[string]string, path string) string {
+	before, inBase := base[path]
+	after, inVersion := version[path]
+	switch {
+	case !inBase && inVersion:
+		return "+"
+	case inBase && !inVersion:
+		return "-"
+	case before != after:
+		return "~"
+	}
+	return " "
+}
+
+func fieldText(fields map[string]string, path string) string {
+	if text, ok := fields[path]; ok {
+		return text
+	}
+	return "(missing)"
+}
+
+// flattenDoc maps the dotted path of every value in doc to its relaxed ExtJSON text. Embedded documents and arrays
+// are walked into so that a change deep inside them is reported against the field that changed
+func flattenDoc(doc bson.M) map[string]string {
+	fields := make(map[string]string)
+	for key, value := range doc {
+		flattenValue(key, value, fields)
+	}
+	return fields
+}
+
+func flattenValue(path string, value any, fields map[string]string) {
+	switch v := value.(type) {
+	case bson.M:
+		if len(v) > 0 {
+			for key, elem := range v {
+				flattenValue(path+"."+key, elem, fields)
+			}
+			return
+		}
+	case bson.D:
+		if len(v) > 0 {
+			for _, elem := range v {
+				flattenValue(path+"."+elem.Key, elem.Value, fields)
+			}
+			return
+		}
+	case bson.A:
+		if len(v) > 0 {
+			for i, elem := range v {
+				flattenValue(path+"."+strconv.Itoa(i), elem, fields)
+			}
+			return
+		}
+	}
+	fields[path] = valueText(value)
+}
+
+// valueText renders a single value as relaxed ExtJSON by wrapping it in a document
+func valueText(value any) string {
+	text, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, false, false)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(text), `{"v":`), "}")
+}
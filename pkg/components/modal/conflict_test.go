@@ -0,0 +1,75 @@
+package modal
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+func TestConflictDiffLines(t *testing.T) {
+	loaded := bson.M{"_id": 1, "name": "Ann", "age": 30, "address": bson.M{"city": "Paris"}, "tags": bson.A{"a"}}
+	tests := []struct {
+		name string
+		msg  DocConflictModalMsg
+		want []string
+	}{
+		{
+			name: "edit marks added removed and changed paths of both versions",
+			msg: DocConflictModalMsg{
+				loadedDoc:  loaded,
+				currentDoc: bson.M{"_id": 1, "name": "Ann", "age": 31, "address": bson.M{"city": "Lyon"}, "tags": bson.A{"a"}},
+				newDoc:     bson.M{"_id": 1, "name": "Anne", "age": 30, "address": bson.M{"city": "Paris"}, "email": "ann@example.com"},
+			},
+			want: []string{
+				"address.city", `    base    "Paris"`, `  ~ theirs  "Lyon"`, `    yours   "Paris"`,
+				"age", "    base    30", "  ~ theirs  31", "    yours   30",
+				"email", "    base    (missing)", "    theirs  (missing)", `  + yours   "ann@example.com"`,
+				"name", `    base    "Ann"`, `    theirs  "Ann"`, `  ~ yours   "Anne"`,
+				"tags.0", `    base    "a"`, `    theirs  "a"`, "  - yours   (missing)",
+			},
+		},
+		{
+			name: "delete only compares their version",
+			msg: DocConflictModalMsg{
+				loadedDoc:  loaded,
+				currentDoc: bson.M{"_id": 1, "name": "Ann", "age": 30, "address": bson.M{"city": "Paris"}},
+			},
+			want: []string{"tags.0", `    base    "a"`, "  - theirs  (missing)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conflictDiffLines(&tt.msg); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("conflictDiffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictScroll(t *testing.T) {
+	loaded, current := bson.M{"_id": 1}, bson.M{"_id": 1}
+	for _, field := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		current[field] = strings.Repeat("x", 2*previewWidth) // Long values wrap instead of being cut off
+	}
+
+	m := New()
+	m.Update(DocConflictModalMsg{loadedDoc: loaded, currentDoc: current, newDoc: nil})
+	lines := conflictDiffLines(m.docConflictMsg)
+	if len(lines) <= conflictDiffHeight {
+		t.Fatalf("got %d diff lines, want more than %d", len(lines), conflictDiffHeight)
+	}
+	if want := fmt.Sprintf("lines 1-%d of %d", conflictDiffHeight, len(lines)); !strings.Contains(m.View(), want) {
+		t.Errorf("View() should contain %q", want)
+	}
+	for range lines { // The modal does not import testutil as that would be an import cycle
+		m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if m.conflictScroll != len(lines)-conflictDiffHeight {
+		t.Errorf("conflictScroll = %d, want it to stop at %d", m.conflictScroll, len(lines)-conflictDiffHeight)
+	}
+	if want := fmt.Sprintf("lines %d-%d of %d", m.conflictScroll+1, len(lines), len(lines)); !strings.Contains(m.View(), want) {
+		t.Errorf("View() should contain %q", want)
+	}
+}
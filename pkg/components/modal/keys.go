@@ -9,6 +9,9 @@ type keyMap struct {
 	Right    key.Binding
 	Left     key.Binding
 	Enter    key.Binding
+	Esc      key.Binding
+	NextItem key.Binding // Moves between the fields of a form without catching keys that are typed into them
+	PrevItem key.Binding
 }
 
 var keys = keyMap{
@@ -36,4 +39,16 @@ var keys = keyMap{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "confirm"),
 	),
+	Esc: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	NextItem: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab/↓", "next field"),
+	),
+	PrevItem: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab/↑", "previous field"),
+	),
 }
@@ -12,6 +12,14 @@ const (
 	noButtonCursor
 )
 
+type conflictButtonCursor int
+
+const (
+	overwriteButtonCursor conflictButtonCursor = iota // Deletes the document instead when resolving a deletion
+	reloadButtonCursor
+	reopenButtonCursor
+)
+
 type dbCollInput int
 
 const (
@@ -25,14 +33,30 @@ type Model struct {
 	errMsg *ErrModalMsg // Sent in as a tea.Cmd from elsewhere in the program
 
 	dbCollInsertMsg *DbCollInsertModalMsg
+	collCreateMsg   *CollCreateModalMsg
+
+	collDropMsg      *CollDropModalMsg
+	dbDropMsg        *DbDropModalMsg
+	docDeleteMsg     *DocDeleteModalMsg
+	docDeleteManyMsg *DocDeleteManyModalMsg
+	docUpdateManyMsg *DocUpdateManyModalMsg
+	docInsertMsg     *DocInsertModalMsg
+	docEditMsg       *DocEditModalMsg
+
+	docConflictMsg   *DocConflictModalMsg
+	undoMsg          *UndoModalMsg
+	stagedChangesMsg *StagedChangesModalMsg
+
+	indexCreateMsg *IndexCreateModalMsg
+	indexDropMsg   *IndexDropModalMsg
 
-	collDropMsg  *CollDropModalMsg
-	dbDropMsg    *DbDropModalMsg
-	docDeleteMsg *DocDeleteModalMsg
-	docInsertMsg *DocInsertModalMsg
-	docEditMsg   *DocEditModalMsg
+	formMsg          *FormModalMsg
+	formInputs       []textinput.Model
+	focusedFormInput int
 
 	confirmationCursor confirmationButtonCursor
+	conflictCursor     conflictButtonCursor
+	conflictScroll     int // The first diff line shown in the conflict modal
 
 	dbInsertInput      textinput.Model
 	collInsertInput    textinput.Model
@@ -51,13 +75,22 @@ func New() *Model {
 	return &Model{
 		styles: defaultStyles(),
 
-		errMsg:          nil,
-		dbCollInsertMsg: nil,
-		collDropMsg:     nil,
-		dbDropMsg:       nil,
-		docDeleteMsg:    nil,
-		docInsertMsg:    nil,
-		docEditMsg:      nil,
+		errMsg:           nil,
+		dbCollInsertMsg:  nil,
+		collCreateMsg:    nil,
+		collDropMsg:      nil,
+		dbDropMsg:        nil,
+		docDeleteMsg:     nil,
+		docDeleteManyMsg: nil,
+		docUpdateManyMsg: nil,
+		docInsertMsg:     nil,
+		docEditMsg:       nil,
+		docConflictMsg:   nil,
+		undoMsg:          nil,
+		stagedChangesMsg: nil,
+		indexCreateMsg:   nil,
+		indexDropMsg:     nil,
+		formMsg:          nil,
 
 		confirmationCursor: yesButtonCursor,
 
@@ -74,15 +107,24 @@ func (m *Model) Init() tea.Cmd {
 func (m *Model) IsModalDisplaying() bool {
 	return m.errMsg != nil ||
 		m.dbCollInsertMsg != nil ||
+		m.collCreateMsg != nil ||
 		m.collDropMsg != nil ||
 		m.dbDropMsg != nil ||
 		m.docDeleteMsg != nil ||
+		m.docDeleteManyMsg != nil ||
+		m.docUpdateManyMsg != nil ||
 		m.docInsertMsg != nil ||
-		m.docEditMsg != nil
+		m.docEditMsg != nil ||
+		m.docConflictMsg != nil ||
+		m.undoMsg != nil ||
+		m.stagedChangesMsg != nil ||
+		m.indexCreateMsg != nil ||
+		m.indexDropMsg != nil ||
+		m.formMsg != nil
 }
 
 // IsTextInputFocused is used to determine if the 'q' key should quit the app or be routed
 // onto the modal component and then the dbInsertInput
 func (m *Model) IsTextInputFocused() bool {
-	return m.dbCollInsertMsg != nil
+	return m.dbCollInsertMsg != nil || m.formMsg != nil
 }
@@ -1,8 +1,10 @@
 package modal
 
 import (
+	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
 )
 
 /*
@@ -39,14 +41,36 @@ func DisplayDbCollInsertModal(databaseName string) tea.Cmd {
 	}
 }
 
+// ExecDbCollInsert creates a collection. Options holds the options of the create command and is nil when the
+// collection was named via the insert modal rather than the collection create modal
 type ExecDbCollInsert struct {
 	DatabaseName   string
 	CollectionName string
+	Options        bson.D
 }
 
-func execDbCollectionInsert(databaseName, collectionName string) tea.Cmd {
+func execDbCollectionInsert(databaseName, collectionName string, options bson.D) tea.Cmd {
 	return func() tea.Msg {
-		return ExecDbCollInsert{DatabaseName: databaseName, CollectionName: collectionName}
+		return ExecDbCollInsert{DatabaseName: databaseName, CollectionName: collectionName, Options: options}
+	}
+}
+
+/*
+************************
+Create Collection With Options Modal
+************************
+*/
+
+type CollCreateModalMsg struct {
+	databaseName   string
+	collectionName string
+	options        bson.D
+}
+
+// DisplayCollectionCreateModal confirms the creation of a collection, or a view if options contains viewOn
+func DisplayCollectionCreateModal(databaseName, collectionName string, options bson.D) tea.Cmd {
+	return func() tea.Msg {
+		return CollCreateModalMsg{databaseName: databaseName, collectionName: collectionName, options: options}
 	}
 }
 
@@ -144,6 +168,88 @@ func execDocDelete(doc *bson.M) tea.Cmd {
 	}
 }
 
+/*
+************************
+Document Delete Matching Modal
+************************
+*/
+
+type DocDeleteManyModalMsg struct {
+	dbName         string
+	collectionName string
+	filter         bson.D
+	count          int64
+}
+
+// DisplayDocDeleteManyModal confirms the deletion of the count documents matching filter
+func DisplayDocDeleteManyModal(dbName, collectionName string, filter bson.D, count int64) tea.Cmd {
+	return func() tea.Msg {
+		return DocDeleteManyModalMsg{
+			dbName:         dbName,
+			collectionName: collectionName,
+			filter:         filter,
+			count:          count,
+		}
+	}
+}
+
+type ExecDocDeleteMany struct {
+	DbName         string
+	CollectionName string
+	Filter         bson.D
+}
+
+func execDocDeleteMany(dbName, collectionName string, filter bson.D) tea.Cmd {
+	return func() tea.Msg {
+		return ExecDocDeleteMany{DbName: dbName, CollectionName: collectionName, Filter: filter}
+	}
+}
+
+/*
+************************
+Document Update Matching Modal
+************************
+*/
+
+type DocUpdateManyModalMsg struct {
+	dbName         string
+	collectionName string
+	filter         bson.D
+	update         any
+	count          int64
+	before         []bson.D
+	after          []bson.D
+}
+
+// DisplayDocUpdateManyModal confirms an update of the count documents matching filter. before and after are a
+// sample of the documents as they are now and as they would be after the update
+func DisplayDocUpdateManyModal(dbName, collectionName string, filter bson.D, update any, count int64, before, after []bson.D) tea.Cmd {
+	return func() tea.Msg {
+		return DocUpdateManyModalMsg{
+			dbName:         dbName,
+			collectionName: collectionName,
+			filter:         filter,
+			update:         update,
+			count:          count,
+			before:         before,
+			after:          after,
+		}
+	}
+}
+
+type ExecDocUpdateMany struct {
+	DbName         string
+	CollectionName string
+	Filter         bson.D
+	Update         any // A document of update operators or an update pipeline
+}
+
+func execDocUpdateMany(dbName, collectionName string, filter bson.D, update any) tea.Cmd {
+	return func() tea.Msg {
+		return ExecDocUpdateMany{DbName: dbName, CollectionName: collectionName, Filter: filter, Update: update}
+	}
+}
+
 /*
 ************************
 Document Insert Modal
@@ -202,3 +308,204 @@ func execDocEdit(oldDoc, newDoc bson.M) tea.Cmd {
 		return ExecDocEdit{OldDoc: oldDoc, NewDoc: newDoc}
 	}
 }
+
+/*
+************************
+Document Conflict Modal
+************************
+*/
+
+type DocConflictModalMsg struct {
+	loadedDoc  bson.M
+	currentDoc bson.M
+	newDoc     bson.M
+}
+
+// DisplayDocConflictModal is displayed when a document was changed by someone else after it was loaded. loadedDoc
+// is the document as it was loaded, currentDoc is how someone else left it and newDoc holds the user's edits or is
+// nil if the user was deleting the document
+func DisplayDocConflictModal(loadedDoc, currentDoc, newDoc bson.M) tea.Cmd {
+	return func() tea.Msg {
+		return DocConflictModalMsg{loadedDoc: loadedDoc, currentDoc: currentDoc, newDoc: newDoc}
+	}
+}
+
+// ExecDocReload reloads the documents so that the changes made by someone else are displayed
+type ExecDocReload struct{}
+
+func execDocReload() tea.Cmd {
+	return func() tea.Msg {
+		return ExecDocReload{}
+	}
+}
+
+// ExecDocReopen opens the user's edits in the editor again so that they can be made against CurrentDoc
+type ExecDocReopen struct {
+	CurrentDoc bson.M
+	NewDoc     bson.M
+}
+
+func execDocReopen(currentDoc, newDoc bson.M) tea.Cmd {
+	return func() tea.Msg {
+		return ExecDocReopen{CurrentDoc: currentDoc, NewDoc: newDoc}
+	}
+}
+
+/*
+************************
+Undo Modal
+************************
+*/
+
+type UndoModalMsg struct {
+	id          uint64
+	description string
+}
+
+// DisplayUndoModal confirms the undoing of the write with the given journal id. description explains what
+// undoing the write will do
+func DisplayUndoModal(id uint64, description string) tea.Cmd {
+	return func() tea.Msg {
+		return UndoModalMsg{id: id, description: description}
+	}
+}
+
+type ExecUndo struct {
+	Id uint64
+}
+
+func execUndo(id uint64) tea.Cmd {
+	return func() tea.Msg {
+		return ExecUndo{Id: id}
+	}
+}
+
+/*
+************************
+Staged Changes Modal
+************************
+*/
+
+type StagedChangesModalMsg struct {
+	count   int
+	discard bool
+}
+
+// DisplayStagedCommitModal confirms the committing of the count changes that are staged
+func DisplayStagedCommitModal(count int) tea.Cmd {
+	return func() tea.Msg {
+		return StagedChangesModalMsg{count: count}
+	}
+}
+
+// DisplayStagedDiscardModal confirms the discarding of the count changes that are staged
+func DisplayStagedDiscardModal(count int) tea.Cmd {
+	return func() tea.Msg {
+		return StagedChangesModalMsg{count: count, discard: true}
+	}
+}
+
+type ExecStagedCommit struct{}
+
+type ExecStagedDiscard struct{}
+
+func execStagedChanges(discard bool) tea.Cmd {
+	return func() tea.Msg {
+		if discard {
+			return ExecStagedDiscard{}
+		}
+		return ExecStagedCommit{}
+	}
+}
+
+/*
+************************
+Index Create Modal
+************************
+*/
+
+type IndexCreateModalMsg struct {
+	spec bson.D
+}
+
+func DisplayIndexCreateModal(spec bson.D) tea.Cmd {
+	return func() tea.Msg {
+		return IndexCreateModalMsg{spec: spec}
+	}
+}
+
+type ExecIndexCreate struct {
+	Spec bson.D
+}
+
+func execIndexCreate(spec bson.D) tea.Cmd {
+	return func() tea.Msg {
+		return ExecIndexCreate{Spec: spec}
+	}
+}
+
+/*
+************************
+Index Drop Modal
+************************
+*/
+
+type IndexDropModalMsg struct {
+	indexName string
+}
+
+func DisplayIndexDropModal(indexName string) tea.Cmd {
+	return func() tea.Msg {
+		return IndexDropModalMsg{indexName: indexName}
+	}
+}
+
+// ExecIndexDrop will be sent down to the indexlist to actually drop an index after a modal confirmation
+type ExecIndexDrop struct {
+	IndexName string
+}
+
+func execIndexDrop(indexName string) tea.Cmd {
+	return func() tea.Msg {
+		return ExecIndexDrop{IndexName: indexName}
+	}
+}
+
+/*
+************************
+Form Modal
+************************
+*/
+
+// FormField is a single line of text entered into a form modal
+type FormField struct {
+	Label string
+	Value string // The initial value
+	Hint  string // Displayed while the field is empty
+}
+
+type FormModalMsg struct {
+	title  string
+	fields []FormField
+	submit func(values []string) tea.Cmd
+}
+
+// DisplayFormModal asks for the value of each field. Once the form is submitted with enter, submit is called with
+// the values in the order of the fields and the command it returns is run. esc closes the form without submitting
+func DisplayFormModal(title string, fields []FormField, submit func(values []string) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return FormModalMsg{title: title, fields: fields, submit: submit}
+	}
+}
+
+// ParseYesNo parses the value of a form field that is answered with yes or no. label names the field in the error
+func ParseYesNo(label, value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s must be yes or no, got %q", label, value)
+	}
+}
@@ -2,6 +2,7 @@ package modal
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -22,6 +23,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.dbInsertInput.Blur()
 			m.collInsertInput.Focus()
 		}
+	case CollCreateModalMsg:
+		m.collCreateMsg = &msg
+		m.confirmationCursor = yesButtonCursor
 	case CollDropModalMsg:
 		m.collDropMsg = &msg
 		m.confirmationCursor = yesButtonCursor
@@ -31,19 +35,59 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case DocDeleteModalMsg:
 		m.docDeleteMsg = &msg
 		m.confirmationCursor = yesButtonCursor
+	case DocDeleteManyModalMsg:
+		m.docDeleteManyMsg = &msg
+		m.confirmationCursor = noButtonCursor // Many documents may be lost so the user must choose to go ahead
+	case DocUpdateManyModalMsg:
+		m.docUpdateManyMsg = &msg
+		m.confirmationCursor = noButtonCursor
 	case DocInsertModalMsg:
 		m.docInsertMsg = &msg
 		m.confirmationCursor = yesButtonCursor
 	case DocEditModalMsg:
 		m.docEditMsg = &msg
 		m.confirmationCursor = yesButtonCursor
+	case DocConflictModalMsg:
+		m.docConflictMsg = &msg
+		m.conflictCursor = reloadButtonCursor // The least destructive option
+		m.conflictScroll = 0
+	case UndoModalMsg:
+		m.undoMsg = &msg
+		m.confirmationCursor = yesButtonCursor
+	case StagedChangesModalMsg:
+		m.stagedChangesMsg = &msg
+		m.confirmationCursor = yesButtonCursor
+		if msg.discard {
+			m.confirmationCursor = noButtonCursor
+		}
+	case IndexCreateModalMsg:
+		m.indexCreateMsg = &msg
+		m.confirmationCursor = yesButtonCursor
+	case IndexDropModalMsg:
+		m.indexDropMsg = &msg
+		m.confirmationCursor = yesButtonCursor
+	case FormModalMsg:
+		m.formMsg = &msg
+		m.formInputs = make([]textinput.Model, len(msg.fields))
+		for i, field := range msg.fields {
+			m.formInputs[i] = textinput.New()
+			m.formInputs[i].Placeholder = field.Hint
+			m.formInputs[i].SetValue(field.Value)
+		}
+		m.focusFormInput(0)
 	case tea.KeyMsg:
 		m.errMsg = nil // Any key clears error messages
+		if m.docConflictMsg != nil {
+			return m, m.updateConflict(msg)
+		}
+		if m.formMsg != nil {
+			return m, m.updateForm(msg)
+		}
 		if m.dbCollInsertMsg != nil {
 			switch {
 			case key.Matches(msg, keys.Enter):
 				m.dbCollInsertMsg = nil
-				return m, execDbCollectionInsert(m.dbInsertInput.Value(), m.collInsertInput.Value())
+				return m, execDbCollectionInsert(m.dbInsertInput.Value(), m.collInsertInput.Value(), nil)
 			case key.Matches(msg, keys.Tab):
 				if m.focusedDbCollInput == databaseInputFocused {
 					m.focusedDbCollInput = collectionInputFocused
@@ -83,6 +127,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			if m.errMsg != nil {
 				m.errMsg = nil
+			} else if m.collCreateMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execDbCollectionInsert(m.collCreateMsg.databaseName, m.collCreateMsg.collectionName, m.collCreateMsg.options)
+				}
+				m.collCreateMsg = nil
+				return m, cmd
 			} else if m.collDropMsg != nil {
 				if m.confirmationCursor == yesButtonCursor {
 					cmd = execCollectionDrop(m.collDropMsg.dbName, m.collDropMsg.collectionName)
@@ -101,6 +151,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.docDeleteMsg = nil
 				return m, cmd
+			} else if m.docDeleteManyMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execDocDeleteMany(m.docDeleteManyMsg.dbName, m.docDeleteManyMsg.collectionName, m.docDeleteManyMsg.filter)
+				}
+				m.docDeleteManyMsg = nil
+				return m, cmd
+			} else if m.docUpdateManyMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execDocUpdateMany(m.docUpdateManyMsg.dbName, m.docUpdateManyMsg.collectionName, m.docUpdateManyMsg.filter, m.docUpdateManyMsg.update)
+				}
+				m.docUpdateManyMsg = nil
+				return m, cmd
 			} else if m.docInsertMsg != nil {
 				if m.confirmationCursor == yesButtonCursor {
 					cmd = execDocInsert(m.docInsertMsg.doc)
@@ -113,8 +175,100 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.docEditMsg = nil
 				return m, cmd
+			} else if m.undoMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execUndo(m.undoMsg.id)
+				}
+				m.undoMsg = nil
+				return m, cmd
+			} else if m.stagedChangesMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execStagedChanges(m.stagedChangesMsg.discard)
+				}
+				m.stagedChangesMsg = nil
+				return m, cmd
+			} else if m.indexCreateMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execIndexCreate(m.indexCreateMsg.spec)
+				}
+				m.indexCreateMsg = nil
+				return m, cmd
+			} else if m.indexDropMsg != nil {
+				if m.confirmationCursor == yesButtonCursor {
+					cmd = execIndexDrop(m.indexDropMsg.indexName)
+				}
+				m.indexDropMsg = nil
+				return m, cmd
 			}
 		}
 	}
 	return m, nil
 }
+
+// updateConflict moves between the options of the conflict modal and runs the chosen one
+func (m *Model) updateConflict(msg tea.KeyMsg) tea.Cmd {
+	lastButton := reopenButtonCursor
+	if m.docConflictMsg.newDoc == nil { // A deletion can not be reopened in the editor
+		lastButton = reloadButtonCursor
+	}
+	switch {
+	case key.Matches(msg, keys.Left):
+		m.conflictCursor = max(overwriteButtonCursor, m.conflictCursor-1)
+	case key.Matches(msg, keys.Right):
+		m.conflictCursor = min(lastButton, m.conflictCursor+1)
+	case key.Matches(msg, keys.LineUp):
+		m.conflictScroll = max(0, m.conflictScroll-1)
+	case key.Matches(msg, keys.LineDown):
+		m.conflictScroll = min(max(0, len(conflictDiffLines(m.docConflictMsg))-conflictDiffHeight), m.conflictScroll+1)
+	case key.Matches(msg, keys.Enter):
+		current, newDoc := m.docConflictMsg.currentDoc, m.docConflictMsg.newDoc
+		m.docConflictMsg = nil
+		switch {
+		case m.conflictCursor == reloadButtonCursor:
+			return execDocReload()
+		case m.conflictCursor == reopenButtonCursor:
+			return execDocReopen(current, newDoc)
+		case newDoc == nil:
+			return execDocDelete(&current)
+		default: // The current document becomes the one that is replaced so that the edit no longer conflicts
+			return execDocEdit(current, newDoc)
+		}
+	}
+	return nil
+}
+
+// updateForm moves between the fields of the form, types into the focused field or submits the form
+func (m *Model) updateForm(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keys.Esc):
+		m.formMsg = nil
+	case key.Matches(msg, keys.Enter):
+		values := make([]string, len(m.formInputs))
+		for i, input := range m.formInputs {
+			values[i] = input.Value()
+		}
+		submit := m.formMsg.submit
+		m.formMsg = nil
+		return submit(values)
+	case key.Matches(msg, keys.NextItem):
+		m.focusFormInput((m.focusedFormInput + 1) % len(m.formInputs))
+	case key.Matches(msg, keys.PrevItem):
+		m.focusFormInput((m.focusedFormInput + len(m.formInputs) - 1) % len(m.formInputs))
+	default:
+		var cmd tea.Cmd
+		m.formInputs[m.focusedFormInput], cmd = m.formInputs[m.focusedFormInput].Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+func (m *Model) focusFormInput(i int) {
+	m.focusedFormInput = i
+	for j := range m.formInputs {
+		if j == i {
+			m.formInputs[j].Focus()
+		} else {
+			m.formInputs[j].Blur()
+		}
+	}
+}
@@ -3,6 +3,9 @@ package modal
 import (
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
 )
 
 func (m *Model) View() string {
@@ -14,6 +17,10 @@ func (m *Model) View() string {
 		text := "Enter the database and collection names you would like to insert\n"
 		msg := fmt.Sprintf("%s\n\n%s\n%s", m.styles.InputTextBoxMsg.Render(text), m.styles.InputTextBox.Render(m.dbInsertInput.View()), m.styles.InputTextBox.Render(m.collInsertInput.View()))
 		return m.styles.Modal.UnsetAlignHorizontal().Render(msg)
+	} else if m.docConflictMsg != nil {
+		return m.conflictView()
+	} else if m.formMsg != nil {
+		return m.formView()
 	} else { // All Confirmation modals
 		var yesButton string
 		var noButton string
@@ -26,7 +33,17 @@ func (m *Model) View() string {
 		}
 		buttons := lipgloss.JoinHorizontal(lipgloss.Center, yesButton, noButton)
 
-		if m.collDropMsg != nil {
+		if m.collCreateMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			kind := "collection"
+			for _, opt := range m.collCreateMsg.options {
+				if opt.Key == "viewOn" && opt.Value != nil {
+					kind = "view"
+				}
+			}
+			msg := fmt.Sprintf("%s\n\nAre you sure you would like to create the %s %s.%s?\n%s", title, kind, m.collCreateMsg.databaseName, m.collCreateMsg.collectionName, buttons)
+			return m.styles.Modal.Render(msg)
+		} else if m.collDropMsg != nil {
 			title := m.styles.ConfirmationHeader.Render("Confirm")
 			msg := fmt.Sprintf("%s\n\nAre you sure you would like to drop the collection %s?\n%s", title, m.collDropMsg.collectionName, buttons)
 			return m.styles.Modal.Render(msg)
@@ -38,6 +55,15 @@ func (m *Model) View() string {
 			title := m.styles.ConfirmationHeader.Render("Confirm")
 			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to delete the selected document?\n%s", title, buttons)
 			return m.styles.Modal.Render(msg)
+		} else if m.docDeleteManyMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to delete %s?\n%s", title, matchingText(m.docDeleteManyMsg.dbName, m.docDeleteManyMsg.collectionName, m.docDeleteManyMsg.filter, m.docDeleteManyMsg.count), buttons)
+			return m.styles.Modal.Render(msg)
+		} else if m.docUpdateManyMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			question := fmt.Sprintf("Are you sure you would like to update %s?", matchingText(m.docUpdateManyMsg.dbName, m.docUpdateManyMsg.collectionName, m.docUpdateManyMsg.filter, m.docUpdateManyMsg.count))
+			msg := fmt.Sprintf("%s\n\n%s\n\n%s\n%s", title, question, updatePreviewText(m.docUpdateManyMsg), buttons)
+			return m.styles.Modal.Width(previewWidth).UnsetAlignHorizontal().Render(msg)
 		} else if m.docInsertMsg != nil {
 			title := m.styles.ConfirmationHeader.Render("Confirm")
 			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to insert the new document?\n%s", title, buttons)
@@ -46,7 +72,112 @@ func (m *Model) View() string {
 			title := m.styles.ConfirmationHeader.Render("Confirm")
 			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to make your edits?\n%s", title, buttons)
 			return m.styles.Modal.Render(msg)
+		} else if m.undoMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to undo your last write and %s?\n%s", title, m.undoMsg.description, buttons)
+			return m.styles.Modal.Render(msg)
+		} else if m.stagedChangesMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			action := "commit"
+			if m.stagedChangesMsg.discard {
+				action = "discard"
+			}
+			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to %s the %d staged changes?\n%s", title, action, m.stagedChangesMsg.count, buttons)
+			return m.styles.Modal.Render(msg)
+		} else if m.indexCreateMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to create the new index?\n%s", title, buttons)
+			return m.styles.Modal.Render(msg)
+		} else if m.indexDropMsg != nil {
+			title := m.styles.ConfirmationHeader.Render("Confirm")
+			msg := fmt.Sprintf("%s\n\n"+"Are you sure you would like to drop the index %s?\n%s", title, m.indexDropMsg.indexName, buttons)
+			return m.styles.Modal.Render(msg)
 		}
 	}
 	return ""
 }
+
+func (m *Model) conflictView() string {
+	title := m.styles.ErrorHeader.Render("Conflict")
+	labels := []string{"Overwrite", "Reload", "Reopen in editor"}
+	action := "overwrite it with your edits"
+	if m.docConflictMsg.newDoc == nil {
+		labels = []string{"Delete", "Reload"}
+		action = "delete it anyway"
+	}
+
+	lines := conflictDiffLines(m.docConflictMsg)
+	shown := lines[m.conflictScroll:min(len(lines), m.conflictScroll+conflictDiffHeight)]
+	diff := strings.Join(shown, "\n")
+	if len(lines) > conflictDiffHeight {
+		diff += fmt.Sprintf("\n\n↑/↓ to scroll, lines %d-%d of %d", m.conflictScroll+1, m.conflictScroll+len(shown), len(lines))
+	}
+
+	var buttons []string
+	for i, label := range labels {
+		style := m.styles.Button
+		if conflictButtonCursor(i) == m.conflictCursor {
+			style = m.styles.HighlightedButton
+		}
+		buttons = append(buttons, style.UnsetWidth().Padding(0, 1).Render(label))
+	}
+	text := fmt.Sprintf("The document was changed by someone else since it was loaded. You can %s, reload the documents to see their changes or reopen your edits in the editor against their version.", action)
+	if m.docConflictMsg.newDoc == nil {
+		text = fmt.Sprintf("The document was changed by someone else since it was loaded. You can %s or reload the documents to see their changes.", action)
+	}
+	legend := " Each changed field is listed as it was loaded (base) and as they left it (theirs)"
+	if m.docConflictMsg.newDoc != nil {
+		legend = " Each changed field is listed as it was loaded (base), as they left it (theirs) and as you left it (yours)"
+	}
+	text += legend + ", marked + when added, - when removed and ~ when changed."
+	msg := fmt.Sprintf("%s\n\n%s\n\n%s\n%s", title, text, diff, lipgloss.JoinHorizontal(lipgloss.Center, buttons...))
+	return m.styles.Modal.Width(previewWidth).UnsetAlignHorizontal().Render(msg)
+}
+
+const previewWidth = 100 // The update preview is wider than the other modals so that documents fit on a line
+
+// matchingText describes the count documents matching filter, calling out when the filter matches the whole collection
+func matchingText(dbName, collectionName string, filter bson.D, count int64) string {
+	ns := dbName + "." + collectionName
+	if len(filter) == 0 {
+		return fmt.Sprintf("all %d documents in %s", count, ns)
+	}
+	noun := "documents"
+	if count == 1 {
+		noun = "document"
+	}
+	return fmt.Sprintf("the %d %s in %s matching %s", count, noun, ns, extJSONText(filter))
+}
+
+// updatePreviewText lists each sampled document as it is now and as it would be after the update
+func updatePreviewText(msg *DocUpdateManyModalMsg) string {
+	var lines []string
+	for i := range msg.before {
+		lines = append(lines, "before "+extJSONText(msg.before[i]))
+		if i < len(msg.after) {
+			lines = append(lines, "after  "+extJSONText(msg.after[i]))
+		}
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		lines[i] = runewidth.Truncate(line, previewWidth-2, "…")
+	}
+	return fmt.Sprintf("Preview of the first %d:\n%s", len(msg.before), strings.Join(lines, "\n"))
+}
+
+func extJSONText(doc any) string {
+	text, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return fmt.Sprint(doc)
+	}
+	return string(text)
+}
+
+func (m *Model) formView() string {
+	lines := []string{m.styles.ConfirmationHeader.Render(m.formMsg.title), ""}
+	for i, field := range m.formMsg.fields {
+		lines = append(lines, field.Label, m.styles.InputTextBox.Render(m.formInputs[i].View()))
+	}
+	lines = append(lines, "", "enter to submit, esc to cancel")
+	return m.styles.Modal.UnsetAlignHorizontal().Render(strings.Join(lines, "\n"))
+}
@@ -4,23 +4,58 @@ import (
 	"fmt"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"strconv"
+	"strings"
 )
 
+type input int
+
+// The order of these inputs is the order that tab cycles through them
+const (
+	filterInput input = iota
+	sortInput
+	projectionInput
+	limitInput
+)
+
+const limitInputWidth = 8
+
 type Model struct {
-	textInput textinput.Model
+	inputs       []textinput.Model
+	focusedInput input
+
+	aggregationMode bool // When enabled, the filter input takes an aggregation pipeline and the other inputs are hidden
 }
 
 func New() *Model {
-	ti := textinput.New()
-	ti.Placeholder = "Query"
-	ti.SetValue("{}")
-	ti.SetCursor(1)
-	ti.CharLimit = 156
-	ti.Blur()
+	filterTi := textinput.New()
+	filterTi.Placeholder = "Query"
+	filterTi.SetValue("{}")
+	filterTi.SetCursor(1)
+	filterTi.CharLimit = 156
+	filterTi.Blur()
+
+	sortTi := textinput.New()
+	sortTi.Prompt = "sort: "
+	sortTi.Placeholder = "{}"
+	sortTi.CharLimit = 156
+
+	projectionTi := textinput.New()
+	projectionTi.Prompt = "project: "
+	projectionTi.Placeholder = "{}"
+	projectionTi.CharLimit = 156
+
+	limitTi := textinput.New()
+	limitTi.Prompt = "limit: "
+	limitTi.Placeholder = "none"
+	limitTi.CharLimit = 10
 
 	return &Model{
-		textInput: ti,
+		inputs:       []textinput.Model{filterTi, sortTi, projectionTi, limitTi},
+		focusedInput: filterInput,
 	}
 }
 
@@ -30,52 +65,160 @@ func (m *Model) Init() tea.Cmd {
 
 // SetWidth sets the width of the viewport of the querysearch
 func (m *Model) SetWidth(w int) {
-	m.textInput.Width = w
+	m.inputs[filterInput].Width = w
+	m.inputs[limitInput].Width = limitInputWidth
+	optionWidth := (w - limitInputWidth - lipgloss.Width(m.inputs[limitInput].Prompt) - 1) / 2 // 1 for the cursor
+	m.inputs[sortInput].Width = optionWidth - lipgloss.Width(m.inputs[sortInput].Prompt) - 1
+	m.inputs[projectionInput].Width = optionWidth - lipgloss.Width(m.inputs[projectionInput].Prompt) - 1
 }
 
+// Height returns the number of lines the querysearch takes up when rendered
+func (m *Model) Height() int {
+	if m.aggregationMode {
+		return 1
+	}
+	return 2
+}
+
+// ToggleAggregationMode switches the filter input between taking a find filter and an aggregation pipeline
+func (m *Model) ToggleAggregationMode() {
+	m.aggregationMode = !m.aggregationMode
+	m.ResetValue()
+}
+
+func (m *Model) IsAggregationMode() bool {
+	return m.aggregationMode
+}
+
+// Focus focuses the filter input. The other inputs can then be reached with tab
 func (m *Model) Focus() {
-	m.textInput.Focus()
+	m.focusInput(filterInput)
 }
 
 func (m *Model) Blur() {
-	m.textInput.Blur()
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
 }
 
 func (m *Model) Focused() bool {
-	return m.textInput.Focused()
+	for _, ti := range m.inputs {
+		if ti.Focused() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) focusInput(i input) {
+	m.Blur()
+	m.focusedInput = i
+	m.inputs[i].Focus()
 }
 
 func (m *Model) ResetValue() {
-	m.textInput.Reset()
-	m.textInput.SetValue("{}")
-	m.textInput.SetCursor(1)
+	for i := range m.inputs {
+		m.inputs[i].Reset()
+	}
+	if m.aggregationMode {
+		m.inputs[filterInput].Placeholder = "Pipeline"
+		m.inputs[filterInput].CharLimit = 1024 // Pipelines tend to be much longer than filters
+		m.inputs[filterInput].SetValue("[]")
+	} else {
+		m.inputs[filterInput].Placeholder = "Query"
+		m.inputs[filterInput].CharLimit = 156
+		m.inputs[filterInput].SetValue("{}")
+	}
+	m.inputs[filterInput].SetCursor(1)
 }
 
-func (m *Model) GetValue() (bson.D, error) {
-	var query bson.D
-	err := bson.UnmarshalExtJSON([]byte(m.textInput.Value()), false, &query)
+// GetValue parses all inputs into a query that can be run by the mongoengine
+func (m *Model) GetValue() (mongoengine.Query, error) {
+	if m.aggregationMode {
+		return m.getPipelineValue()
+	}
+	var query mongoengine.Query
+	err := bson.UnmarshalExtJSON([]byte(m.inputs[filterInput].Value()), false, &query.Filter)
 	if err != nil {
-		return bson.D{}, fmt.Errorf("invalid query: %v", err)
+		return mongoengine.Query{}, fmt.Errorf("invalid query: %v", err)
+	}
+	if query.Sort, err = parseOptionalDoc(m.inputs[sortInput].Value()); err != nil {
+		return mongoengine.Query{}, fmt.Errorf("invalid sort: %v", err)
+	}
+	if query.Projection, err = parseOptionalDoc(m.inputs[projectionInput].Value()); err != nil {
+		return mongoengine.Query{}, fmt.Errorf("invalid projection: %v", err)
+	}
+	if limit := strings.TrimSpace(m.inputs[limitInput].Value()); limit != "" {
+		query.Limit, err = strconv.ParseInt(limit, 10, 64)
+		if err != nil || query.Limit < 0 {
+			return mongoengine.Query{}, fmt.Errorf("invalid limit: %s must be a positive number, or 0 for no limit", limit)
+		}
 	}
 	return query, nil
 }
 
+// getPipelineValue parses the filter input as an array of aggregation stages
+func (m *Model) getPipelineValue() (mongoengine.Query, error) {
+	// Extended JSON must be a document at the top level so the array is wrapped before being parsed
+	var wrapped struct {
+		Pipeline []bson.D `bson:"pipeline"`
+	}
+	wrappedVal := fmt.Sprintf(`{"pipeline": %s}`, m.inputs[filterInput].Value())
+	if err := bson.UnmarshalExtJSON([]byte(wrappedVal), false, &wrapped); err != nil {
+		return mongoengine.Query{}, fmt.Errorf("invalid pipeline: %v", err)
+	}
+	if wrapped.Pipeline == nil {
+		wrapped.Pipeline = []bson.D{}
+	}
+	return mongoengine.Query{Pipeline: wrapped.Pipeline}, nil
+}
+
+// parseOptionalDoc will parse a sort or projection document. These inputs are allowed to be left blank
+func parseOptionalDoc(val string) (bson.D, error) {
+	if strings.TrimSpace(val) == "" {
+		return nil, nil
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON([]byte(val), false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEsc, tea.KeyDown:
-			m.textInput.Blur()
+			m.Blur()
+			return m, nil
+		case tea.KeyTab, tea.KeyShiftTab:
+			if m.aggregationMode { // There is only the pipeline input to focus
+				return m, nil
+			}
+			if msg.Type == tea.KeyShiftTab {
+				m.focusInput((m.focusedInput + input(len(m.inputs)) - 1) % input(len(m.inputs)))
+			} else {
+				m.focusInput((m.focusedInput + 1) % input(len(m.inputs)))
+			}
 			return m, nil
 		default:
 		}
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.inputs[m.focusedInput], cmd = m.inputs[m.focusedInput].Update(msg)
 	return m, cmd
 }
 
 func (m *Model) View() string {
-	return m.textInput.View()
+	if m.aggregationMode {
+		return m.inputs[filterInput].View()
+	}
+	options := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(m.inputs[sortInput].Width+lipgloss.Width(m.inputs[sortInput].Prompt)+1).Render(m.inputs[sortInput].View()),
+		lipgloss.NewStyle().Width(m.inputs[projectionInput].Width+lipgloss.Width(m.inputs[projectionInput].Prompt)+1).Render(m.inputs[projectionInput].View()),
+		m.inputs[limitInput].View(),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, m.inputs[filterInput].View(), options)
 }
@@ -0,0 +1,135 @@
+// The schemaviewer package displays the schema last inferred through mongoengine.InferSchema as a tree of fields
+// that can be expanded to reveal the fields of embedded docs
+
+package schemaviewer
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+)
+
+type Model struct {
+	state  *state.MainViewState
+	Help   help.Model
+	styles Styles
+
+	width    int
+	height   int
+	cursor   int
+	expanded map[string]bool // Paths of the fields whose children are displayed
+
+	engine *mongoengine.Engine
+}
+
+// row is a field that is visible given the fields that are expanded
+type row struct {
+	field *mongoengine.SchemaField
+	depth int
+}
+
+func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
+	return &Model{
+		state:    state,
+		Help:     help.New(),
+		styles:   defaultStyles(),
+		expanded: make(map[string]bool),
+		engine:   engine,
+	}
+}
+
+// Focus collapses the tree of the schema that was last cached by the engine
+func (m *Model) Focus() error {
+	if m.engine.GetSchema() == nil {
+		return fmt.Errorf("no schema has been inferred")
+	}
+	m.cursor = 0
+	m.expanded = make(map[string]bool)
+	return nil
+}
+
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	m.Help.Width = w
+}
+
+func (m *Model) SetHeight(h int) {
+	m.height = h
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.state.SetActiveComponent(state.DocList)
+		case key.Matches(msg, keys.LineUp):
+			m.cursor = renderutils.Max(0, m.cursor-1)
+		case key.Matches(msg, keys.LineDown):
+			m.cursor = renderutils.Clamp(m.cursor+1, 0, len(m.rows())-1)
+		case key.Matches(msg, keys.Expand):
+			if r, ok := m.cursoredRow(); ok && len(r.field.Fields) > 0 {
+				m.expanded[r.field.Path] = true
+			}
+		case key.Matches(msg, keys.Collapse):
+			m.collapse()
+		case key.Matches(msg, keys.Resample):
+			return m, m.engine.InferSchema()
+		}
+	case mongoengine.SchemaReadyMsg: // A resample keeps the same fields expanded
+		m.cursor = renderutils.Clamp(m.cursor, 0, len(m.rows())-1)
+	}
+	return m, nil
+}
+
+// collapse hides the children of the cursored field. If they are already hidden, the cursor moves to its parent
+func (m *Model) collapse() {
+	r, ok := m.cursoredRow()
+	if !ok {
+		return
+	}
+	if m.expanded[r.field.Path] {
+		delete(m.expanded, r.field.Path)
+		return
+	}
+	rows := m.rows()
+	for i := m.cursor - 1; i >= 0; i-- {
+		if rows[i].depth < r.depth {
+			m.cursor = i
+			delete(m.expanded, rows[i].field.Path)
+			return
+		}
+	}
+}
+
+func (m *Model) cursoredRow() (row, bool) {
+	rows := m.rows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return row{}, false
+	}
+	return rows[m.cursor], true
+}
+
+// rows flattens the tree of fields into the rows that are visible
+func (m *Model) rows() []row {
+	schema := m.engine.GetSchema()
+	if schema == nil {
+		return nil
+	}
+	var rows []row
+	var addFields func(fields []*mongoengine.SchemaField, depth int)
+	addFields = func(fields []*mongoengine.SchemaField, depth int) {
+		for _, field := range fields {
+			rows = append(rows, row{field: field, depth: depth})
+			if m.expanded[field.Path] {
+				addFields(field.Fields, depth+1)
+			}
+		}
+	}
+	addFields(schema.Fields, 0)
+	return rows
+}
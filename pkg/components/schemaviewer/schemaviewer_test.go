@@ -0,0 +1,72 @@
+package schemaviewer
+
+import (
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+	engine := testutil.NewEngine(t, map[string][]any{"shop.users": {
+		bson.D{{Key: "name", Value: "Kevin"}, {Key: "address", Value: bson.D{{Key: "city", Value: "Boston"}, {Key: "geo", Value: bson.D{{Key: "lat", Value: 42.3}}}}}},
+		bson.D{{Key: "name", Value: "Sally"}},
+	}})
+	engine.SetSelectedCollection("shop", "users")
+	if msg := engine.InferSchema()(); msg != (mongoengine.SchemaReadyMsg{}) {
+		t.Fatalf("InferSchema() returned %#v", msg)
+	}
+
+	s := state.DefaultState()
+	s.SetActiveComponent(state.SchemaViewer)
+	m := New(engine, s)
+	m.SetWidth(160)
+	m.SetHeight(20)
+	if err := m.Focus(); err != nil {
+		t.Fatalf("Focus() error = %v", err)
+	}
+	return m
+}
+
+func TestTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		wantRows   []string
+		wantCursor string
+		wantInView string
+	}{
+		{name: "starts collapsed", wantRows: []string{"_id", "address", "name"}, wantCursor: "_id", wantInView: "50%"},
+		{name: "expand an embedded doc", keys: []string{"j", "l"}, wantRows: []string{"_id", "address", "address.city", "address.geo", "name"}, wantCursor: "address", wantInView: `"Boston"`},
+		{name: "expand a nested doc", keys: []string{"j", "l", "j", "j", "l"}, wantRows: []string{"_id", "address", "address.city", "address.geo", "address.geo.lat", "name"}, wantCursor: "address.geo", wantInView: "42.3"},
+		{name: "fields without children do not expand", keys: []string{"l"}, wantRows: []string{"_id", "address", "name"}, wantCursor: "_id"},
+		{name: "collapse", keys: []string{"j", "l", "h"}, wantRows: []string{"_id", "address", "name"}, wantCursor: "address"},
+		{name: "collapse from a child moves to its parent", keys: []string{"j", "l", "j", "h"}, wantRows: []string{"_id", "address", "name"}, wantCursor: "address"},
+		{name: "cursor stops at the last row", keys: []string{"j", "j", "j", "j"}, wantRows: []string{"_id", "address", "name"}, wantCursor: "name", wantInView: `"Kevin" … "Sally"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			for _, k := range tt.keys {
+				m.Update(testutil.KeyPress(k))
+			}
+			var paths []string
+			for _, r := range m.rows() {
+				paths = append(paths, r.field.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.wantRows, ",") {
+				t.Errorf("rows = %v, want %v", paths, tt.wantRows)
+			}
+			if r, _ := m.cursoredRow(); r.field.Path != tt.wantCursor {
+				t.Errorf("cursor is on %s, want %s", r.field.Path, tt.wantCursor)
+			}
+			if view := m.View(); !strings.Contains(view, tt.wantInView) {
+				t.Errorf("View() does not contain %q:\n%s", tt.wantInView, view)
+			}
+		})
+	}
+}
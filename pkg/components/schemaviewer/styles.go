@@ -0,0 +1,36 @@
+package schemaviewer
+
+import "github.com/charmbracelet/lipgloss"
+
+type Styles struct {
+	Table    lipgloss.Style
+	Title    lipgloss.Style
+	Header   lipgloss.Style
+	Cell     lipgloss.Style
+	Selected lipgloss.Style
+}
+
+func defaultStyles() Styles {
+	return Styles{
+		Table: lipgloss.NewStyle().
+			BorderStyle(lipgloss.ThickBorder()).
+			BorderForeground(lipgloss.Color("57")),
+		Title: lipgloss.NewStyle().
+			Bold(true).
+			Padding(0, 1),
+		Header: lipgloss.NewStyle().
+			Inline(true).
+			Bold(true).
+			Padding(0, 1).
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			BorderBottom(true),
+		Cell: lipgloss.NewStyle().
+			Inline(true).
+			Padding(0, 1),
+		Selected: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("57")).
+			Bold(false),
+	}
+}
@@ -0,0 +1,131 @@
+package schemaviewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// columns are the headers of the schema table along with the share of the width each column takes up
+var columns = []struct {
+	title string
+	share int
+}{
+	{"Field", 30},
+	{"Types", 25},
+	{"Presence", 10},
+	{"Values", 35},
+}
+
+func (m *Model) View() string {
+	dbName, collName := m.engine.GetSelectedCollection()
+	var sampled int64
+	if schema := m.engine.GetSchema(); schema != nil {
+		sampled = schema.SampledDocs
+	}
+	title := m.styles.Title.Render(fmt.Sprintf("Schema of %s.%s (%d docs sampled)", dbName, collName, sampled))
+
+	var headers []string
+	for i, column := range columns {
+		headers = append(headers, m.styles.Header.Width(m.columnWidth(i)).Render(column.title))
+	}
+
+	visibleRows := m.rows()
+	rowsHeight := m.height - 6 // Borders, title, headers and help
+	start := renderutils.Clamp(m.cursor-rowsHeight+1, 0, m.cursor)
+	rows := []string{title, lipgloss.JoinHorizontal(lipgloss.Top, headers...)}
+	for i := start; i < len(visibleRows) && i < start+rowsHeight; i++ {
+		rows = append(rows, m.renderRow(i, visibleRows[i], sampled))
+	}
+	table := lipgloss.NewStyle().Height(m.height - 3).Render(lipgloss.JoinVertical(lipgloss.Top, rows...))
+	return lipgloss.JoinVertical(lipgloss.Top, m.styles.Table.Render(table), m.Help.View(keys))
+}
+
+func (m *Model) renderRow(i int, r row, sampled int64) string {
+	marker := "  "
+	if len(r.field.Fields) > 0 {
+		marker = "▸ "
+		if m.expanded[r.field.Path] {
+			marker = "▾ "
+		}
+	}
+	cells := []string{
+		strings.Repeat("  ", r.depth) + marker + r.field.Name,
+		typesText(r.field.Types),
+		percentText(r.field.Count, sampled),
+		valuesText(r.field),
+	}
+	var renderedCells []string
+	for c, cell := range cells {
+		width := m.columnWidth(c)
+		renderedCells = append(renderedCells, m.styles.Cell.Width(width).Render(runewidth.Truncate(cell, width-2, "…")))
+	}
+	renderedRow := lipgloss.JoinHorizontal(lipgloss.Top, renderedCells...)
+	if i == m.cursor {
+		renderedRow = m.styles.Selected.Render(renderedRow)
+	}
+	return renderedRow
+}
+
+func (m *Model) columnWidth(i int) int {
+	return (m.width - 2) * columns[i].share / 100
+}
+
+// typesText lists each type along with its share of the values found, unless there is only one type
+func typesText(types []mongoengine.SchemaType) string {
+	if len(types) == 1 {
+		return types[0].Name
+	}
+	var total int64
+	for _, t := range types {
+		total += t.Count
+	}
+	var parts []string
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%s %s", t.Name, percentText(t.Count, total)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func percentText(n, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+}
+
+// valuesText displays the range of values found or an example of one if they could not be compared
+func valuesText(field *mongoengine.SchemaField) string {
+	switch {
+	case field.Min != nil && valueText(field.Min) == valueText(field.Max):
+		return valueText(field.Min)
+	case field.Min != nil:
+		return valueText(field.Min) + " … " + valueText(field.Max)
+	case field.Example != nil:
+		return "e.g. " + valueText(field.Example)
+	default:
+		return ""
+	}
+}
+
+func valueText(v any) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case bson.DateTime:
+		return val.Time().UTC().Format(time.RFC3339)
+	case bson.ObjectID:
+		return val.Hex()
+	case bson.Binary:
+		return fmt.Sprintf("binary (%d bytes)", len(val.Data))
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,52 @@
+package stagedchanges
+
+import "github.com/charmbracelet/bubbles/key"
+
+// keyMap defines keybindings. It satisfies to the help.KeyMap interface, which
+// is used to render the help menu.
+type keyMap struct {
+	Back     key.Binding
+	LineUp   key.Binding
+	LineDown key.Binding
+	Unstage  key.Binding
+	Commit   key.Binding
+	Discard  key.Binding
+}
+
+func (km keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{km.LineUp, km.LineDown, km.Unstage, km.Commit, km.Discard, km.Back}
+}
+
+// FullHelp is only used to satisfy the interface as we do not actually use this
+func (km keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		km.ShortHelp(),
+	}
+}
+
+var keys = keyMap{
+	Back: key.NewBinding(
+		key.WithKeys("b", "esc"),
+		key.WithHelp("b", "back"),
+	),
+	LineUp: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	LineDown: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Unstage: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "unstage"),
+	),
+	Commit: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "commit all"),
+	),
+	Discard: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "discard all"),
+	),
+}
@@ -0,0 +1,90 @@
+// The stagedchanges package lists the inserts, edits and deletes queued by mongoengine while staging along with
+// a diff of each, so that they can be reviewed before being committed together or discarded
+
+package stagedchanges
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+)
+
+type Model struct {
+	state  *state.MainViewState
+	Help   help.Model
+	styles Styles
+
+	width  int
+	height int
+	cursor int
+
+	engine *mongoengine.Engine
+}
+
+func New(engine *mongoengine.Engine, state *state.MainViewState) *Model {
+	return &Model{
+		state:  state,
+		Help:   help.New(),
+		styles: defaultStyles(),
+		engine: engine,
+	}
+}
+
+// Focus moves the cursor to the first staged change
+func (m *Model) Focus() error {
+	if len(m.engine.GetStagedChanges()) == 0 {
+		return fmt.Errorf("there are no staged changes")
+	}
+	m.cursor = 0
+	return nil
+}
+
+func (m *Model) SetWidth(w int) {
+	m.width = w
+	m.Help.Width = w
+}
+
+func (m *Model) SetHeight(h int) {
+	m.height = h
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	changes := m.engine.GetStagedChanges()
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.state.SetActiveComponent(state.DocList)
+		case key.Matches(msg, keys.LineUp):
+			m.cursor = renderutils.Max(0, m.cursor-1)
+		case key.Matches(msg, keys.LineDown):
+			m.cursor = renderutils.Clamp(m.cursor+1, 0, len(changes)-1)
+		case key.Matches(msg, keys.Unstage):
+			if m.cursor < len(changes) {
+				m.engine.UnstageChange(changes[m.cursor].Id)
+				m.leaveIfEmpty()
+			}
+		case key.Matches(msg, keys.Commit):
+			return m, modal.DisplayStagedCommitModal(len(changes))
+		case key.Matches(msg, keys.Discard):
+			return m, modal.DisplayStagedDiscardModal(len(changes))
+		}
+	case mongoengine.RedrawMessage: // Sent once the staged changes are discarded
+		m.leaveIfEmpty()
+	}
+	return m, nil
+}
+
+// leaveIfEmpty returns to the doclist once there is nothing left to review. Otherwise the cursor is kept on a change
+func (m *Model) leaveIfEmpty() {
+	n := len(m.engine.GetStagedChanges())
+	if n == 0 {
+		m.state.SetActiveComponent(state.DocList)
+	}
+	m.cursor = renderutils.Clamp(m.cursor, 0, renderutils.Max(0, n-1))
+}
@@ -0,0 +1,85 @@
+package stagedchanges
+
+import (
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before bson.M
+		after  bson.M
+		want   []string
+	}{
+		{name: "insert", after: bson.M{"_id": 1, "name": "Ann"}, want: []string{`+ "_id":1`, `+ "name":"Ann"`}},
+		{name: "delete", before: bson.M{"_id": 1, "name": "Ann"}, want: []string{`- "_id":1`, `- "name":"Ann"`}},
+		{
+			name:   "edit only lists the fields that changed",
+			before: bson.M{"_id": 1, "name": "Ann", "age": 30, "tags": bson.A{"a"}},
+			after:  bson.M{"_id": 1, "name": "Anne", "age": 30, "city": "Boston"},
+			want:   []string{`+ "city":"Boston"`, `- "name":"Ann"`, `+ "name":"Anne"`, `- "tags":["a"]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range diffLines(tt.before, tt.after) {
+				got = append(got, line.text)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("diffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReview(t *testing.T) {
+	engine := testutil.NewEngine(t, map[string][]any{"shop.users": {bson.D{{Key: "_id", Value: 1}, {Key: "name", Value: "Kevin"}}}})
+	engine.SetSelectedCollection("shop", "users")
+	engine.ToggleStaging()
+
+	s := state.DefaultState()
+	s.SetActiveComponent(state.StagedChanges)
+	m := New(engine, s)
+	m.SetWidth(120)
+	m.SetHeight(20)
+	if err := m.Focus(); err == nil {
+		t.Fatalf("Focus() should fail when nothing is staged")
+	}
+
+	for _, name := range []string{"Ann", "Bob"} {
+		if err := engine.InsertDocument(bson.M{"name": name}); err != nil {
+			t.Fatalf("InsertDocument() error = %v", err)
+		}
+	}
+	if err := engine.UpdateDocument(bson.M{"_id": int32(1), "name": "Kevin"}, bson.M{"_id": int32(1), "name": "Kev"}); err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	if err := m.Focus(); err != nil {
+		t.Fatalf("Focus() error = %v", err)
+	}
+
+	m.Update(testutil.KeyPress("j"))
+	m.Update(testutil.KeyPress("j"))
+	if view := m.View(); !strings.Contains(view, `- "name":"Kevin"`) || !strings.Contains(view, `+ "name":"Kev"`) {
+		t.Errorf("View() should show the diff of the cursored edit:\n%s", view)
+	}
+	if _, cmd := m.Update(testutil.KeyPress("c")); cmd == nil {
+		t.Fatalf("commit should ask for confirmation")
+	} else if _, ok := cmd().(modal.StagedChangesModalMsg); !ok {
+		t.Errorf("commit = %#v, want the confirmation modal", cmd())
+	}
+
+	// Unstaging every change returns to the doclist
+	for range 3 {
+		m.Update(testutil.KeyPress("d"))
+	}
+	if len(engine.GetStagedChanges()) != 0 || !s.IsComponentActive(state.DocList) {
+		t.Errorf("staged changes = %v, active component = %v", engine.GetStagedChanges(), s.GetActiveComponent())
+	}
+}
@@ -0,0 +1,38 @@
+package stagedchanges
+
+import "github.com/charmbracelet/lipgloss"
+
+type Styles struct {
+	Table    lipgloss.Style
+	Title    lipgloss.Style
+	Cell     lipgloss.Style
+	Selected lipgloss.Style
+	Removed  lipgloss.Style
+	Added    lipgloss.Style
+}
+
+func defaultStyles() Styles {
+	return Styles{
+		Table: lipgloss.NewStyle().
+			BorderStyle(lipgloss.ThickBorder()).
+			BorderForeground(lipgloss.Color("57")),
+		Title: lipgloss.NewStyle().
+			Bold(true).
+			Padding(0, 1),
+		Cell: lipgloss.NewStyle().
+			Inline(true).
+			Padding(0, 1),
+		Selected: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("229")).
+			Background(lipgloss.Color("57")).
+			Bold(false),
+		Removed: lipgloss.NewStyle().
+			Inline(true).
+			Padding(0, 1).
+			Foreground(lipgloss.Color("160")),
+		Added: lipgloss.NewStyle().
+			Inline(true).
+			Padding(0, 1).
+			Foreground(lipgloss.Color("35")),
+	}
+}
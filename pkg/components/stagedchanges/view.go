@@ -0,0 +1,100 @@
+package stagedchanges
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kreulenk/mongotui/pkg/renderutils"
+	"github.com/mattn/go-runewidth"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// diffLine is a field as it was before or after a change
+type diffLine struct {
+	text    string
+	removed bool
+}
+
+func (m *Model) View() string {
+	changes := m.engine.GetStagedChanges()
+	title := m.styles.Title.Render(fmt.Sprintf("Staged changes (%d)", len(changes)))
+
+	contentHeight := m.height - 3 // Borders and help
+	listHeight := renderutils.Max(1, contentHeight/3)
+	start := renderutils.Clamp(m.cursor-listHeight+1, 0, m.cursor)
+	rows := []string{title}
+	for i := start; i < len(changes) && i < start+listHeight; i++ {
+		row := m.styles.Cell.Width(m.width - 2).Render(runewidth.Truncate(changes[i].Summary(), m.width-4, "…"))
+		if i == m.cursor {
+			row = m.styles.Selected.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	if m.cursor < len(changes) {
+		rows = append(rows, "", m.styles.Title.Render("Diff"))
+		lines := diffLines(changes[m.cursor].Before, changes[m.cursor].After)
+		diffHeight := contentHeight - len(rows)
+		for i, line := range lines {
+			if i == diffHeight-1 && len(lines) > diffHeight {
+				rows = append(rows, m.styles.Cell.Render(fmt.Sprintf("… %d more lines", len(lines)-i)))
+				break
+			}
+			style := m.styles.Added
+			if line.removed {
+				style = m.styles.Removed
+			}
+			rows = append(rows, style.Render(runewidth.Truncate(line.text, m.width-4, "…")))
+		}
+	}
+	table := lipgloss.NewStyle().Height(contentHeight).Render(lipgloss.JoinVertical(lipgloss.Top, rows...))
+	return lipgloss.JoinVertical(lipgloss.Top, m.styles.Table.Render(table), m.Help.View(keys))
+}
+
+// diffLines lists the fields that differ between before and after, each as it was before prefixed with - followed
+// by as it is after prefixed with +. Every field of an insert is added and every field of a delete is removed
+func diffLines(before, after bson.M) []diffLine {
+	var lines []diffLine
+	for _, name := range fieldNames(before, after) {
+		oldText, newText := fieldText(before, name), fieldText(after, name)
+		if oldText == newText {
+			continue
+		}
+		if oldText != "" {
+			lines = append(lines, diffLine{text: "- " + oldText, removed: true})
+		}
+		if newText != "" {
+			lines = append(lines, diffLine{text: "+ " + newText})
+		}
+	}
+	return lines
+}
+
+// fieldNames returns the fields found in either doc with the _id first and the rest sorted
+func fieldNames(before, after bson.M) []string {
+	var names []string
+	for _, doc := range []bson.M{before, after} {
+		for name := range doc {
+			if name != "_id" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return append([]string{"_id"}, names...)
+}
+
+// fieldText renders a field as relaxed extended JSON or returns "" if doc does not have it
+func fieldText(doc bson.M, name string) string {
+	val, ok := doc[name]
+	if !ok {
+		return ""
+	}
+	text, err := bson.MarshalExtJSON(bson.D{{Key: name, Value: val}}, false, false)
+	if err != nil {
+		return fmt.Sprintf("%q: %v", name, val)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(text), "{"), "}")
+}
@@ -0,0 +1,80 @@
+// The statusbar package renders a single line beneath the dbcoltable and doclist components that displays
+// the state of any operation or background job the mongoengine is running, or the number of changes pending while
+// staging. The read preference that queries are sent with is displayed on the right
+
+package statusbar
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/clipboard"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"github.com/mattn/go-runewidth"
+)
+
+type Model struct {
+	styles Styles
+	width  int
+	status string // Last status to be displayed. Cleared on the next key press
+
+	engine *mongoengine.Engine
+}
+
+func New(engine *mongoengine.Engine) *Model {
+	return &Model{
+		styles: defaultStyles(),
+		engine: engine,
+	}
+}
+
+func (m *Model) SetWidth(w int) {
+	m.width = w
+}
+
+func (m *Model) Update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case mongoengine.OperationCancelledMsg:
+		m.status = "operation cancelled"
+	case mongoengine.DocsDeletedMsg:
+		m.status = fmt.Sprintf("deleted %d documents", msg.Count)
+	case mongoengine.DocsUpdatedMsg:
+		m.status = fmt.Sprintf("matched %d and modified %d documents", msg.Matched, msg.Modified)
+	case mongoengine.JobFinishedMsg:
+		m.status = msg.Summary
+	case mongoengine.StagedCommittedMsg:
+		m.status = fmt.Sprintf("committed %d staged changes", msg.Count)
+	case mongoengine.ConcernsChangedMsg:
+		m.status = fmt.Sprintf("reading from %s", msg.Concerns.ReadPreferenceName())
+	case clipboard.CopiedMsg:
+		m.status = fmt.Sprintf("copied %s to the clipboard", msg.Description)
+	case tea.KeyMsg:
+		m.status = ""
+	}
+}
+
+func (m *Model) View() string {
+	text := m.status
+	if text == "" && m.engine.IsStaging() {
+		text = fmt.Sprintf("staging: %d changes pending (T to review)", len(m.engine.GetStagedChanges()))
+	}
+	if progress, ok := m.engine.GetJobProgress(); ok {
+		text = jobProgressText(progress)
+	}
+	if m.engine.IsOperationRunning() {
+		text = "running… (ctrl+x to cancel)"
+	}
+	readPreference := fmt.Sprintf("readPreference: %s (ctrl+r)", m.engine.GetConcerns().ReadPreferenceName())
+	width := m.width - runewidth.StringWidth(readPreference) - 1
+	if width < 1 { // Too narrow to show both so the status takes precedence
+		return m.styles.Status.Render(runewidth.Truncate(text, m.width, "…"))
+	}
+	text = runewidth.FillRight(runewidth.Truncate(text, width, "…"), width)
+	return m.styles.Status.Render(text) + " " + m.styles.ReadPreference.Render(readPreference)
+}
+
+func jobProgressText(progress mongoengine.JobProgress) string {
+	if progress.Total > 0 {
+		return fmt.Sprintf("%s: %d/%d documents (ctrl+x to cancel)", progress.Description, progress.Done, progress.Total)
+	}
+	return fmt.Sprintf("%s: %d documents (ctrl+x to cancel)", progress.Description, progress.Done)
+}
@@ -0,0 +1,17 @@
+package statusbar
+
+import "github.com/charmbracelet/lipgloss"
+
+type Styles struct {
+	Status         lipgloss.Style
+	ReadPreference lipgloss.Style
+}
+
+func defaultStyles() Styles {
+	return Styles{
+		Status: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")),
+		ReadPreference: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")),
+	}
+}
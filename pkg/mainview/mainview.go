@@ -11,8 +11,13 @@ import (
 	"github.com/kreulenk/mongotui/pkg/components/dbcoltable"
 	"github.com/kreulenk/mongotui/pkg/components/doclist"
 	"github.com/kreulenk/mongotui/pkg/components/editor"
+	"github.com/kreulenk/mongotui/pkg/components/explainviewer"
+	"github.com/kreulenk/mongotui/pkg/components/indexlist"
 	"github.com/kreulenk/mongotui/pkg/components/jsonviewer"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/components/schemaviewer"
+	"github.com/kreulenk/mongotui/pkg/components/stagedchanges"
+	"github.com/kreulenk/mongotui/pkg/components/statusbar"
 	"github.com/kreulenk/mongotui/pkg/mainview/state"
 	"github.com/kreulenk/mongotui/pkg/mongoengine"
 )
@@ -24,6 +29,11 @@ type Model struct {
 	docList         *doclist.Model
 	singleDocViewer *jsonviewer.Model
 	singleDocEditor editor.Editor
+	explainViewer   *explainviewer.Model
+	indexList       *indexlist.Model
+	schemaViewer    *schemaviewer.Model
+	stagedChanges   *stagedchanges.Model
+	statusBar       *statusbar.Model
 
 	engine *mongoengine.Engine
 }
@@ -38,6 +48,11 @@ func New(engine *mongoengine.Engine) *Model {
 		docList:         doclist.New(engine, s),
 		singleDocViewer: jsonviewer.New(engine, s),
 		singleDocEditor: editor.New(engine, s),
+		explainViewer:   explainviewer.New(engine, s),
+		indexList:       indexlist.New(engine, s),
+		schemaViewer:    schemaviewer.New(engine, s),
+		stagedChanges:   stagedchanges.New(engine, s),
+		statusBar:       statusbar.New(engine),
 		engine:          engine,
 	}
 }
@@ -46,27 +61,60 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
+	m.statusBar.Update(msg)
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		leftRightBorderWidth := 2
 		if os.Getenv("TERM_PROGRAM") == "Apple_Terminal" {
 			leftRightBorderWidth = 3 // The default mac terminal has occasional rendering issues if the full width is used
 		}
-		topBottomBorderAndHelpHeight := 3
+		topBottomBorderStatusAndHelpHeight := 4
 		m.dbColTable.SetWidth((msg.Width/3)-leftRightBorderWidth, msg.Width-leftRightBorderWidth)
-		m.dbColTable.SetHeight(msg.Height - topBottomBorderAndHelpHeight)
+		m.dbColTable.SetHeight(msg.Height - topBottomBorderStatusAndHelpHeight)
 		m.docList.SetWidth((msg.Width * 2 / 3) - leftRightBorderWidth)
-		m.docList.SetHeight(msg.Height - topBottomBorderAndHelpHeight)
+		m.docList.SetHeight(msg.Height - topBottomBorderStatusAndHelpHeight)
+		m.statusBar.SetWidth(msg.Width)
+		m.dbColTable.Help.Width = msg.Width // Truncates the help menu once there are more keys than fit on a line
+		m.docList.Help.Width = msg.Width
 
 		m.singleDocViewer.SetWidth(msg.Width)
 		m.singleDocViewer.SetHeight(msg.Height)
+		m.explainViewer.SetWidth(msg.Width)
+		m.explainViewer.SetHeight(msg.Height)
+		m.indexList.SetWidth(msg.Width - leftRightBorderWidth)
+		m.indexList.SetHeight(msg.Height)
+		m.schemaViewer.SetWidth(msg.Width - leftRightBorderWidth)
+		m.schemaViewer.SetHeight(msg.Height)
+		m.stagedChanges.SetWidth(msg.Width - leftRightBorderWidth)
+		m.stagedChanges.SetHeight(msg.Height)
 		return m, tea.ClearScreen // Necessary for resizes
-	case modal.ExecCollDrop, modal.ExecDbDrop: // A deletion was confirmed via the modal component
+	case modal.ExecCollDrop, modal.ExecDbDrop, mongoengine.DatabasesRefreshedMsg, mongoengine.CollectionRenamedMsg: // A deletion was confirmed via the modal component or the databases were reloaded or renamed
 		m.dbColTable, cmd = m.dbColTable.Update(msg)
 		return m, cmd
-	case modal.ExecDocDelete:
+	case modal.ExecDocDelete, modal.ExecDocDeleteMany, mongoengine.DocsDeletedMsg, modal.ExecDocUpdateMany, mongoengine.DocsUpdatedMsg, mongoengine.ChangeEventMsg, mongoengine.FollowStoppedMsg: // Change events keep arriving while a doc is viewed
 		m.docList, cmd = m.docList.Update(msg)
 		return m, cmd
+	case mongoengine.StagedCommittedMsg: // The changes may be committed while they are being reviewed
+		if m.state.IsComponentActive(state.StagedChanges) {
+			m.state.SetActiveComponent(state.DocList)
+			m.docList.Focus()
+		}
+		m.docList, cmd = m.docList.Update(msg)
+		return m, cmd
+	case modal.ExecDocReopen: // A conflicting edit is made again against the latest version of the document
+		return m, tea.Batch(m.singleDocEditor.ReopenDoc(msg.CurrentDoc, msg.NewDoc), tea.ClearScreen)
+	case modal.ExecIndexDrop:
+		m.indexList, cmd = m.indexList.Update(msg)
+		return m, cmd
+	case mongoengine.JobFinishedMsg: // Displayed by the statusBar
+		if msg.DatabasesRefreshed {
+			m.dbColTable, cmd = m.dbColTable.Update(msg)
+		}
+		return m, cmd
+	case mongoengine.OperationCancelledMsg, mongoengine.ConcernsChangedMsg: // Only displayed by the statusBar
+		return m, nil
+	case mongoengine.JobProgressMsg:
+		return m, m.engine.NextJobProgress(msg)
 	}
 
 	switch m.state.GetActiveComponent() {
@@ -75,6 +123,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		if m.state.IsComponentActive(state.DocList) { // If the state switched, use a fresh docList
 			m.docList.Focus()
+		} else if m.state.IsComponentActive(state.IndexList) {
+			m.indexList.Focus()
+		} else if m.state.IsComponentActive(state.CollectionCreate) {
+			cmd = m.singleDocEditor.CreateCollection()
+			m.state.SetActiveComponent(state.DbColTable)
+			cmds = append(cmds, cmd, tea.ClearScreen)
 		}
 	case state.DocList:
 		m.docList, cmd = m.docList.Update(msg)
@@ -85,6 +139,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if err := m.singleDocViewer.Focus(); err != nil {
 				return m, modal.DisplayErrorModal(err)
 			}
+		} else if m.state.IsComponentActive(state.ExplainViewer) {
+			if err := m.explainViewer.Focus(); err != nil {
+				m.state.SetActiveComponent(state.DocList)
+				return m, modal.DisplayErrorModal(err)
+			}
+		} else if m.state.IsComponentActive(state.SchemaViewer) {
+			if err := m.schemaViewer.Focus(); err != nil {
+				m.state.SetActiveComponent(state.DocList)
+				return m, modal.DisplayErrorModal(err)
+			}
+		} else if m.state.IsComponentActive(state.StagedChanges) {
+			if err := m.stagedChanges.Focus(); err != nil {
+				m.state.SetActiveComponent(state.DocList)
+				return m, modal.DisplayErrorModal(err)
+			}
 		} else if m.state.IsComponentActive(state.SingleDocEditor) {
 			cmd = m.singleDocEditor.EditDoc()
 			m.state.SetActiveComponent(state.DocList)
@@ -93,6 +162,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmd = m.singleDocEditor.InsertDoc()
 			m.state.SetActiveComponent(state.DocList)
 			cmds = append(cmds, cmd, tea.ClearScreen)
+		} else if m.state.IsComponentActive(state.DocClone) {
+			cmd = m.singleDocEditor.CloneDoc()
+			m.state.SetActiveComponent(state.DocList)
+			cmds = append(cmds, cmd, tea.ClearScreen)
+		} else if m.state.IsComponentActive(state.DocUpdateMany) {
+			query, _ := m.docList.GetQuery() // Already validated by the docList before switching state
+			cmd = m.singleDocEditor.UpdateMatching(query)
+			m.state.SetActiveComponent(state.DocList)
+			cmds = append(cmds, cmd, tea.ClearScreen)
 		}
 	case state.SingleDocViewer:
 		m.singleDocViewer, cmd = m.singleDocViewer.Update(msg)
@@ -100,6 +178,34 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.docList.Focus()
 		}
 		cmds = append(cmds, cmd)
+	case state.ExplainViewer:
+		m.explainViewer, cmd = m.explainViewer.Update(msg)
+		if m.state.IsComponentActive(state.DocList) {
+			m.docList.Focus()
+		}
+		cmds = append(cmds, cmd)
+	case state.SchemaViewer:
+		m.schemaViewer, cmd = m.schemaViewer.Update(msg)
+		if m.state.IsComponentActive(state.DocList) {
+			m.docList.Focus()
+		}
+		cmds = append(cmds, cmd)
+	case state.StagedChanges:
+		m.stagedChanges, cmd = m.stagedChanges.Update(msg)
+		if m.state.IsComponentActive(state.DocList) {
+			m.docList.Focus()
+		}
+		cmds = append(cmds, cmd)
+	case state.IndexList:
+		m.indexList, cmd = m.indexList.Update(msg)
+		cmds = append(cmds, cmd)
+		if m.state.IsComponentActive(state.DbColTable) {
+			m.dbColTable.Focus()
+		} else if m.state.IsComponentActive(state.IndexCreate) {
+			cmd = m.singleDocEditor.CreateIndex()
+			m.state.SetActiveComponent(state.IndexList)
+			cmds = append(cmds, cmd, tea.ClearScreen)
+		}
 	case state.SingleDocEditor: // This shouldn't happen
 		panic("SingleDocEditor should only be selected after an update to DocList")
 	default:
@@ -109,20 +215,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-// Init is used to satisfy the tea.Model interface
+// Init starts loading the collections displayed in the dbcoltable. It also satisfies the tea.Model interface
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return m.dbColTable.Init()
 }
 
 func (m *Model) View() string {
 	if m.state.GetActiveComponent() == state.SingleDocViewer {
 		return m.singleDocViewer.View()
+	} else if m.state.GetActiveComponent() == state.ExplainViewer {
+		return m.explainViewer.View()
+	} else if m.state.GetActiveComponent() == state.IndexList {
+		return m.indexList.View()
+	} else if m.state.GetActiveComponent() == state.SchemaViewer {
+		return m.schemaViewer.View()
+	} else if m.state.GetActiveComponent() == state.StagedChanges {
+		return m.stagedChanges.View()
 	}
 	tables := lipgloss.JoinHorizontal(lipgloss.Left, m.dbColTable.View(), m.docList.View())
 	if m.state.GetActiveComponent() == state.DbColTable {
-		return lipgloss.JoinVertical(lipgloss.Top, tables, m.dbColTable.HelpView())
+		return lipgloss.JoinVertical(lipgloss.Top, tables, m.statusBar.View(), m.dbColTable.HelpView())
 	} else {
-		return lipgloss.JoinVertical(lipgloss.Top, tables, m.docList.HelpView())
+		return lipgloss.JoinVertical(lipgloss.Top, tables, m.statusBar.View(), m.docList.HelpView())
 	}
 }
 
@@ -0,0 +1,84 @@
+package mainview
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/internal/testutil"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"github.com/kreulenk/mongotui/pkg/mainview/state"
+	"github.com/kreulenk/mongotui/pkg/mongoengine"
+	"strings"
+	"testing"
+)
+
+func newTestModel(t *testing.T) (*Model, *mongoengine.Engine) {
+	t.Helper()
+	engine := testutil.NewEngine(t, map[string][]any{"shop.users": testutil.NumberedDocs(3)})
+	m := New(engine)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	testutil.RunCmd(m.Init())
+	return m, engine
+}
+
+// send updates the model and then feeds any messages produced by the returned commands back into
+// the model the same way that the bubbletea runtime would
+func send(t *testing.T, m *Model, msg tea.Msg) {
+	t.Helper()
+	_, cmd := m.Update(msg)
+	for _, resMsg := range testutil.RunCmd(cmd) {
+		if errMsg, ok := resMsg.(modal.ErrModalMsg); ok {
+			t.Fatalf("received error: %v", errMsg.Err)
+		}
+		switch resMsg.(type) {
+		case mongoengine.RedrawMessage, mongoengine.ExplainReadyMsg, mongoengine.SchemaReadyMsg:
+			send(t, m, resMsg)
+		}
+	}
+}
+
+func TestComponentSwitching(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		wantComponent state.ActiveComponent
+		wantInView    string
+	}{
+		{name: "starts on the dbcoltable", wantComponent: state.DbColTable, wantInView: "shop"},
+		{name: "enter a collection", keys: []string{"l", "l"}, wantComponent: state.DocList, wantInView: "viewing documents 1-3 of ~3 (estimated)"},
+		{name: "view a document", keys: []string{"l", "l", "v"}, wantComponent: state.SingleDocViewer, wantInView: `"n"`},
+		{name: "back from the document viewer", keys: []string{"l", "l", "v", "b"}, wantComponent: state.DocList, wantInView: "users"},
+		{name: "explain the query", keys: []string{"l", "l", "x"}, wantComponent: state.ExplainViewer, wantInView: "COLLSCAN"},
+		{name: "back from the explain viewer", keys: []string{"l", "l", "x", "b"}, wantComponent: state.DocList, wantInView: "users"},
+		{name: "infer the schema", keys: []string{"l", "l", "s"}, wantComponent: state.SchemaViewer, wantInView: "Schema of shop.users (3 docs sampled)"},
+		{name: "back from the schema viewer", keys: []string{"l", "l", "s", "b"}, wantComponent: state.DocList, wantInView: "users"},
+		{name: "open the indexes", keys: []string{"l", "x"}, wantComponent: state.IndexList, wantInView: "Indexes of shop.users (1)"},
+		{name: "back from the indexes", keys: []string{"l", "x", "b"}, wantComponent: state.DbColTable, wantInView: "users"},
+		{name: "back to the dbcoltable", keys: []string{"l", "l", "h"}, wantComponent: state.DbColTable, wantInView: "users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModel(t)
+			for _, k := range tt.keys {
+				send(t, m, testutil.KeyPress(k))
+			}
+			if got := m.state.GetActiveComponent(); got != tt.wantComponent {
+				t.Errorf("active component = %v, want %v", got, tt.wantComponent)
+			}
+			if view := m.View(); !strings.Contains(view, tt.wantInView) {
+				t.Errorf("View() does not contain %q:\n%s", tt.wantInView, view)
+			}
+		})
+	}
+}
+
+func TestDocDeleteIsRoutedToDocList(t *testing.T) {
+	m, engine := newTestModel(t)
+	send(t, m, testutil.KeyPress("l"))
+	send(t, m, testutil.KeyPress("l"))
+	send(t, m, testutil.KeyPress("h")) // The delete should still be handled after the doclist loses focus
+
+	send(t, m, modal.ExecDocDelete{Doc: engine.GetQueriedDocs()[0]})
+	if engine.DocCount != 2 {
+		t.Errorf("DocCount = %d, want 2", engine.DocCount)
+	}
+}
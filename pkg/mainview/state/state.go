@@ -11,6 +11,14 @@ const (
 	SingleDocViewer
 	SingleDocEditor
 	DocInsert
+	ExplainViewer
+	IndexList
+	IndexCreate
+	SchemaViewer
+	DocUpdateMany
+	CollectionCreate
+	StagedChanges
+	DocClone
 )
 
 func DefaultState() *MainViewState {
@@ -0,0 +1,102 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Backend contains every database operation used by the Engine. MongoBackend runs these operations against a
+// live MongoDB server while MemoryBackend keeps everything in memory so that components can be tested without one
+type Backend interface {
+	ListDatabaseNames(ctx context.Context) ([]string, error)
+	ListCollectionNames(ctx context.Context, db string) ([]string, error)
+
+	// Find and Aggregate decode every returned document into results which must be a pointer to a slice
+	Find(ctx context.Context, db, coll string, filter any, opts FindOptions, results any) error
+	Aggregate(ctx context.Context, db, coll string, pipeline any, results any) error
+	// FindCursor and AggregateCursor return the documents one at a time so that results too large to hold in
+	// memory can be streamed
+	FindCursor(ctx context.Context, db, coll string, filter any, opts FindOptions) (Cursor, error)
+	AggregateCursor(ctx context.Context, db, coll string, pipeline any) (Cursor, error)
+	// CountDocuments will stop counting once limit is reached. A limit of 0 counts every matching document
+	CountDocuments(ctx context.Context, db, coll string, filter any, limit int64) (int64, error)
+	// EstimatedDocumentCount returns the number of docs in a collection using its metadata rather than a scan
+	EstimatedDocumentCount(ctx context.Context, db, coll string) (int64, error)
+
+	// InsertOne returns the _id of the inserted doc, which is generated when the doc does not have one
+	InsertOne(ctx context.Context, db, coll string, doc any) (insertedId any, err error)
+	ReplaceOne(ctx context.Context, db, coll string, filter, replacement any) (matchedCount int64, err error)
+	DeleteOne(ctx context.Context, db, coll string, filter any) (deletedCount int64, err error)
+	// UpdateMany applies update, either a document of update operators or an update pipeline, to every matching doc
+	UpdateMany(ctx context.Context, db, coll string, filter, update any) (matchedCount, modifiedCount int64, err error)
+	DeleteMany(ctx context.Context, db, coll string, filter any) (deletedCount int64, err error)
+	// InsertMany inserts docs in a single batch. When ordered the batch stops at the first doc that fails,
+	// otherwise every doc is attempted. Docs that fail are reported in the BulkResult rather than as an error
+	InsertMany(ctx context.Context, db, coll string, docs []bson.D, ordered bool) (BulkResult, error)
+	// UpsertMany replaces the doc matching each filter with the doc at the same index, inserting it when nothing
+	// matches. Failures are reported in the same way as InsertMany
+	UpsertMany(ctx context.Context, db, coll string, filters, docs []bson.D, ordered bool) (BulkResult, error)
+
+	// RunCommand runs a database command such as explain and decodes the reply into result
+	RunCommand(ctx context.Context, db string, cmd any, result any) error
+
+	// ListIndexes decodes the spec of every index of a collection into results which must be a pointer to a slice
+	ListIndexes(ctx context.Context, db, coll string, results any) error
+	// CreateIndex creates an index from a spec in the format accepted by the createIndexes command
+	CreateIndex(ctx context.Context, db, coll string, spec any) error
+	DropIndex(ctx context.Context, db, coll, name string) error
+
+	// CreateCollection creates a collection, or a view when opts contains viewOn, with the options of the create
+	// command such as capped, timeseries or validator
+	CreateCollection(ctx context.Context, db, coll string, opts bson.D) error
+	DropDatabase(ctx context.Context, db string) error
+	DropCollection(ctx context.Context, db, coll string) error
+	// RenameCollection moves a collection to a new name, which may be in another database. An existing target is
+	// only replaced when dropTarget is set
+	RenameCollection(ctx context.Context, db, coll, toDb, toColl string, dropTarget bool) error
+
+	// Watch opens a change stream on a collection. Updates include the full document as it is after the update
+	Watch(ctx context.Context, db, coll string) (ChangeStream, error)
+
+	// SetConcerns changes the read preference, read concern and write concern of every following operation
+	SetConcerns(c Concerns)
+
+	// WithTransaction runs fn inside a transaction that is committed if fn returns nil and aborted otherwise. The
+	// operations that are part of the transaction must be passed the ctx given to fn. fn may be retried
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Cursor is the subset of *mongo.Cursor used to stream the documents returned by a query
+type Cursor interface {
+	// Next advances to the next document. false is returned once there are no more documents, the cursor fails
+	// or ctx is done
+	Next(ctx context.Context) bool
+	Decode(val any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// ChangeStream is the subset of *mongo.ChangeStream used to follow the changes made to a collection
+type ChangeStream interface {
+	// Next blocks until the next event is received. false is returned once the stream fails or ctx is done
+	Next(ctx context.Context) bool
+	Decode(val any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// BulkResult counts the docs written by InsertMany or UpsertMany. When ordered, the docs following the first
+// failure are not attempted and so are counted as neither written nor failed
+type BulkResult struct {
+	Written      int64
+	Failed       int64
+	FirstFailure string // Why the first doc to fail was rejected
+}
+
+// FindOptions are the options supported by Backend.Find. Empty fields are not sent to the server
+type FindOptions struct {
+	Skip       int64
+	Limit      int64
+	Sort       bson.D
+	Projection bson.D
+}
@@ -0,0 +1,187 @@
+package mongoengine
+
+// The methods contained within this file modify every document matched by a filter rather than the single
+// document that is selected. The matching documents are always counted first so that the change can be confirmed
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const updatePreviewSize = 3 // Number of documents shown before and after an update when it is previewed
+
+// DocsUpdatedMsg is sent once UpdateMatchingDocs has updated the documents matching its filter
+type DocsUpdatedMsg struct {
+	Matched  int64
+	Modified int64
+}
+
+// DocsDeletedMsg is sent once DeleteMatchingDocs has deleted the documents matching its filter
+type DocsDeletedMsg struct {
+	Count int64
+}
+
+// PreviewDeleteMatching counts the documents matched by the filter of query within the selected collection and
+// asks the user to confirm their deletion. The limit of the query is ignored as every matching document is deleted
+func (e *Engine) PreviewDeleteMatching(query Query) tea.Cmd {
+	if query.IsAggregation() {
+		return modal.DisplayErrorModal(fmt.Errorf("cannot delete the documents matched by an aggregation, switch to a find filter first"))
+	}
+	if e.IsStaging() {
+		return modal.DisplayErrorModal(fmt.Errorf("deleting the documents matching a filter can not be staged, stop staging first"))
+	}
+	ctx, id, err := e.startPinnedOperation(Timeout)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return func() tea.Msg {
+		defer e.finishPinnedOperation(id)
+
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+
+		n, err := e.backend.CountDocuments(ctx, dbName, collName, query.filter(), 0)
+		if err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not count the documents to delete: %w", err))
+		}
+		if n == 0 {
+			return modal.ErrModalMsg{Err: fmt.Errorf("no documents match the filter")}
+		}
+		return modal.DisplayDocDeleteManyModal(dbName, collName, query.filter(), n)()
+	}
+}
+
+// DeleteMatchingDocs deletes every document matching filter. Unlike a query, it is neither cancelled by a newer query
+// nor by Timeout, as deleting a large collection may take longer, so it runs until the user cancels it. The
+// documents deleted before the cancellation stay deleted
+func (e *Engine) DeleteMatchingDocs(dbName, collName string, filter bson.D) tea.Cmd {
+	ctx, id, err := e.startPinnedOperation(noTimeout)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return func() tea.Msg {
+		defer e.finishPinnedOperation(id)
+
+		n, err := e.backend.DeleteMany(ctx, dbName, collName, filter)
+		if err != nil {
+			return writeErrMsg(ctx, fmt.Errorf("failed to delete documents, those already deleted stay deleted: %w", err))
+		}
+		return DocsDeletedMsg{Count: n}
+	}
+}
+
+// PreviewUpdateMatching counts the documents matched by the filter of query and runs the update as an aggregation
+// on the first few of them so that the user can see its effect before confirming it. update is either a document
+// of update operators or an update pipeline
+func (e *Engine) PreviewUpdateMatching(query Query, update any) tea.Cmd {
+	if query.IsAggregation() {
+		return modal.DisplayErrorModal(fmt.Errorf("cannot update the documents matched by an aggregation, switch to a find filter first"))
+	}
+	if e.IsStaging() {
+		return modal.DisplayErrorModal(fmt.Errorf("updating the documents matching a filter can not be staged, stop staging first"))
+	}
+	previewStages, err := updatePreviewStages(update)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	ctx, id, err := e.startPinnedOperation(Timeout)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return func() tea.Msg {
+		defer e.finishPinnedOperation(id)
+
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+
+		n, err := e.backend.CountDocuments(ctx, dbName, collName, query.filter(), 0)
+		if err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not count the documents to update: %w", err))
+		}
+		if n == 0 {
+			return modal.ErrModalMsg{Err: fmt.Errorf("no documents match the filter")}
+		}
+
+		sample := []bson.D{
+			{{Key: "$match", Value: query.filter()}},
+			{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+			{{Key: "$limit", Value: updatePreviewSize}},
+		}
+		var before, after []bson.D
+		if err := e.backend.Aggregate(ctx, dbName, collName, sample, &before); err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not fetch the documents to preview: %w", err))
+		}
+		if err := e.backend.Aggregate(ctx, dbName, collName, append(sample, previewStages...), &after); err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not preview the update: %w", err))
+		}
+		return modal.DisplayDocUpdateManyModal(dbName, collName, query.filter(), update, n, before, after)()
+	}
+}
+
+// UpdateMatchingDocs applies update to every document matching filter. Like DeleteMatchingDocs, it is neither
+// cancelled by a newer query nor by Timeout so it runs until the user cancels it. The documents updated before the
+// cancellation stay updated
+func (e *Engine) UpdateMatchingDocs(dbName, collName string, filter bson.D, update any) tea.Cmd {
+	ctx, id, err := e.startPinnedOperation(noTimeout)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return func() tea.Msg {
+		defer e.finishPinnedOperation(id)
+
+		matched, modified, err := e.backend.UpdateMany(ctx, dbName, collName, filter, update)
+		if err != nil {
+			return writeErrMsg(ctx, fmt.Errorf("failed to update documents, those already updated stay updated: %w", err))
+		}
+		return DocsUpdatedMsg{Matched: matched, Modified: modified}
+	}
+}
+
+// updatePreviewStages converts an update into the aggregation stages with the same effect. An update pipeline
+// is used as is while $set, $unset, $inc and $rename are rewritten as $set and $unset stages
+func updatePreviewStages(update any) ([]bson.D, error) {
+	operators, ok := update.(bson.D)
+	if !ok {
+		stages, ok := update.([]bson.D)
+		if !ok {
+			return nil, fmt.Errorf("an update must be a document or a pipeline")
+		}
+		return stages, nil
+	}
+
+	var stages []bson.D
+	for _, op := range operators {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object", op.Key)
+		}
+		set, unset := bson.D{}, bson.A{}
+		for _, field := range fields {
+			switch op.Key {
+			case "$set": // Wrapped in $literal so that strings starting with $ are not read as field paths
+				set = append(set, bson.E{Key: field.Key, Value: bson.D{{Key: "$literal", Value: field.Value}}})
+			case "$unset":
+				unset = append(unset, field.Key)
+			case "$inc":
+				current := bson.D{{Key: "$ifNull", Value: bson.A{"$" + field.Key, 0}}}
+				set = append(set, bson.E{Key: field.Key, Value: bson.D{{Key: "$add", Value: bson.A{current, field.Value}}}})
+			case "$rename":
+				set = append(set, bson.E{Key: fmt.Sprint(field.Value), Value: "$" + field.Key})
+				unset = append(unset, field.Key)
+			default:
+				return nil, fmt.Errorf("%s can not be previewed, only $set, $unset, $inc and $rename can", op.Key)
+			}
+		}
+		if len(set) > 0 {
+			stages = append(stages, bson.D{{Key: "$set", Value: set}})
+		}
+		if len(unset) > 0 {
+			stages = append(stages, bson.D{{Key: "$unset", Value: unset}})
+		}
+	}
+	return stages, nil
+}
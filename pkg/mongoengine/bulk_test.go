@@ -0,0 +1,206 @@
+package mongoengine
+
+import (
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestUpdateMatching(t *testing.T) {
+	ctx := context.Background()
+	adults := bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 30}}}}
+
+	tests := []struct {
+		name         string
+		query        Query
+		update       any
+		wantMatched  int64
+		wantModified int64
+		wantPreview  bool
+	}{
+		{
+			name:         "set a string that looks like a field path",
+			query:        Query{Filter: adults},
+			update:       bson.D{{Key: "$set", Value: bson.D{{Key: "tier", Value: "$gold"}, {Key: "address.zip", Value: "02110"}}}},
+			wantMatched:  2,
+			wantModified: 2,
+			wantPreview:  true,
+		},
+		{
+			name:         "inc a missing field and unset",
+			query:        Query{},
+			update:       bson.D{{Key: "$inc", Value: bson.D{{Key: "visits", Value: 1}, {Key: "age", Value: 1}}}, {Key: "$unset", Value: bson.D{{Key: "tags", Value: ""}}}},
+			wantMatched:  3,
+			wantModified: 3,
+			wantPreview:  true,
+		},
+		{
+			name:         "rename",
+			query:        Query{Filter: bson.D{{Key: "_id", Value: 2}}},
+			update:       bson.D{{Key: "$rename", Value: bson.D{{Key: "name", Value: "fullName"}, {Key: "missing", Value: "other"}}}},
+			wantMatched:  1,
+			wantModified: 1,
+			wantPreview:  true,
+		},
+		{
+			name:         "unset a missing field modifies nothing",
+			query:        Query{},
+			update:       bson.D{{Key: "$unset", Value: bson.D{{Key: "missing", Value: ""}}}},
+			wantMatched:  3,
+			wantModified: 0,
+			wantPreview:  true,
+		},
+		{
+			name:         "pipeline",
+			query:        Query{Filter: adults},
+			update:       []bson.D{{{Key: "$set", Value: bson.D{{Key: "nextAge", Value: bson.D{{Key: "$add", Value: bson.A{"$age", 1}}}}}}}},
+			wantMatched:  2,
+			wantModified: 2,
+			wantPreview:  true,
+		},
+		{name: "nothing matches", query: Query{Filter: bson.D{{Key: "_id", Value: 100}}}, update: bson.D{{Key: "$set", Value: bson.D{{Key: "a", Value: 1}}}}},
+		{name: "aggregation", query: Query{Pipeline: []bson.D{}}, update: bson.D{{Key: "$set", Value: bson.D{{Key: "a", Value: 1}}}}},
+		{name: "operator that can not be previewed", query: Query{}, update: bson.D{{Key: "$push", Value: bson.D{{Key: "tags", Value: "new"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+
+			msg := e.PreviewUpdateMatching(tt.query, tt.update)()
+			if _, ok := msg.(modal.DocUpdateManyModalMsg); ok != tt.wantPreview {
+				t.Fatalf("PreviewUpdateMatching() = %#v, want the modal = %v", msg, tt.wantPreview)
+			}
+			if !tt.wantPreview {
+				return
+			}
+
+			// The preview must match what the update actually does
+			stages, err := updatePreviewStages(tt.update)
+			if err != nil {
+				t.Fatalf("updatePreviewStages() error = %v", err)
+			}
+			var preview []bson.D
+			pipeline := append([]bson.D{{{Key: "$match", Value: tt.query.filter()}}}, stages...)
+			if err := backend.Aggregate(ctx, "shop", "users", pipeline, &preview); err != nil {
+				t.Fatalf("Aggregate() error = %v", err)
+			}
+
+			updated, ok := e.UpdateMatchingDocs("shop", "users", tt.query.filter(), tt.update)().(DocsUpdatedMsg)
+			if !ok || updated.Matched != tt.wantMatched || updated.Modified != tt.wantModified {
+				t.Fatalf("UpdateMatchingDocs() = %#v, want %d matched and %d modified", updated, tt.wantMatched, tt.wantModified)
+			}
+			var ids bson.A
+			for _, doc := range preview {
+				id, _ := lookupField(doc, "_id")
+				ids = append(ids, id)
+			}
+			var after []bson.D
+			filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}
+			if err := backend.Find(ctx, "shop", "users", filter, FindOptions{}, &after); err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if !valuesEqual(toBsonA(preview), toBsonA(after)) {
+				t.Errorf("preview = %v, want %v", preview, after)
+			}
+		})
+	}
+}
+
+func toBsonA(docs []bson.D) bson.A {
+	arr := bson.A{}
+	for _, doc := range docs {
+		arr = append(arr, doc)
+	}
+	return arr
+}
+
+func TestBulkWriteCancellation(t *testing.T) {
+	tests := []struct {
+		name        string
+		interrupt   func(e *Engine)
+		wantDeleted bool
+	}{
+		{name: "query started during the write", interrupt: func(e *Engine) { e.QueryCollection(Query{})() }, wantDeleted: true},
+		{name: "another write started during the write", interrupt: func(e *Engine) { e.DeleteMatchingDocs("shop", "users", bson.D{}) }, wantDeleted: true},
+		{name: "cancelled by the user", interrupt: func(e *Engine) { e.CancelOperation() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+			cmd := e.DeleteMatchingDocs("shop", "users", bson.D{})
+
+			tt.interrupt(e)
+			msg := cmd()
+			if tt.wantDeleted {
+				if msg != (DocsDeletedMsg{Count: 3}) {
+					t.Errorf("DeleteMatchingDocs() = %#v, want all 3 documents deleted", msg)
+				}
+				return
+			}
+			// A cancelled write may have been partly applied so it must be reported rather than dropped
+			if _, ok := msg.(modal.ErrModalMsg); !ok {
+				t.Errorf("DeleteMatchingDocs() = %#v, want an error modal", msg)
+			}
+			if e.IsOperationRunning() {
+				t.Errorf("IsOperationRunning() = true after the write was cancelled")
+			}
+		})
+	}
+}
+
+// deadlineBackend records if the bulk writes made through it were given a deadline
+type deadlineBackend struct {
+	*MemoryBackend
+	hadDeadline bool
+}
+
+func (b *deadlineBackend) DeleteMany(ctx context.Context, db, coll string, filter any) (int64, error) {
+	_, b.hadDeadline = ctx.Deadline()
+	return b.MemoryBackend.DeleteMany(ctx, db, coll, filter)
+}
+
+func (b *deadlineBackend) UpdateMany(ctx context.Context, db, coll string, filter, update any) (int64, int64, error) {
+	_, b.hadDeadline = ctx.Deadline()
+	return b.MemoryBackend.UpdateMany(ctx, db, coll, filter, update)
+}
+
+func TestBulkWriteHasNoTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		write   func(e *Engine) tea.Cmd
+		wantMsg tea.Msg
+	}{
+		{
+			name:    "delete",
+			write:   func(e *Engine) tea.Cmd { return e.DeleteMatchingDocs("shop", "users", bson.D{}) },
+			wantMsg: DocsDeletedMsg{Count: 3},
+		},
+		{
+			name: "update",
+			write: func(e *Engine) tea.Cmd {
+				return e.UpdateMatchingDocs("shop", "users", bson.D{}, bson.D{{Key: "$set", Value: bson.D{{Key: "a", Value: 1}}}})
+			},
+			wantMsg: DocsUpdatedMsg{Matched: 3, Modified: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &deadlineBackend{MemoryBackend: newSeededBackend(t)}
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+			if msg := tt.write(e)(); msg != tt.wantMsg {
+				t.Fatalf("write = %#v, want %#v", msg, tt.wantMsg)
+			}
+			// Stopping a large write at Timeout would leave it partly applied
+			if backend.hadDeadline {
+				t.Errorf("the write was given a deadline, want it to run until it completes or is cancelled")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package mongoengine
+
+// The methods contained within this file manage the read preference, read concern and write concern that queries
+// and writes are sent with, such as to browse the secondaries of a replica set without loading its primary
+
+import (
+	"fmt"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConcernsChangedMsg is sent once the concerns were changed by the user so that the new ones can be displayed
+type ConcernsChangedMsg struct {
+	Concerns Concerns
+}
+
+var readConcernLevels = []string{"local", "available", "majority", "linearizable", "snapshot"}
+
+// Concerns are applied to the Database and Collection handles of the backend. Empty fields keep the default of the
+// client, which may have been set in the connection string
+type Concerns struct {
+	ReadPreference *readpref.ReadPref // Includes the tag sets and max staleness that may be set in the connection string
+	ReadConcern    string             // One of readConcernLevels
+	W              string             // A number of members, majority or the name of a tag set
+	Journal        *bool
+	WriteTimeout   time.Duration // How long the client waits for a write before abandoning it, which may still be applied
+}
+
+// ParseConcerns validates the concerns entered by the user. journal is yes or no and writeTimeout is in milliseconds
+func ParseConcerns(readPreference, readConcern, w, journal, writeTimeout string) (Concerns, error) {
+	var c Concerns
+	if readPreference = strings.TrimSpace(readPreference); readPreference != "" {
+		mode, err := readpref.ModeFromString(readPreference)
+		if err != nil {
+			return Concerns{}, err
+		}
+		if c.ReadPreference, err = readpref.New(mode); err != nil {
+			return Concerns{}, err
+		}
+	}
+	c.ReadConcern = strings.ToLower(strings.TrimSpace(readConcern))
+	if c.ReadConcern != "" && !slices.Contains(readConcernLevels, c.ReadConcern) {
+		return Concerns{}, fmt.Errorf("unknown read concern %s, must be one of %s", readConcern, strings.Join(readConcernLevels, ", "))
+	}
+	c.W = strings.TrimSpace(w)
+	if journal = strings.TrimSpace(journal); journal != "" {
+		j, err := modal.ParseYesNo("journal", journal)
+		if err != nil {
+			return Concerns{}, err
+		}
+		c.Journal = &j
+	}
+	if writeTimeout = strings.TrimSpace(writeTimeout); writeTimeout != "" {
+		ms, err := strconv.Atoi(writeTimeout)
+		if err != nil || ms < 0 {
+			return Concerns{}, fmt.Errorf("write timeout must be a number of milliseconds")
+		}
+		c.WriteTimeout = time.Duration(ms) * time.Millisecond
+	}
+	if wc := c.WriteConcern(); wc != nil && !wc.IsValid() {
+		return Concerns{}, fmt.Errorf("a write concern of w 0 cannot request journal acknowledgment")
+	}
+	return c, nil
+}
+
+// Update parses the concerns edited by the user. The read preference is kept as is, along with its tag sets and max
+// staleness, unless the user changed its mode. A cleared read preference reads from the primary as it is displayed
+func (c Concerns) Update(readPreference, readConcern, w, journal, writeTimeout string) (Concerns, error) {
+	updated, err := ParseConcerns(readPreference, readConcern, w, journal, writeTimeout)
+	if err != nil {
+		return Concerns{}, err
+	}
+	if updated.ReadPreference == nil {
+		updated.ReadPreference = readpref.Primary()
+	} else if c.ReadPreference != nil && updated.ReadPreference.Mode() == c.ReadPreference.Mode() {
+		updated.ReadPreference = c.ReadPreference
+	}
+	return updated, nil
+}
+
+// WriteConcern returns nil when neither W nor Journal are set so that the default of the client is kept
+func (c Concerns) WriteConcern() *writeconcern.WriteConcern {
+	if c.W == "" && c.Journal == nil {
+		return nil
+	}
+	wc := &writeconcern.WriteConcern{Journal: c.Journal}
+	if n, err := strconv.Atoi(c.W); err == nil {
+		wc.W = n
+	} else if c.W != "" {
+		wc.W = c.W
+	}
+	return wc
+}
+
+// ReadPreferenceName is the name of the read preference, which defaults to the primary when not set
+func (c Concerns) ReadPreferenceName() string {
+	if c.ReadPreference == nil {
+		return readpref.PrimaryMode.String()
+	}
+	return c.ReadPreference.Mode().String()
+}
+
+// Strings formats the concerns in the same way as they are given to ParseConcerns
+func (c Concerns) Strings() (readPreference, readConcern, w, journal, writeTimeout string) {
+	if c.ReadPreference != nil {
+		readPreference = c.ReadPreference.Mode().String()
+	}
+	if c.Journal != nil {
+		journal = "no"
+		if *c.Journal {
+			journal = "yes"
+		}
+	}
+	if c.WriteTimeout > 0 {
+		writeTimeout = strconv.FormatInt(c.WriteTimeout.Milliseconds(), 10)
+	}
+	return readPreference, c.ReadConcern, c.W, journal, writeTimeout
+}
+
+// SetConcerns changes the concerns that every following query and write is sent with
+func (e *Engine) SetConcerns(c Concerns) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.concerns = c
+	e.backend.SetConcerns(c)
+}
@@ -0,0 +1,114 @@
+package mongoengine
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseConcerns(t *testing.T) {
+	journaled := true
+	tests := []struct {
+		name                                                  string
+		readPreference, readConcern, w, journal, writeTimeout string
+		wantReadPreference                                    string
+		wantWriteConcern                                      *writeconcern.WriteConcern
+		wantErr                                               bool
+	}{
+		{name: "defaults", wantReadPreference: "primary"},
+		{name: "secondaries with majority reads", readPreference: "secondaryPreferred", readConcern: "Majority", wantReadPreference: "secondaryPreferred"},
+		{name: "numbered write concern", readPreference: "nearest", w: "2", journal: "yes", writeTimeout: "500", wantReadPreference: "nearest", wantWriteConcern: &writeconcern.WriteConcern{W: 2, Journal: &journaled}},
+		{name: "majority write concern", w: "majority", wantReadPreference: "primary", wantWriteConcern: &writeconcern.WriteConcern{W: "majority"}},
+		{name: "unknown read preference", readPreference: "tertiary", wantErr: true},
+		{name: "unknown read concern", readConcern: "eventual", wantErr: true},
+		{name: "invalid journal", journal: "maybe", wantErr: true},
+		{name: "invalid write timeout", writeTimeout: "5s", wantErr: true},
+		{name: "unacknowledged journal", w: "0", journal: "yes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConcerns(tt.readPreference, tt.readConcern, tt.w, tt.journal, tt.writeTimeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConcerns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := c.ReadPreferenceName(); got != tt.wantReadPreference {
+				t.Errorf("ReadPreferenceName() = %q, want %q", got, tt.wantReadPreference)
+			}
+			if got := c.WriteConcern(); !reflect.DeepEqual(got, tt.wantWriteConcern) {
+				t.Errorf("WriteConcern() = %#v, want %#v", got, tt.wantWriteConcern)
+			}
+			// The concerns are displayed in the form they are parsed from when edited again
+			again, err := ParseConcerns(c.Strings())
+			if err != nil || !reflect.DeepEqual(again, c) {
+				t.Errorf("ParseConcerns(Strings()) = %#v, %v, want %#v", again, err, c)
+			}
+		})
+	}
+}
+
+func TestUpdateConcerns(t *testing.T) {
+	// As parsed from a connection string with ?readPreference=secondary&readPreferenceTags=dc:east
+	fromURI := readpref.Secondary(readpref.WithTags("dc", "east"))
+	tests := []struct {
+		name           string
+		readPreference string
+		wantTags       bool
+		wantName       string
+	}{
+		{name: "unchanged mode keeps the tags", readPreference: "secondary", wantTags: true, wantName: "secondary"},
+		{name: "changed mode", readPreference: "nearest", wantName: "nearest"},
+		{name: "cleared", readPreference: "", wantName: "primary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Concerns{ReadPreference: fromURI}.Update(tt.readPreference, "majority", "", "", "")
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := c.ReadPreference == fromURI; got != tt.wantTags {
+				t.Errorf("Update() read preference = %v, want the one from the URI = %v", c.ReadPreference, tt.wantTags)
+			}
+			if got := c.ReadPreferenceName(); got != tt.wantName {
+				t.Errorf("ReadPreferenceName() = %q, want %q", got, tt.wantName)
+			}
+			if c.ReadConcern != "majority" {
+				t.Errorf("ReadConcern = %q, want the edited read concern", c.ReadConcern)
+			}
+		})
+	}
+}
+
+func TestWriteTimeoutErr(t *testing.T) {
+	tests := []struct {
+		name         string
+		writeTimeout time.Duration
+		wantUnknown  bool
+	}{
+		{name: "timed out write may have been applied", writeTimeout: time.Millisecond, wantUnknown: true},
+		{name: "no write timeout", writeTimeout: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &MongoBackend{concerns: Concerns{WriteTimeout: tt.writeTimeout}}
+			ctx, cancel := b.writeContext(context.Background())
+			defer cancel()
+			if tt.writeTimeout > 0 {
+				<-ctx.Done()
+			}
+			err := writeErr(ctx, context.DeadlineExceeded)
+			if got := errors.Is(err, errWriteTimedOut); got != tt.wantUnknown {
+				t.Errorf("writeErr() = %v, want it to say the outcome is unknown %v", err, tt.wantUnknown)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("writeErr() = %v, want it to wrap the error of the write", err)
+			}
+		})
+	}
+}
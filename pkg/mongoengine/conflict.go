@@ -0,0 +1,59 @@
+package mongoengine
+
+// The methods contained within this file detect that a document was changed by someone else between it being
+// loaded into the doclist and the user editing or deleting it
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"maps"
+	"slices"
+)
+
+// ConflictError is returned when a document no longer matches the version that was loaded
+type ConflictError struct {
+	Current bson.M // The document as it is now
+}
+
+func (e *ConflictError) Error() string {
+	return "the document was changed by someone else since it was loaded"
+}
+
+// unchangedFilter matches a document only while it is as it was loaded: every loaded field must still be equal and
+// none may have been added. Used as the filter of the write itself, the check and the write happen atomically
+func unchangedFilter(loaded bson.M) bson.D {
+	filter := bson.D{}
+	if id, ok := loaded["_id"]; ok { // Listed first so that the _id index is used
+		filter = append(filter, bson.E{Key: "_id", Value: id})
+	}
+	for _, k := range slices.Sorted(maps.Keys(loaded)) {
+		if k != "_id" {
+			filter = append(filter, bson.E{Key: k, Value: bson.D{{Key: "$eq", Value: loaded[k]}}})
+		}
+	}
+	fieldCount := bson.D{{Key: "$size", Value: bson.D{{Key: "$objectToArray", Value: "$$ROOT"}}}}
+	return append(filter, bson.E{Key: "$expr", Value: bson.D{{Key: "$eq", Value: bson.A{fieldCount, len(loaded)}}}})
+}
+
+// writeUnchanged makes write, a DeleteOne or ReplaceOne given the filter to apply it with, only if the document is
+// still as it was loaded. Otherwise the document is fetched again to return a *ConflictError with its current
+// version, or an error if it was deleted
+func (e *Engine) writeUnchanged(ctx context.Context, dbName, collName string, loaded bson.M, write func(filter bson.D) (int64, error)) error {
+	n, err := write(unchangedFilter(loaded))
+	if err != nil || n > 0 {
+		return err
+	}
+	id, ok := loaded["_id"]
+	if !ok { // Without an _id there is no telling a changed document from a deleted one
+		return fmt.Errorf("the document was changed or deleted by someone else since it was loaded")
+	}
+	var current []bson.M
+	if err := e.backend.Find(ctx, dbName, collName, bson.D{{Key: "_id", Value: id}}, FindOptions{Limit: 1}, &current); err != nil {
+		return fmt.Errorf("failed to fetch the current version of the document: %w", err)
+	}
+	if len(current) == 0 {
+		return fmt.Errorf("the document was deleted by someone else since it was loaded")
+	}
+	return &ConflictError{Current: current[0]}
+}
@@ -0,0 +1,146 @@
+package mongoengine
+
+import (
+	"context"
+	"errors"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"reflect"
+	"testing"
+)
+
+// loadDoc fetches a doc the way the doclist does and round trips it through the editor's extended JSON
+func loadDoc(t *testing.T, backend Backend, id any) bson.M {
+	t.Helper()
+	var docs []bson.M
+	if err := backend.Find(context.Background(), "shop", "users", bson.D{{Key: "_id", Value: id}}, FindOptions{}, &docs); err != nil || len(docs) != 1 {
+		t.Fatalf("Find() = %v, %v", docs, err)
+	}
+	data, err := bson.MarshalExtJSON(docs[0], false, false)
+	if err != nil {
+		t.Fatalf("MarshalExtJSON() error = %v", err)
+	}
+	var loaded bson.M
+	if err := bson.UnmarshalExtJSON(data, false, &loaded); err != nil {
+		t.Fatalf("UnmarshalExtJSON() error = %v", err)
+	}
+	return loaded
+}
+
+func TestUpdateDocument(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		id           any
+		concurrent   bson.D // Update made by someone else after the doc was loaded
+		deleted      bool   // If someone else deleted the doc after it was loaded
+		wantConflict bool
+		wantErr      bool
+	}{
+		{name: "unchanged", id: 2},
+		{name: "changed by someone else", id: 2, concurrent: bson.D{{Key: "$set", Value: bson.D{{Key: "age", Value: 26}}}}, wantConflict: true, wantErr: true},
+		{name: "field added by someone else", id: 2, concurrent: bson.D{{Key: "$set", Value: bson.D{{Key: "nickname", Value: "Al"}}}}, wantConflict: true, wantErr: true},
+		{name: "field removed by someone else", id: 2, concurrent: bson.D{{Key: "$unset", Value: bson.D{{Key: "age", Value: ""}}}}, wantConflict: true, wantErr: true},
+		{name: "deleted by someone else", id: 3, deleted: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+			loaded := loadDoc(t, backend, tt.id)
+			if tt.concurrent != nil {
+				if _, _, err := backend.UpdateMany(ctx, "shop", "users", bson.D{{Key: "_id", Value: tt.id}}, tt.concurrent); err != nil {
+					t.Fatalf("UpdateMany() error = %v", err)
+				}
+			}
+			if tt.deleted {
+				if _, err := backend.DeleteOne(ctx, "shop", "users", bson.D{{Key: "_id", Value: tt.id}}); err != nil {
+					t.Fatalf("DeleteOne() error = %v", err)
+				}
+			}
+
+			edited := bson.M{"_id": loaded["_id"], "name": "Edited"}
+			err := e.UpdateDocument(loaded, edited)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var conflict *ConflictError
+			if errors.As(err, &conflict) != tt.wantConflict {
+				t.Fatalf("UpdateDocument() error = %v, want a conflict = %v", err, tt.wantConflict)
+			}
+			if tt.wantConflict {
+				var current []bson.M
+				if err := backend.Find(ctx, "shop", "users", bson.D{{Key: "_id", Value: tt.id}}, FindOptions{}, &current); err != nil || !reflect.DeepEqual([]bson.M{conflict.Current}, current) {
+					t.Errorf("conflict current doc = %v, want the concurrent change %v", conflict.Current, current)
+				}
+				// Overwriting replaces the current version instead
+				if err := e.UpdateDocument(conflict.Current, edited); err != nil {
+					t.Fatalf("UpdateDocument() against the current version error = %v", err)
+				}
+			}
+			if !tt.wantErr || tt.wantConflict {
+				if got := loadDoc(t, backend, tt.id); got["name"] != "Edited" {
+					t.Errorf("doc after the update = %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+
+	loaded := loadDoc(t, backend, 1)
+	if _, _, err := backend.UpdateMany(context.Background(), "shop", "users", bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "Kev"}}}}); err != nil {
+		t.Fatalf("UpdateMany() error = %v", err)
+	}
+	if msg := e.DeleteDocument(&loaded)(); !isConflictModal(msg) {
+		t.Fatalf("DeleteDocument() of a changed doc = %#v, want the conflict modal", msg)
+	}
+
+	loaded = loadDoc(t, backend, 1)
+	if msg := e.DeleteDocument(&loaded)(); msg != (RedrawMessage{}) {
+		t.Fatalf("DeleteDocument() = %#v", msg)
+	}
+	if n, _ := backend.CountDocuments(context.Background(), "shop", "users", bson.D{}, 0); n != 2 {
+		t.Errorf("CountDocuments() after the delete = %d, want 2", n)
+	}
+}
+
+func isConflictModal(msg any) bool {
+	_, ok := msg.(modal.DocConflictModalMsg)
+	return ok
+}
+
+func TestConflictOverwriteOfPartialDocs(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+	}{
+		{name: "projection", query: Query{Projection: bson.D{{Key: "name", Value: 1}}}},
+		{name: "pipeline", query: Query{Pipeline: []bson.D{{{Key: "$project", Value: bson.D{{Key: "name", Value: 1}}}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+			e.QueryCollection(tt.query)()
+
+			// The loaded doc is missing fields so overwriting the current version with it would drop them
+			partial := bson.M{"_id": int32(1), "name": "Edited"}
+			if err := e.UpdateDocument(loadDoc(t, backend, 1), partial); err == nil {
+				t.Errorf("UpdateDocument() of a partial doc succeeded")
+			}
+			if _, ok := e.DeleteDocument(&partial)().(modal.ErrModalMsg); !ok {
+				t.Errorf("DeleteDocument() of a partial doc was not refused")
+			}
+			if got := loadDoc(t, backend, 1); got["name"] != "Kevin" || got["age"] == nil {
+				t.Errorf("doc after the refused writes = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package mongoengine
+
+// The methods contained within this file copy a collection, or every collection of a database, to another namespace
+// on the connected server or on another server
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"strings"
+)
+
+// CopyOptions describe what Copy copies and where to
+type CopyOptions struct {
+	SourceDb   string
+	SourceColl string // Every collection of SourceDb is copied when empty
+	Filter     bson.D // Only the docs matching the filter are copied
+	TargetURI  string // Connection string of the server to copy to. The connected server is used when empty
+	TargetDb   string
+	TargetColl string // Ignored when a whole database is copied as its collections keep their names
+	Indexes    bool   // Recreate the indexes of every collection on its copy
+}
+
+// ParseCopyOptions validates the options entered by the user. filter is Extended JSON and may be left empty to
+// copy every doc, and indexes is yes or no
+func ParseCopyOptions(sourceDb, sourceColl, filter, targetDb, targetColl, targetURI, indexes string) (CopyOptions, error) {
+	opts := CopyOptions{
+		SourceDb:   sourceDb,
+		SourceColl: sourceColl,
+		Filter:     bson.D{},
+		TargetURI:  strings.TrimSpace(targetURI),
+		TargetDb:   strings.TrimSpace(targetDb),
+		TargetColl: strings.TrimSpace(targetColl),
+	}
+	if opts.SourceColl == "" {
+		opts.TargetColl = ""
+	}
+	if err := opts.validate(); err != nil {
+		return CopyOptions{}, err
+	}
+	if strings.TrimSpace(filter) != "" {
+		if err := bson.UnmarshalExtJSON([]byte(filter), false, &opts.Filter); err != nil {
+			return CopyOptions{}, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	var err error
+	if opts.Indexes, err = modal.ParseYesNo("indexes", indexes); err != nil {
+		return CopyOptions{}, err
+	}
+	return opts, nil
+}
+
+// validate checks that there is a target and that it is not the source, which would copy the docs onto themselves
+func (o CopyOptions) validate() error {
+	if o.TargetDb == "" || (o.SourceColl != "" && o.TargetColl == "") {
+		return fmt.Errorf("a target to copy to is required")
+	}
+	if o.SourceColl == "" && o.TargetColl != "" {
+		return fmt.Errorf("a target collection can only be given when copying a collection")
+	}
+	if o.TargetURI == "" && o.TargetDb == o.SourceDb && o.TargetColl == o.SourceColl {
+		return fmt.Errorf("the target must differ from the source when copying within the same server")
+	}
+	return nil
+}
+
+// copyResult counts what a copy wrote to its target
+type copyResult struct {
+	BulkResult
+	Indexes int
+	Views   int
+}
+
+// Copy streams the docs of a collection, or of every collection in a database, to the target as a background job.
+// The docs are inserted in batches and those that are rejected, such as for a duplicate _id, are counted rather
+// than failing the copy. The indexes are recreated once the docs of each collection have been copied. The views of a
+// database are recreated on the copy rather than copied as collections holding their results
+func (e *Engine) Copy(opts CopyOptions) tea.Cmd {
+	if err := opts.validate(); err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	source, target := opts.SourceDb, opts.TargetDb
+	if opts.SourceColl != "" {
+		source, target = source+"."+opts.SourceColl, target+"."+opts.TargetColl
+	}
+	if opts.TargetURI != "" {
+		target += " on another server"
+	}
+	description := fmt.Sprintf("copying %s to %s", source, target)
+	return e.runJob(description, func(ctx context.Context, j *job) (string, error) {
+		targetBackend := e.backend
+		if opts.TargetURI != "" {
+			connected, disconnect, err := e.connect(ctx, opts.TargetURI)
+			if err != nil {
+				return "", fmt.Errorf("could not connect to the target server: %w", err)
+			}
+			defer disconnect()
+			targetBackend = connected
+		} else {
+			j.refreshDatabases = true
+		}
+
+		res, err := e.copyNamespaces(ctx, j, targetBackend, opts)
+		if err != nil {
+			return "", err
+		}
+		summary := fmt.Sprintf("copied %d documents and %d indexes from %s to %s", res.Written, res.Indexes, source, target)
+		if res.Views > 0 {
+			summary += fmt.Sprintf(", recreated %d views", res.Views)
+		}
+		if res.Failed > 0 {
+			summary += fmt.Sprintf(", %d failed: %s", res.Failed, res.FirstFailure)
+		}
+		return summary, nil
+	})
+}
+
+// copyNamespaces copies every collection of the copy, followed by the views when a whole database is copied
+func (e *Engine) copyNamespaces(ctx context.Context, j *job, target Backend, opts CopyOptions) (copyResult, error) {
+	colls := []string{opts.SourceColl}
+	var views []viewSpec
+	if opts.SourceColl == "" {
+		names, err := e.backend.ListCollectionNames(ctx, opts.SourceDb)
+		if err != nil {
+			return copyResult{}, err
+		}
+		if views, err = e.listViews(ctx, opts.SourceDb); err != nil {
+			return copyResult{}, err
+		}
+		colls = colls[:0]
+		for _, name := range names {
+			isView := slices.ContainsFunc(views, func(v viewSpec) bool { return v.Name == name })
+			if !isView && !strings.HasPrefix(name, "system.") {
+				colls = append(colls, name)
+			}
+		}
+	}
+
+	var total int64 // Counted up front so that the progress of the copy can be shown
+	for _, coll := range colls {
+		n, err := e.backend.CountDocuments(ctx, opts.SourceDb, coll, opts.Filter, 0)
+		if err != nil {
+			return copyResult{}, err
+		}
+		total += n
+	}
+	j.total.Store(total)
+
+	var res copyResult
+	for _, coll := range colls {
+		targetColl := coll
+		if opts.SourceColl != "" {
+			targetColl = opts.TargetColl
+		}
+		written, err := e.copyDocs(ctx, j, target, opts.SourceDb, coll, opts.TargetDb, targetColl, opts.Filter)
+		res.Written += written.Written
+		res.Failed += written.Failed
+		if res.FirstFailure == "" {
+			res.FirstFailure = written.FirstFailure
+		}
+		if err != nil {
+			return res, err
+		}
+		if opts.Indexes {
+			created, err := e.copyIndexes(ctx, target, opts.SourceDb, coll, opts.TargetDb, targetColl)
+			res.Indexes += created
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+	// Created last as a view can only be created once the collection it is on exists
+	for _, view := range views {
+		if err := target.CreateCollection(ctx, opts.TargetDb, view.Name, view.Options); err != nil {
+			return res, fmt.Errorf("could not create the view %s.%s: %w", opts.TargetDb, view.Name, err)
+		}
+		res.Views++
+	}
+	return res, nil
+}
+
+// viewSpec is a view as listed by listCollections. Its options hold the viewOn and pipeline it was created with
+type viewSpec struct {
+	Name    string `bson:"name"`
+	Options bson.D `bson:"options"`
+}
+
+// listViews returns the views of a database. A database holds few enough views for them to all be returned in the
+// first batch of listCollections
+func (e *Engine) listViews(ctx context.Context, dbName string) ([]viewSpec, error) {
+	listCollections := bson.D{
+		{Key: "listCollections", Value: 1},
+		{Key: "filter", Value: bson.D{{Key: "type", Value: "view"}}},
+	}
+	var reply struct {
+		Cursor struct {
+			FirstBatch []viewSpec `bson:"firstBatch"`
+		} `bson:"cursor"`
+	}
+	if err := e.backend.RunCommand(ctx, dbName, listCollections, &reply); err != nil {
+		return nil, fmt.Errorf("could not list the views of %s: %w", dbName, err)
+	}
+	return reply.Cursor.FirstBatch, nil
+}
+
+func (e *Engine) copyDocs(ctx context.Context, j *job, target Backend, dbName, collName, targetDb, targetColl string, filter bson.D) (BulkResult, error) {
+	cur, err := e.backend.FindCursor(ctx, dbName, collName, filter, FindOptions{})
+	if err != nil {
+		return BulkResult{}, err
+	}
+	defer cur.Close(context.Background())
+
+	w := &batchWriter{j: j, write: func(ctx context.Context, batch []bson.D) (BulkResult, error) {
+		return target.InsertMany(ctx, targetDb, targetColl, batch, false)
+	}}
+	for cur.Next(ctx) {
+		var doc bson.D
+		if err := cur.Decode(&doc); err != nil {
+			return w.total, err
+		}
+		if _, err := w.add(ctx, doc); err != nil {
+			return w.total, err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return w.total, err
+	}
+	_, err = w.flush(ctx)
+	return w.total, err
+}
+
+// copyIndexes creates every index of a collection, apart from the _id index, on its copy
+func (e *Engine) copyIndexes(ctx context.Context, target Backend, dbName, collName, targetDb, targetColl string) (int, error) {
+	var specs []bson.D
+	if err := e.backend.ListIndexes(ctx, dbName, collName, &specs); err != nil {
+		return 0, err
+	}
+	var created int
+	for _, spec := range specs {
+		name, _ := lookupField(spec, "name")
+		if name == "_id_" {
+			continue
+		}
+		var copied bson.D
+		for _, elem := range spec {
+			if elem.Key != "v" && elem.Key != "ns" { // Set by the server for the collection the index was created on
+				copied = append(copied, elem)
+			}
+		}
+		if err := target.CreateIndex(ctx, targetDb, targetColl, copied); err != nil {
+			return created, fmt.Errorf("could not create the index %v on %s.%s: %w", name, targetDb, targetColl, err)
+		}
+		created++
+	}
+	return created, nil
+}
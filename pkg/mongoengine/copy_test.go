@@ -0,0 +1,139 @@
+package mongoengine
+
+import (
+	"context"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		name                   string
+		sourceColl, filter     string
+		targetDb, targetColl   string
+		targetURI              string
+		wantSummary            string
+		wantCounts             map[string]int64 // Docs in each db.coll of the target backend afterwards
+		wantViews              int              // Views in the target database afterwards, including those of the source
+		wantDatabasesRefreshed bool
+	}{
+		{
+			name:                   "filtered collection with its indexes",
+			sourceColl:             "users",
+			filter:                 `{"age": {"$gte": 30}}`,
+			targetDb:               "shop",
+			targetColl:             "adults",
+			wantSummary:            "copied 2 documents and 1 indexes from shop.users to shop.adults",
+			wantCounts:             map[string]int64{"shop.adults": 2},
+			wantViews:              1,
+			wantDatabasesRefreshed: true,
+		},
+		{
+			name:                   "whole database",
+			targetDb:               "shop_copy",
+			wantSummary:            "copied 4 documents and 1 indexes from shop to shop_copy, recreated 1 views",
+			wantCounts:             map[string]int64{"shop_copy.users": 3, "shop_copy.orders": 1},
+			wantViews:              1,
+			wantDatabasesRefreshed: true,
+		},
+		{
+			name:                   "duplicate _ids are counted as failed",
+			sourceColl:             "orders",
+			targetDb:               "shop",
+			targetColl:             "users",
+			wantSummary:            "copied 0 documents and 0 indexes from shop.orders to shop.users, 1 failed: E11000 duplicate key error collection: shop.users index: _id_ dup key: { _id: 1 }",
+			wantCounts:             map[string]int64{"shop.users": 3},
+			wantViews:              1,
+			wantDatabasesRefreshed: true,
+		},
+		{
+			name:        "another server",
+			sourceColl:  "users",
+			targetDb:    "shop",
+			targetColl:  "users",
+			targetURI:   "localhost:27018",
+			wantSummary: "copied 3 documents and 1 indexes from shop.users to shop.users on another server",
+			wantCounts:  map[string]int64{"shop.users": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			backend := newSeededBackend(t)
+			if _, err := backend.InsertOne(ctx, "shop", "orders", bson.D{{Key: "_id", Value: 1}, {Key: "total", Value: 12.5}}); err != nil {
+				t.Fatalf("InsertOne() error = %v", err)
+			}
+			if err := backend.CreateIndex(ctx, "shop", "users", bson.D{{Key: "key", Value: bson.D{{Key: "age", Value: 1}}}, {Key: "name", Value: "age_1"}}); err != nil {
+				t.Fatalf("CreateIndex() error = %v", err)
+			}
+			adults := bson.D{{Key: "viewOn", Value: "users"}, {Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 30}}}}}}}}}
+			if err := backend.CreateCollection(ctx, "shop", "adults", adults); err != nil {
+				t.Fatalf("CreateCollection() error = %v", err)
+			}
+			e := New(backend)
+			target := backend
+			if tt.targetURI != "" {
+				target = NewMemoryBackend()
+				e.connect = func(_ context.Context, uri string) (Backend, func(), error) {
+					if uri != tt.targetURI {
+						t.Errorf("connected to %q, want %q", uri, tt.targetURI)
+					}
+					return target, func() {}, nil
+				}
+			}
+
+			opts, err := ParseCopyOptions("shop", tt.sourceColl, tt.filter, tt.targetDb, tt.targetColl, tt.targetURI, "yes")
+			if err != nil {
+				t.Fatalf("ParseCopyOptions() error = %v", err)
+			}
+			msg, ok := finishJob(t, e.Copy(opts)).(JobFinishedMsg)
+			if !ok || msg.Summary != tt.wantSummary || msg.DatabasesRefreshed != tt.wantDatabasesRefreshed {
+				t.Fatalf("Copy() = %#v, want the summary %q", msg, tt.wantSummary)
+			}
+			if views, err := New(target).listViews(ctx, tt.targetDb); err != nil || len(views) != tt.wantViews {
+				t.Errorf("listViews(%s) = %v, %v, want %d views", tt.targetDb, views, err, tt.wantViews)
+			}
+			for ns, want := range tt.wantCounts {
+				db, coll, _ := strings.Cut(ns, ".")
+				if got, err := target.CountDocuments(ctx, db, coll, bson.D{}, 0); err != nil || got != want {
+					t.Errorf("CountDocuments(%s) = %d, %v, want %d", ns, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyOntoItself(t *testing.T) {
+	e := New(newSeededBackend(t))
+	opts := CopyOptions{SourceDb: "shop", SourceColl: "users", Filter: bson.D{}, TargetDb: "shop", TargetColl: "users"}
+	if msg, ok := e.Copy(opts)().(modal.ErrModalMsg); !ok {
+		t.Errorf("Copy() onto its own source = %#v, want an error modal", msg)
+	}
+}
+
+func TestParseCopyOptions(t *testing.T) {
+	tests := []struct {
+		name                                     string
+		sourceColl, filter, targetDb, targetColl string
+		targetURI, indexes                       string
+		wantErr                                  bool
+	}{
+		{name: "collection", sourceColl: "users", targetDb: "shop", targetColl: "users_copy", indexes: "no"},
+		{name: "same namespace on another server", sourceColl: "users", targetDb: "shop", targetColl: "users", targetURI: "localhost:27018", indexes: "yes"},
+		{name: "same namespace", sourceColl: "users", targetDb: "shop", targetColl: "users", indexes: "yes", wantErr: true},
+		{name: "same database", targetDb: "shop", indexes: "yes", wantErr: true},
+		{name: "missing target collection", sourceColl: "users", targetDb: "shop", indexes: "yes", wantErr: true},
+		{name: "invalid filter", sourceColl: "users", filter: "{age", targetDb: "other", targetColl: "users", indexes: "yes", wantErr: true},
+		{name: "invalid indexes", sourceColl: "users", targetDb: "other", targetColl: "users", indexes: "maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCopyOptions("shop", tt.sourceColl, tt.filter, tt.targetDb, tt.targetColl, tt.targetURI, tt.indexes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCopyOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
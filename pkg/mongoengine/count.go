@@ -0,0 +1,67 @@
+package mongoengine
+
+// The functions contained within this file decide how the total number of docs matched by a query is counted.
+// An exact count has to scan every matching doc, which on large unindexed filters costs more than the find itself
+
+import (
+	"context"
+)
+
+const countCap = 1000 // Capped counts stop once this many docs have been counted
+
+// CountKind describes how the DocCount of a query was counted. CountExact, CountCapped and CountNone are the
+// modes that can be selected for filtered queries while CountEstimated is always used when there is no filter
+type CountKind int
+
+const (
+	CountExact CountKind = iota
+	CountCapped
+	CountNone
+	CountEstimated
+)
+
+func (c CountKind) String() string {
+	switch c {
+	case CountCapped:
+		return "capped"
+	case CountNone:
+		return "not counted"
+	case CountEstimated:
+		return "estimated"
+	default:
+		return "exact"
+	}
+}
+
+// docCount is the number of docs matched by a query along with how it was counted
+type docCount struct {
+	n    int64
+	kind CountKind
+}
+
+// countDocs counts the docs matched by a find. The collection metadata is used when there is no filter as it
+// does not require a scan, otherwise mode decides whether and how far to count
+func (e *Engine) countDocs(ctx context.Context, dbName, collName string, query Query, mode CountKind) (docCount, error) {
+	if len(query.Filter) == 0 {
+		n, err := e.backend.EstimatedDocumentCount(ctx, dbName, collName)
+		if query.Limit > 0 && n > query.Limit {
+			n = query.Limit
+		}
+		return docCount{n: n, kind: CountEstimated}, err
+	}
+
+	switch mode {
+	case CountNone:
+		return docCount{kind: CountNone}, nil
+	case CountCapped:
+		if query.Limit == 0 || query.Limit > countCap {
+			n, err := e.backend.CountDocuments(ctx, dbName, collName, query.filter(), countCap)
+			if n < countCap { // Every matching doc was counted
+				return docCount{n: n, kind: CountExact}, err
+			}
+			return docCount{n: n, kind: CountCapped}, err
+		}
+	}
+	n, err := e.backend.CountDocuments(ctx, dbName, collName, query.filter(), query.Limit)
+	return docCount{n: n, kind: CountExact}, err
+}
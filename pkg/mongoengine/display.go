@@ -1,6 +1,8 @@
 package mongoengine
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"slices"
@@ -33,6 +35,37 @@ func (e *Engine) GetSelectedCollections() []string {
 	}
 }
 
+// GetSelectedCollection returns the database and collection last set by SetSelectedCollection
+func (e *Engine) GetSelectedCollection() (string, string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.selectedDb, e.selectedCollection
+}
+
+// GetCollectionsState reports if the collections of a database have been loaded. The error is only set when
+// the state is CollectionsFailed
+func (e *Engine) GetCollectionsState(dbName string) (CollectionsState, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	db := e.server.databases[dbName]
+	return db.state, db.err
+}
+
+// GetPaginationMode returns the pagination mode selected via TogglePaginationMode along with whether the docs
+// currently cached were actually paginated by keyset. The query may have fallen back to skip pagination
+func (e *Engine) GetPaginationMode() (PaginationMode, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.paginationMode, e.keysetPaginated
+}
+
+// GetDocCountKind returns how the DocCount of the docs currently cached was counted
+func (e *Engine) GetDocCountKind() CountKind {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.docCountKind
+}
+
 // GetDocumentSummaries will fetch a processed list of document summaries for each document
 // These documents are currently being used to be displayed within the doclist component
 func (e *Engine) GetDocumentSummaries() []docSummary {
@@ -55,6 +88,38 @@ func (e *Engine) GetSelectedDocumentMarshalled() ([]byte, error) {
 	return parsedDoc, nil
 }
 
+// DocumentText renders doc, or the value at a dotted path within it such as address.city, as text to be copied.
+// Strings are kept as they are and every other value is written as indented relaxed Extended JSON
+func DocumentText(doc *bson.M, path string) (string, error) {
+	if doc == nil {
+		return "", fmt.Errorf("no document is selected")
+	}
+	var value any = doc
+	if path != "" {
+		d, err := toDoc(doc)
+		if err != nil {
+			return "", err
+		}
+		var ok bool
+		if value, ok = lookupPath(d, path); !ok {
+			return "", fmt.Errorf("the document has no field %s", path)
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	// Extended JSON can only be written for a document so the value is wrapped in one and then unwrapped
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, false, false)
+	if err != nil {
+		return "", fmt.Errorf("could not parse document: %v", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data[len(`{"v":`):len(data)-1], "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
 // GetSelectedDocument will return a reference to the bson of the highlighted doc
 // last selected via SetSelectedDocument
 func (e *Engine) GetSelectedDocument() *bson.M {
@@ -70,3 +135,92 @@ func (e *Engine) GetQueriedDocs() []*bson.M {
 	defer e.mu.RUnlock()
 	return e.server.cachedDocs
 }
+
+// GetExplain returns the plan of the last query run through Explain
+func (e *Engine) GetExplain() *Explain {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.explain
+}
+
+// GetChangeEvents returns the events received since Follow was last called, oldest first
+func (e *Engine) GetChangeEvents() []ChangeEvent {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.changeEvents
+}
+
+// GetSchema returns the schema last inferred by InferSchema
+func (e *Engine) GetSchema() *Schema {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.schema
+}
+
+// GetIndexes returns the indexes of the selected collection that were last fetched by LoadIndexes
+func (e *Engine) GetIndexes() []Index {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.server.cachedIndexes
+}
+
+// GetDatabaseStats returns the stats of a database loaded by LoadDatabaseStats. false is returned if they have
+// not been requested yet
+func (e *Engine) GetDatabaseStats(dbName string) (DatabaseStats, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	stats := e.server.databases[dbName].stats
+	if stats == nil {
+		return DatabaseStats{}, false
+	}
+	return *stats, true
+}
+
+// GetCollectionStats returns the stats of a collection loaded by LoadCollectionStats. false is returned if they
+// have not been requested yet
+func (e *Engine) GetCollectionStats(dbName, collName string) (CollectionStats, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	stats := e.server.databases[dbName].collectionStats[collName]
+	if stats == nil {
+		return CollectionStats{}, false
+	}
+	return *stats, true
+}
+
+// GetJournal returns every write that can still be undone, oldest first
+func (e *Engine) GetJournal() []JournalEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]JournalEntry(nil), e.journal...)
+}
+
+// IsStaging reports if inserts, edits and deletes are queued rather than made
+func (e *Engine) IsStaging() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.staging
+}
+
+// GetStagedChanges returns the changes waiting to be committed in the order they were staged
+func (e *Engine) GetStagedChanges() []JournalEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]JournalEntry(nil), e.staged...)
+}
+
+// GetJobProgress returns the progress of the job running in the background. false is returned if none is running
+func (e *Engine) GetConcerns() Concerns {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.concerns
+}
+
+func (e *Engine) GetJobProgress() (JobProgress, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.job == nil {
+		return JobProgress{}, false
+	}
+	return JobProgress{Description: e.job.description, Done: e.job.done.Load(), Total: e.job.total.Load()}, true
+}
@@ -0,0 +1,146 @@
+package mongoengine
+
+// The methods contained within this file run the explain command against a query and extract the parts of the
+// plan that matter most when debugging a slow query
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ExplainReadyMsg is sent once Explain has cached the plan of a query so that it can be displayed
+type ExplainReadyMsg struct{}
+
+// Explain holds the output of the explain command along with a summary of its winning plan
+type Explain struct {
+	Summary     ExplainSummary
+	WinningPlan bson.Raw
+}
+
+// ExplainSummary describes how the winning plan found the docs of a query
+type ExplainSummary struct {
+	Stages              []string // Stages of the winning plan from the top down, such as FETCH then IXSCAN
+	Indexes             []string // Names of the indexes scanned by the winning plan
+	CollectionScan      bool     // If any stage of the winning plan scans the whole collection
+	DocsReturned        int64
+	DocsExamined        int64
+	KeysExamined        int64
+	ExecutionTimeMillis int64
+}
+
+// Explain runs the query with the executionStats verbosity, which includes the queryPlanner output, against the
+// selected collection. Like any other query it can be cancelled and is cancelled by newer queries
+func (e *Engine) Explain(query Query) tea.Cmd {
+	ctx, id := e.startOperation()
+	return func() tea.Msg {
+		defer e.finishOperation(id)
+
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+
+		var reply bson.Raw
+		if err := e.backend.RunCommand(ctx, dbName, explainCommand(collName, query), &reply); err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not explain query: %w", err))
+		}
+		explain, err := parseExplain(reply)
+		if err != nil {
+			return operationErrMsg(ctx, err)
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.explain = explain
+		return ExplainReadyMsg{}
+	}
+}
+
+// explainCommand wraps the find or aggregate command that the query would run in an explain command
+func explainCommand(collName string, query Query) bson.D {
+	var explained bson.D
+	if query.IsAggregation() {
+		explained = bson.D{
+			{Key: "aggregate", Value: collName},
+			{Key: "pipeline", Value: query.Pipeline},
+			{Key: "cursor", Value: bson.D{}},
+		}
+	} else {
+		explained = bson.D{{Key: "find", Value: collName}, {Key: "filter", Value: query.filter()}}
+		if len(query.Sort) > 0 {
+			explained = append(explained, bson.E{Key: "sort", Value: query.Sort})
+		}
+		if len(query.Projection) > 0 {
+			explained = append(explained, bson.E{Key: "projection", Value: query.Projection})
+		}
+		if query.Limit > 0 {
+			explained = append(explained, bson.E{Key: "limit", Value: query.Limit})
+		}
+	}
+	return bson.D{{Key: "explain", Value: explained}, {Key: "verbosity", Value: "executionStats"}}
+}
+
+// parseExplain summarises the reply of the explain command. Aggregations that could not be pushed down to the
+// query layer nest the queryPlanner and executionStats within the $cursor of their first stage
+func parseExplain(reply bson.Raw) (*Explain, error) {
+	explainRoot := reply
+	if _, err := reply.LookupErr("queryPlanner"); err != nil {
+		cursor, err := reply.LookupErr("stages", "0", "$cursor")
+		if err != nil {
+			return nil, fmt.Errorf("explain output does not contain a query plan")
+		}
+		explainRoot = cursor.Document()
+	}
+
+	winningPlan, err := explainRoot.LookupErr("queryPlanner", "winningPlan")
+	if err != nil {
+		return nil, fmt.Errorf("explain output does not contain a winning plan")
+	}
+	plan := winningPlan.Document()
+	if queryPlan, err := plan.LookupErr("queryPlan"); err == nil { // Plans run by the slot based engine
+		plan = queryPlan.Document()
+	}
+
+	explain := &Explain{WinningPlan: plan}
+	summariseStages(plan, &explain.Summary)
+	explain.Summary.DocsReturned = lookupInt64(explainRoot, "executionStats", "nReturned")
+	explain.Summary.DocsExamined = lookupInt64(explainRoot, "executionStats", "totalDocsExamined")
+	explain.Summary.KeysExamined = lookupInt64(explainRoot, "executionStats", "totalKeysExamined")
+	explain.Summary.ExecutionTimeMillis = lookupInt64(explainRoot, "executionStats", "executionTimeMillis")
+	return explain, nil
+}
+
+// summariseStages walks a plan stage along with all of its input stages
+func summariseStages(stage bson.Raw, summary *ExplainSummary) {
+	if name, ok := stage.Lookup("stage").StringValueOK(); ok {
+		summary.Stages = append(summary.Stages, name)
+		if name == "COLLSCAN" {
+			summary.CollectionScan = true
+		}
+	}
+	if indexName, ok := stage.Lookup("indexName").StringValueOK(); ok {
+		summary.Indexes = append(summary.Indexes, indexName)
+	}
+
+	if inputStage, ok := stage.Lookup("inputStage").DocumentOK(); ok {
+		summariseStages(inputStage, summary)
+	}
+	if inputStages, ok := stage.Lookup("inputStages").ArrayOK(); ok {
+		values, _ := inputStages.Values()
+		for _, v := range values {
+			if inputStage, ok := v.DocumentOK(); ok {
+				summariseStages(inputStage, summary)
+			}
+		}
+	}
+}
+
+// lookupInt64 returns the number found at the path of keys, or 0 if there is none
+func lookupInt64(doc bson.Raw, keys ...string) int64 {
+	v, err := doc.LookupErr(keys...)
+	if err != nil {
+		return 0
+	}
+	n, _ := v.AsInt64OK()
+	return n
+}
@@ -0,0 +1,88 @@
+package mongoengine
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"testing"
+)
+
+func TestParseExplain(t *testing.T) {
+	indexScan := bson.D{
+		{Key: "stage", Value: "FETCH"},
+		{Key: "inputStage", Value: bson.D{{Key: "stage", Value: "IXSCAN"}, {Key: "indexName", Value: "name_1"}}},
+	}
+	executionStats := bson.D{
+		{Key: "nReturned", Value: int32(2)},
+		{Key: "executionTimeMillis", Value: int32(7)},
+		{Key: "totalKeysExamined", Value: int32(2)},
+		{Key: "totalDocsExamined", Value: int32(2)},
+	}
+
+	tests := []struct {
+		name        string
+		reply       bson.D
+		wantStages  []string
+		wantIndexes []string
+		wantCollScn bool
+		wantErr     bool
+	}{
+		{
+			name: "find using an index",
+			reply: bson.D{
+				{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: indexScan}}},
+				{Key: "executionStats", Value: executionStats},
+			},
+			wantStages:  []string{"FETCH", "IXSCAN"},
+			wantIndexes: []string{"name_1"},
+		},
+		{
+			name: "slot based engine plan",
+			reply: bson.D{
+				{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "queryPlan", Value: bson.D{{Key: "stage", Value: "COLLSCAN"}}}}}}},
+				{Key: "executionStats", Value: executionStats},
+			},
+			wantStages:  []string{"COLLSCAN"},
+			wantCollScn: true,
+		},
+		{
+			name: "aggregation with a $cursor stage",
+			reply: bson.D{{Key: "stages", Value: bson.A{
+				bson.D{{Key: "$cursor", Value: bson.D{
+					{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{
+						{Key: "stage", Value: "OR"},
+						{Key: "inputStages", Value: bson.A{indexScan, bson.D{{Key: "stage", Value: "COLLSCAN"}}}},
+					}}}},
+					{Key: "executionStats", Value: executionStats},
+				}}},
+				bson.D{{Key: "$group", Value: bson.D{}}},
+			}}},
+			wantStages:  []string{"OR", "FETCH", "IXSCAN", "COLLSCAN"},
+			wantIndexes: []string{"name_1"},
+			wantCollScn: true,
+		},
+		{name: "no plan", reply: bson.D{{Key: "ok", Value: 1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := bson.Marshal(tt.reply)
+			if err != nil {
+				t.Fatalf("failed to marshal reply: %v", err)
+			}
+			explain, err := parseExplain(reply)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExplain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			summary := explain.Summary
+			if !slices.Equal(summary.Stages, tt.wantStages) || !slices.Equal(summary.Indexes, tt.wantIndexes) || summary.CollectionScan != tt.wantCollScn {
+				t.Errorf("parseExplain() = %+v, want stages %v, indexes %v, collection scan %v", summary, tt.wantStages, tt.wantIndexes, tt.wantCollScn)
+			}
+			if summary.DocsReturned != 2 || summary.ExecutionTimeMillis != 7 {
+				t.Errorf("parseExplain() did not read the execution stats: %+v", summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package mongoengine
+
+// The methods contained within this file export every document returned by a query, rather than the page that is
+// cached for the doclist, to a file
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExportFormat int
+
+const (
+	ExportJSON   ExportFormat = iota // A JSON array of every document
+	ExportNDJSON                     // One document per line
+	ExportCSV                        // One column per dotted field path found across the documents
+)
+
+// JSONMode is how the BSON types that JSON lacks, such as ObjectIDs and dates, are written
+type JSONMode int
+
+const (
+	PlainJSON        JSONMode = iota // ObjectIDs, dates and other BSON types are written as strings
+	RelaxedExtJSON                   // Extended JSON that keeps numbers and dates readable
+	CanonicalExtJSON                 // Extended JSON that preserves the exact type of every value
+)
+
+var exportFormats = map[string]ExportFormat{"json": ExportJSON, "ndjson": ExportNDJSON, "csv": ExportCSV}
+var jsonModes = map[string]JSONMode{"plain": PlainJSON, "relaxed": RelaxedExtJSON, "canonical": CanonicalExtJSON}
+
+// ExportOptions describe the file written by Export
+type ExportOptions struct {
+	Path      string
+	Format    ExportFormat
+	Mode      JSONMode // Ignored by CSV which always writes plain values
+	Overwrite bool     // If a file already at Path may be replaced. It must be confirmed by the user
+}
+
+// ParseExportOptions validates the options entered by the user. format is one of json, ndjson or csv and mode one
+// of plain, relaxed or canonical
+func ParseExportOptions(path, format, mode string) (ExportOptions, error) {
+	opts := ExportOptions{Path: strings.TrimSpace(path)}
+	if opts.Path == "" {
+		return ExportOptions{}, fmt.Errorf("a file to export to is required")
+	}
+	var ok bool
+	if opts.Format, ok = exportFormats[strings.ToLower(strings.TrimSpace(format))]; !ok {
+		return ExportOptions{}, fmt.Errorf("unknown export format %q, expected json, ndjson or csv", format)
+	}
+	if opts.Mode, ok = jsonModes[strings.ToLower(strings.TrimSpace(mode))]; !ok {
+		return ExportOptions{}, fmt.Errorf("unknown JSON mode %q, expected plain, relaxed or canonical", mode)
+	}
+	return opts, nil
+}
+
+// FileExists reports if there is already a file at Path, which the user must agree to overwrite
+func (o ExportOptions) FileExists() bool {
+	_, err := os.Stat(o.Path)
+	return err == nil
+}
+
+// Export writes every document returned by query against the selected collection to a file as a background job.
+// The limit of the query is honoured but not the doclist's pagination. The documents are written to a temporary
+// file that only replaces Path once the export completes, so a failed or cancelled export leaves Path untouched
+func (e *Engine) Export(query Query, opts ExportOptions) tea.Cmd {
+	e.mu.RLock()
+	dbName, collName := e.selectedDb, e.selectedCollection
+	e.mu.RUnlock()
+
+	description := fmt.Sprintf("exporting %s.%s to %s", dbName, collName, opts.Path)
+	return e.runJob(description, func(ctx context.Context, j *job) (string, error) {
+		if err := e.export(ctx, j, dbName, collName, query, opts); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("exported %d documents to %s", j.done.Load(), opts.Path), nil
+	})
+}
+
+func (e *Engine) export(ctx context.Context, j *job, dbName, collName string, query Query, opts ExportOptions) error {
+	cur, err := e.openQueryCursor(ctx, dbName, collName, query)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.Background())
+
+	// Created in the same directory so that it can be renamed to Path without being copied across filesystems
+	file, err := os.CreateTemp(filepath.Dir(opts.Path), "."+filepath.Base(opts.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	renamed := false
+	defer func() {
+		_ = file.Close()
+		if !renamed {
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if err := file.Chmod(0o644); err != nil { // CreateTemp makes the file only readable by its owner
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	w, err := newDocWriter(buffered, opts)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	for cur.Next(ctx) {
+		var doc bson.D
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := w.write(doc); err != nil {
+			return err
+		}
+		j.done.Add(1)
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	if err := w.finish(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if !opts.Overwrite && opts.FileExists() { // Created by someone else while the export was running
+		return fmt.Errorf("%s already exists", opts.Path)
+	}
+	if err := os.Rename(file.Name(), opts.Path); err != nil {
+		return err
+	}
+	renamed = true
+	return nil
+}
+
+// openQueryCursor runs query, either as a find or an aggregation, and returns a cursor over every result
+func (e *Engine) openQueryCursor(ctx context.Context, dbName, collName string, query Query) (Cursor, error) {
+	if query.IsAggregation() {
+		return e.backend.AggregateCursor(ctx, dbName, collName, query.Pipeline)
+	}
+	opts := FindOptions{Sort: query.Sort, Projection: query.Projection, Limit: query.Limit}
+	return e.backend.FindCursor(ctx, dbName, collName, query.filter(), opts)
+}
+
+// docWriter writes documents to an export in one of the ExportFormats
+type docWriter interface {
+	write(doc bson.D) error
+	finish() error // Writes anything that must follow the last document
+	close()        // Releases anything held by the writer whether or not it finished
+}
+
+func newDocWriter(w io.Writer, opts ExportOptions) (docWriter, error) {
+	switch opts.Format {
+	case ExportJSON:
+		return &jsonArrayWriter{w: w, mode: opts.Mode}, nil
+	case ExportNDJSON:
+		return &ndjsonWriter{w: w, mode: opts.Mode}, nil
+	case ExportCSV:
+		return newCSVWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format %d", opts.Format)
+	}
+}
+
+func marshalJSON(doc bson.D, mode JSONMode) ([]byte, error) {
+	switch mode {
+	case CanonicalExtJSON:
+		return bson.MarshalExtJSON(doc, true, false)
+	case RelaxedExtJSON:
+		return bson.MarshalExtJSON(doc, false, false)
+	default:
+		return bson.MarshalExtJSON(plainValue(doc), false, false)
+	}
+}
+
+type jsonArrayWriter struct {
+	w     io.Writer
+	mode  JSONMode
+	count int
+}
+
+func (a *jsonArrayWriter) write(doc bson.D) error {
+	data, err := marshalJSON(doc, a.mode)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if a.count == 0 {
+		separator = "[\n"
+	}
+	a.count++
+	_, err = fmt.Fprintf(a.w, "%s%s", separator, data)
+	return err
+}
+
+func (a *jsonArrayWriter) finish() error {
+	if a.count == 0 {
+		_, err := io.WriteString(a.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(a.w, "\n]\n")
+	return err
+}
+
+func (a *jsonArrayWriter) close() {}
+
+type ndjsonWriter struct {
+	w    io.Writer
+	mode JSONMode
+}
+
+func (n *ndjsonWriter) write(doc bson.D) error {
+	data, err := marshalJSON(doc, n.mode)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(n.w, "%s\n", data)
+	return err
+}
+
+func (n *ndjsonWriter) finish() error {
+	return nil
+}
+
+func (n *ndjsonWriter) close() {}
+
+// csvWriter flattens every document into dotted field paths. The columns are only known once every document has
+// been seen so the rows are spooled to a temporary file until finish writes the header followed by the rows
+type csvWriter struct {
+	w       io.Writer
+	spool   *os.File
+	encoder *json.Encoder
+	columns []string
+	seen    map[string]bool
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	spool, err := os.CreateTemp("", "mongotui-export-*.ndjson")
+	if err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: w, spool: spool, encoder: json.NewEncoder(spool), seen: make(map[string]bool)}, nil
+}
+
+func (c *csvWriter) write(doc bson.D) error {
+	row := make(map[string]string)
+	for _, field := range flattenDoc("", doc) {
+		if !c.seen[field.path] {
+			c.seen[field.path] = true
+			c.columns = append(c.columns, field.path)
+		}
+		row[field.path] = field.value
+	}
+	return c.encoder.Encode(row)
+}
+
+func (c *csvWriter) finish() error {
+	if _, err := c.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	out := csv.NewWriter(c.w)
+	if err := out.Write(c.columns); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(c.spool)
+	record := make([]string, len(c.columns))
+	for decoder.More() {
+		var row map[string]string
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		for i, column := range c.columns {
+			record[i] = row[column]
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func (c *csvWriter) close() {
+	c.spool.Close()
+	os.Remove(c.spool.Name())
+}
+
+type flatField struct {
+	path  string
+	value string
+}
+
+// flattenDoc returns every value within doc along with its dotted path. Array elements are addressed by their
+// index, and empty documents and arrays are kept as {} and []
+func flattenDoc(prefix string, value any) []flatField {
+	var fields []flatField
+	switch v := value.(type) {
+	case bson.D:
+		if len(v) == 0 && prefix != "" {
+			return []flatField{{path: prefix, value: "{}"}}
+		}
+		for _, elem := range v {
+			fields = append(fields, flattenDoc(joinPath(prefix, elem.Key), elem.Value)...)
+		}
+	case bson.A:
+		if len(v) == 0 {
+			return []flatField{{path: prefix, value: "[]"}}
+		}
+		for i, elem := range v {
+			fields = append(fields, flattenDoc(joinPath(prefix, strconv.Itoa(i)), elem)...)
+		}
+	default:
+		fields = append(fields, flatField{path: prefix, value: plainText(value)})
+	}
+	return fields
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// plainText renders a value that is neither a document nor an array as a CSV cell
+func plainText(value any) string {
+	switch v := plainValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// plainValue replaces the BSON types that JSON lacks with strings, or with numbers for timestamps, so that
+// documents can be written as plain JSON
+func plainValue(value any) any {
+	switch v := value.(type) {
+	case bson.D:
+		doc := make(bson.D, len(v))
+		for i, elem := range v {
+			doc[i] = bson.E{Key: elem.Key, Value: plainValue(elem.Value)}
+		}
+		return doc
+	case bson.A:
+		arr := make(bson.A, len(v))
+		for i, elem := range v {
+			arr[i] = plainValue(elem)
+		}
+		return arr
+	case bson.ObjectID:
+		return v.Hex()
+	case bson.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case bson.Decimal128:
+		return v.String()
+	case bson.Binary:
+		return base64.StdEncoding.EncodeToString(v.Data)
+	case bson.Timestamp:
+		return int64(v.T)
+	case bson.Regex:
+		return "/" + v.Pattern + "/" + v.Options
+	case bson.JavaScript:
+		return string(v)
+	case bson.CodeWithScope:
+		return string(v.Code)
+	case bson.Symbol:
+		return string(v)
+	case bson.Undefined, bson.Null:
+		return nil
+	case bson.MinKey:
+		return "MinKey"
+	case bson.MaxKey:
+		return "MaxKey"
+	case bson.DBPointer:
+		return v.DB + "." + v.Pointer.Hex()
+	default:
+		return value
+	}
+}
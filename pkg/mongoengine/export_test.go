@@ -0,0 +1,189 @@
+package mongoengine
+
+import (
+	"context"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// finishJob runs the job started by cmd and returns the message sent once it finishes
+func finishJob(t *testing.T, cmd tea.Cmd) tea.Msg {
+	t.Helper()
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("the job was not started")
+	}
+	return batch[0]()
+}
+
+func TestExport(t *testing.T) {
+	adults := Query{Filter: bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 30}}}}}
+	tests := []struct {
+		name   string
+		query  Query
+		format string
+		mode   string
+		want   string
+	}{
+		{
+			name:   "ndjson relaxed",
+			query:  adults,
+			format: "ndjson",
+			mode:   "relaxed",
+			want:   `{"_id":1,"name":"Kevin","age":30,"tags":["admin","dev"]}` + "\n" + `{"_id":3,"name":"George","age":41.5}` + "\n",
+		},
+		{
+			name:   "json canonical",
+			query:  Query{Filter: bson.D{{Key: "_id", Value: 2}}, Projection: bson.D{{Key: "age", Value: 1}}},
+			format: "json",
+			mode:   "canonical",
+			want:   "[\n" + `{"_id":{"$numberInt":"2"},"age":{"$numberLong":"25"}}` + "\n]\n",
+		},
+		{
+			name:   "json plain",
+			query:  Query{Filter: bson.D{{Key: "_id", Value: 4}}},
+			format: "json",
+			mode:   "plain",
+			want:   "[\n" + `{"_id":4,"owner":"65a0c0ffee0000000000abcd","created":"2024-01-02T03:04:05Z"}` + "\n]\n",
+		},
+		{
+			name:   "empty json array",
+			query:  Query{Filter: bson.D{{Key: "_id", Value: 100}}},
+			format: "json",
+			mode:   "plain",
+			want:   "[]\n",
+		},
+		{
+			name:   "csv columns from dotted paths",
+			query:  Query{Filter: bson.D{{Key: "_id", Value: bson.D{{Key: "$lte", Value: 2}}}}, Sort: bson.D{{Key: "_id", Value: -1}}},
+			format: "csv",
+			mode:   "plain",
+			want:   "_id,name,age,address.city,tags.0,tags.1\n2,Sally,25,Boston,,\n1,Kevin,30,,admin,dev\n",
+		},
+		{
+			name:   "aggregation",
+			query:  Query{Pipeline: []bson.D{{{Key: "$match", Value: bson.D{{Key: "_id", Value: 3}}}}, {{Key: "$project", Value: bson.D{{Key: "name", Value: 1}}}}}},
+			format: "ndjson",
+			mode:   "relaxed",
+			want:   `{"_id":3,"name":"George"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			owner, _ := bson.ObjectIDFromHex("65a0c0ffee0000000000abcd")
+			created := bson.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+			if _, err := backend.InsertOne(context.Background(), "shop", "users", bson.D{{Key: "_id", Value: 4}, {Key: "owner", Value: owner}, {Key: "created", Value: created}}); err != nil {
+				t.Fatalf("InsertOne() error = %v", err)
+			}
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+
+			opts, err := ParseExportOptions(filepath.Join(t.TempDir(), "users.out"), tt.format, tt.mode)
+			if err != nil {
+				t.Fatalf("ParseExportOptions() error = %v", err)
+			}
+			if msg, ok := finishJob(t, e.Export(tt.query, opts)).(JobFinishedMsg); !ok {
+				t.Fatalf("Export() = %#v", msg)
+			}
+			got, err := os.ReadFile(opts.Path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("exported\n%s\nwant\n%s", got, tt.want)
+			}
+			if _, running := e.GetJobProgress(); running {
+				t.Errorf("GetJobProgress() reports a job running after it finished")
+			}
+		})
+	}
+}
+
+func TestExportCancelled(t *testing.T) {
+	e := New(newSeededBackend(t))
+	e.SetSelectedCollection("shop", "users")
+	opts, err := ParseExportOptions(filepath.Join(t.TempDir(), "users.json"), "json", "relaxed")
+	if err != nil {
+		t.Fatalf("ParseExportOptions() error = %v", err)
+	}
+
+	cmd := e.Export(Query{}, opts)
+	if _, ok := e.Export(Query{}, opts)().(tea.BatchMsg); ok {
+		t.Errorf("a second job should not start while one is running")
+	}
+	if !e.CancelJob() {
+		t.Fatalf("CancelJob() = false, want the export cancelled")
+	}
+	msg, ok := finishJob(t, cmd).(JobFinishedMsg)
+	if !ok || msg.Summary != "cancelled exporting shop.users to "+opts.Path+" after 0 documents" {
+		t.Errorf("Export() = %#v", msg)
+	}
+	if _, err := os.Stat(opts.Path); !os.IsNotExist(err) {
+		t.Errorf("the partial export should be removed, Stat() error = %v", err)
+	}
+}
+
+func TestExportExistingFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     Query
+		overwrite bool
+		cancel    bool
+		wantFile  string // Contents of the file after the export
+	}{
+		{name: "overwrite confirmed", query: Query{Filter: bson.D{{Key: "_id", Value: 3}}}, overwrite: true, wantFile: "_id,name,age\n3,George,41.5\n"},
+		{name: "overwrite not confirmed", wantFile: "kept"},
+		{name: "failed export", query: Query{Filter: bson.D{{Key: "$where", Value: "true"}}}, overwrite: true, wantFile: "kept"},
+		{name: "cancelled export", overwrite: true, cancel: true, wantFile: "kept"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(newSeededBackend(t))
+			e.SetSelectedCollection("shop", "users")
+			dir := t.TempDir()
+			path := filepath.Join(dir, "users.csv")
+			if err := os.WriteFile(path, []byte("kept"), 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			opts, err := ParseExportOptions(path, "csv", "plain")
+			if err != nil || !opts.FileExists() {
+				t.Fatalf("ParseExportOptions() = %v, %v, want an existing file", opts, err)
+			}
+			opts.Overwrite = tt.overwrite
+
+			cmd := e.Export(tt.query, opts)
+			if tt.cancel {
+				e.CancelJob()
+			}
+			finishJob(t, cmd)
+			if got, err := os.ReadFile(path); err != nil || string(got) != tt.wantFile {
+				t.Errorf("file after the export = %q, %v, want %q", got, err, tt.wantFile)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("the directory has %d files after the export, want the temporary file removed", len(entries))
+			}
+		})
+	}
+}
+
+func TestParseExportOptions(t *testing.T) {
+	tests := []struct {
+		path, format, mode string
+		wantErr            bool
+	}{
+		{path: "out.csv", format: "CSV", mode: "plain"},
+		{path: " ", format: "json", mode: "plain", wantErr: true},
+		{path: "out.xml", format: "xml", mode: "plain", wantErr: true},
+		{path: "out.json", format: "json", mode: "strict", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := ParseExportOptions(tt.path, tt.format, tt.mode); (err != nil) != tt.wantErr {
+			t.Errorf("ParseExportOptions(%q, %q, %q) error = %v, wantErr %v", tt.path, tt.format, tt.mode, err, tt.wantErr)
+		}
+	}
+}
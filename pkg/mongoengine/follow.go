@@ -0,0 +1,148 @@
+package mongoengine
+
+// The methods contained within this file follow the changes made to the selected collection through a change
+// stream. Each event is received by its own command so that bubbletea is never blocked waiting on the stream
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+const maxChangeEvents = 1000 // The oldest events are dropped once more than this have been received
+
+// ChangeEvent is a single change made to the followed collection
+type ChangeEvent struct {
+	OperationType string // insert, update, replace, delete, drop, etc.
+	DocumentKey   bson.D
+	Time          time.Time
+	Doc           *bson.M // The full document after the change. The whole event when there is none, such as for deletes
+}
+
+// ChangeEventMsg is sent once an event has been added to the events returned by GetChangeEvents. The message must
+// be passed to NextChangeEvent to receive the event after it
+type ChangeEventMsg struct {
+	followId uint64
+	ctx      context.Context
+	stream   ChangeStream
+}
+
+// FollowStoppedMsg is sent once a change stream has been closed. Err is nil if it was stopped via StopFollowing
+type FollowStoppedMsg struct {
+	Err error
+}
+
+// Follow opens a change stream on the selected collection, replacing any stream that is already open
+func (e *Engine) Follow() tea.Cmd {
+	e.StopFollowing()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	e.followId++
+	e.followCancel = cancel
+	e.changeEvents = nil
+	id, dbName, collName := e.followId, e.selectedDb, e.selectedCollection
+
+	return func() tea.Msg {
+		openCtx, cancelOpen := context.WithTimeout(ctx, Timeout)
+		defer cancelOpen()
+		stream, err := e.backend.Watch(openCtx, dbName, collName)
+		if err != nil {
+			return e.followStopped(ctx, id, fmt.Errorf("could not follow %s.%s: %w", dbName, collName, err))
+		}
+		return e.receiveChangeEvent(ctx, id, stream)
+	}
+}
+
+// NextChangeEvent waits for the event after the one that was just received
+func (e *Engine) NextChangeEvent(msg ChangeEventMsg) tea.Cmd {
+	return func() tea.Msg {
+		return e.receiveChangeEvent(msg.ctx, msg.followId, msg.stream)
+	}
+}
+
+// StopFollowing cancels the change stream that is open. The stream is closed by the command waiting on it
+func (e *Engine) StopFollowing() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.followCancel != nil {
+		e.followCancel()
+		e.followCancel = nil
+	}
+}
+
+// IsFollowing reports if a change stream is open on the selected collection
+func (e *Engine) IsFollowing() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.followCancel != nil
+}
+
+func (e *Engine) receiveChangeEvent(ctx context.Context, id uint64, stream ChangeStream) tea.Msg {
+	if !stream.Next(ctx) {
+		return e.closeStream(ctx, id, stream, stream.Err())
+	}
+	var raw bson.Raw
+	if err := stream.Decode(&raw); err != nil {
+		return e.closeStream(ctx, id, stream, fmt.Errorf("could not decode change event: %w", err))
+	}
+	event := parseChangeEvent(raw)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if id == e.followId {
+		e.changeEvents = append(e.changeEvents, event)
+		if len(e.changeEvents) > maxChangeEvents {
+			e.changeEvents = e.changeEvents[len(e.changeEvents)-maxChangeEvents:]
+		}
+	}
+	return ChangeEventMsg{followId: id, ctx: ctx, stream: stream}
+}
+
+// closeStream closes a stream that will not be read from again before marking it as stopped
+func (e *Engine) closeStream(ctx context.Context, id uint64, stream ChangeStream, err error) tea.Msg {
+	closeCtx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_ = stream.Close(closeCtx)
+	return e.followStopped(ctx, id, err)
+}
+
+// followStopped marks the stream as no longer open if it is still the current one. Errors caused by StopFollowing
+// cancelling the stream are dropped
+func (e *Engine) followStopped(ctx context.Context, id uint64, err error) tea.Msg {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if id == e.followId && e.followCancel != nil {
+		e.followCancel()
+		e.followCancel = nil
+	}
+	if ctx.Err() != nil {
+		err = nil
+	}
+	return FollowStoppedMsg{Err: err}
+}
+
+// parseChangeEvent extracts the fields displayed for an event. wallTime is only sent by MongoDB 6.0 and later so
+// the seconds of clusterTime are used otherwise
+func parseChangeEvent(raw bson.Raw) ChangeEvent {
+	event := ChangeEvent{}
+	event.OperationType, _ = raw.Lookup("operationType").StringValueOK()
+	if documentKey, ok := raw.Lookup("documentKey").DocumentOK(); ok {
+		_ = bson.Unmarshal(documentKey, &event.DocumentKey)
+	}
+	if wallTime, ok := raw.Lookup("wallTime").DateTimeOK(); ok {
+		event.Time = time.UnixMilli(wallTime)
+	} else if t, _, ok := raw.Lookup("clusterTime").TimestampOK(); ok {
+		event.Time = time.Unix(int64(t), 0)
+	}
+
+	doc := raw
+	if fullDocument, ok := raw.Lookup("fullDocument").DocumentOK(); ok {
+		doc = fullDocument
+	}
+	event.Doc = &bson.M{}
+	_ = bson.Unmarshal(doc, event.Doc)
+	return event
+}
@@ -0,0 +1,152 @@
+package mongoengine
+
+import (
+	"context"
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+	"time"
+)
+
+// runAsync runs a command that blocks on a change stream in the background
+func runAsync(cmd tea.Cmd) chan tea.Msg {
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	return msgs
+}
+
+func receive(t *testing.T, msgs chan tea.Msg) tea.Msg {
+	t.Helper()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("no message was received from the change stream")
+		return nil
+	}
+}
+
+// waitForWatchers waits until the number of streams opened on a collection reaches n
+func waitForWatchers(t *testing.T, b *MemoryBackend, ns string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		b.mu.Lock()
+		watchers := len(b.watchers[ns])
+		b.mu.Unlock()
+		if watchers == n {
+			return
+		}
+	}
+	t.Fatalf("%s does not have %d change streams", ns, n)
+}
+
+func TestFollow(t *testing.T) {
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+	msgs := runAsync(e.Follow())
+	waitForWatchers(t, backend, "shop.users", 1)
+	if !e.IsFollowing() {
+		t.Fatalf("IsFollowing() = false after Follow")
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		write    func() error
+		wantOp   string
+		wantKey  any
+		wantName any
+	}{
+		{
+			name: "insert",
+			write: func() error {
+				_, err := backend.InsertOne(ctx, "shop", "users", bson.D{{Key: "_id", Value: 4}, {Key: "name", Value: "Ann"}})
+				return err
+			},
+			wantOp:   "insert",
+			wantKey:  int32(4),
+			wantName: "Ann",
+		},
+		{
+			name: "replace",
+			write: func() error {
+				_, err := backend.ReplaceOne(ctx, "shop", "users", bson.D{{Key: "_id", Value: 4}}, bson.D{{Key: "name", Value: "Anne"}})
+				return err
+			},
+			wantOp:   "replace",
+			wantKey:  int32(4),
+			wantName: "Anne",
+		},
+		{
+			name: "delete",
+			write: func() error {
+				_, err := backend.DeleteOne(ctx, "shop", "users", bson.D{{Key: "_id", Value: 4}})
+				return err
+			},
+			wantOp:  "delete",
+			wantKey: int32(4),
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			msg, ok := receive(t, msgs).(ChangeEventMsg)
+			if !ok {
+				t.Fatalf("received %#v, want a ChangeEventMsg", msg)
+			}
+			events := e.GetChangeEvents()
+			if len(events) != i+1 {
+				t.Fatalf("GetChangeEvents() has %d events, want %d", len(events), i+1)
+			}
+			event := events[i]
+			if event.OperationType != tt.wantOp || len(event.DocumentKey) != 1 || event.DocumentKey[0].Value != tt.wantKey {
+				t.Errorf("event = %+v, want %s of %v", event, tt.wantOp, tt.wantKey)
+			}
+			if tt.wantName != nil && (*event.Doc)["name"] != tt.wantName {
+				t.Errorf("event doc = %v, want name %v", *event.Doc, tt.wantName)
+			}
+			if event.Time.IsZero() {
+				t.Errorf("event has no time")
+			}
+			msgs = runAsync(e.NextChangeEvent(msg))
+		})
+	}
+
+	e.StopFollowing()
+	if msg := receive(t, msgs); msg != (FollowStoppedMsg{}) {
+		t.Errorf("after StopFollowing received %#v, want FollowStoppedMsg{}", msg)
+	}
+	if e.IsFollowing() {
+		t.Errorf("IsFollowing() = true after StopFollowing")
+	}
+	waitForWatchers(t, backend, "shop.users", 0)
+}
+
+// undecodableStream returns a single event that cannot be decoded
+type undecodableStream struct {
+	closed bool
+}
+
+func (s *undecodableStream) Next(context.Context) bool { return true }
+func (s *undecodableStream) Decode(any) error          { return errors.New("corrupt event") }
+func (s *undecodableStream) Err() error                { return nil }
+func (s *undecodableStream) Close(context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func TestFollowClosesUndecodableStream(t *testing.T) {
+	e := New(NewMemoryBackend())
+	stream := &undecodableStream{}
+	msg, ok := e.receiveChangeEvent(context.Background(), e.followId, stream).(FollowStoppedMsg)
+	if !ok || msg.Err == nil {
+		t.Fatalf("receiveChangeEvent() = %#v, want a FollowStoppedMsg with an error", msg)
+	}
+	if !stream.closed {
+		t.Errorf("the stream was not closed after failing to decode an event")
+	}
+}
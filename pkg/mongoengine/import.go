@@ -0,0 +1,439 @@
+package mongoengine
+
+// The methods contained within this file import the documents held in a JSON, NDJSON or CSV file into a collection
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const writeBatchSize = 1000 // Number of docs sent to the server per bulk write by imports and copies
+
+type ImportFormat int
+
+const (
+	ImportAuto   ImportFormat = iota // Detected from the extension of the file and its first character
+	ImportJSON                       // A JSON array of documents
+	ImportNDJSON                     // One document after another, usually one per line
+	ImportCSV                        // A header of dotted field paths, optionally with type hints, then one row per document
+)
+
+var importFormats = map[string]ImportFormat{"auto": ImportAuto, "json": ImportJSON, "ndjson": ImportNDJSON, "csv": ImportCSV}
+
+// ImportOptions describe the file read by Import and how its documents are written
+type ImportOptions struct {
+	Path       string
+	Format     ImportFormat
+	Ordered    bool     // Stop at the first doc that fails to be written rather than skipping it
+	UpsertKeys []string // Fields that identify the doc to replace. Docs are inserted when there are none
+}
+
+// ParseImportOptions validates the options entered by the user. format is one of auto, json, ndjson or csv,
+// ordered is yes or no and upsertKeys is a comma separated list of fields
+func ParseImportOptions(path, format, ordered, upsertKeys string) (ImportOptions, error) {
+	opts := ImportOptions{Path: strings.TrimSpace(path)}
+	if opts.Path == "" {
+		return ImportOptions{}, fmt.Errorf("a file to import is required")
+	}
+	var ok bool
+	if opts.Format, ok = importFormats[strings.ToLower(strings.TrimSpace(format))]; !ok {
+		return ImportOptions{}, fmt.Errorf("unknown import format %q, expected auto, json, ndjson or csv", format)
+	}
+	var err error
+	if opts.Ordered, err = modal.ParseYesNo("ordered", ordered); err != nil {
+		return ImportOptions{}, err
+	}
+	for _, key := range strings.Split(upsertKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			opts.UpsertKeys = append(opts.UpsertKeys, key)
+		}
+	}
+	return opts, nil
+}
+
+// Import writes every document within a file to a collection as a background job, creating the collection if it
+// does not exist. The documents are written in batches and those that are rejected, such as for a duplicate key,
+// are counted rather than failing the import
+func (e *Engine) Import(dbName, collName string, opts ImportOptions) tea.Cmd {
+	description := fmt.Sprintf("importing %s into %s.%s", opts.Path, dbName, collName)
+	return e.runJob(description, func(ctx context.Context, j *job) (string, error) {
+		j.refreshDatabases = true
+		res, err := e.importFile(ctx, j, dbName, collName, opts)
+		if err != nil {
+			return "", err
+		}
+		summary := fmt.Sprintf("imported %d documents into %s.%s", res.Written, dbName, collName)
+		if res.Failed > 0 {
+			summary += fmt.Sprintf(", %d failed: %s", res.Failed, res.FirstFailure)
+		}
+		return summary, nil
+	})
+}
+
+func (e *Engine) importFile(ctx context.Context, j *job, dbName, collName string, opts ImportOptions) (BulkResult, error) {
+	file, err := os.Open(opts.Path)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	defer file.Close()
+	buffered := bufio.NewReader(file)
+	docs, err := newDocReader(buffered, opts)
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	w := &batchWriter{j: j, ordered: opts.Ordered, write: func(ctx context.Context, batch []bson.D) (BulkResult, error) {
+		return e.writeBatch(ctx, dbName, collName, batch, j.done.Load(), opts)
+	}}
+	for {
+		doc, err := docs.next()
+		if errors.Is(err, io.EOF) {
+			_, err := w.flush(ctx)
+			return w.total, err
+		} else if err != nil {
+			return w.total, fmt.Errorf("document %d: %w", j.done.Load()+int64(len(w.batch))+1, err)
+		}
+		if ok, err := w.add(ctx, doc); err != nil || !ok {
+			return w.total, err
+		}
+	}
+}
+
+// batchWriter collects docs into batches that are written together and tallies the outcome of every batch
+type batchWriter struct {
+	j       *job
+	ordered bool // Stop writing once a doc fails
+	write   func(ctx context.Context, batch []bson.D) (BulkResult, error)
+	batch   []bson.D
+	total   BulkResult
+}
+
+// add queues doc and writes the batch once it is full. false is returned once a doc of an ordered write failed
+func (w *batchWriter) add(ctx context.Context, doc bson.D) (bool, error) {
+	w.batch = append(w.batch, doc)
+	if len(w.batch) < writeBatchSize {
+		return true, nil
+	}
+	return w.flush(ctx)
+}
+
+// flush writes the docs that are queued
+func (w *batchWriter) flush(ctx context.Context) (bool, error) {
+	if len(w.batch) == 0 {
+		return true, nil
+	}
+	res, err := w.write(ctx, w.batch)
+	if err != nil {
+		return false, err
+	}
+	w.j.done.Add(int64(len(w.batch)))
+	w.total.Written += res.Written
+	w.total.Failed += res.Failed
+	if w.total.FirstFailure == "" {
+		w.total.FirstFailure = res.FirstFailure
+	}
+	w.batch = w.batch[:0]
+	return !w.ordered || res.Failed == 0, nil
+}
+
+// writeBatch inserts the batch or, when upsert keys were given, replaces the docs with the same keys. first is the
+// number of docs of the file that came before the batch. A doc missing an upsert key fails instead of being matched
+// against null, which would replace an unrelated doc
+func (e *Engine) writeBatch(ctx context.Context, dbName, collName string, batch []bson.D, first int64, opts ImportOptions) (BulkResult, error) {
+	if len(opts.UpsertKeys) == 0 {
+		return e.backend.InsertMany(ctx, dbName, collName, batch, opts.Ordered)
+	}
+	var missing BulkResult
+	var filters, docs []bson.D
+	for i, doc := range batch {
+		filter, key := upsertFilter(doc, opts.UpsertKeys)
+		if filter == nil {
+			missing.Failed++
+			if missing.FirstFailure == "" {
+				missing.FirstFailure = fmt.Sprintf("document %d is missing the upsert key %s", first+int64(i)+1, key)
+			}
+			if opts.Ordered {
+				break
+			}
+			continue
+		}
+		filters = append(filters, filter)
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return missing, nil
+	}
+	res, err := e.backend.UpsertMany(ctx, dbName, collName, filters, docs, opts.Ordered)
+	if err != nil {
+		return res, err
+	}
+	if opts.Ordered && res.Failed > 0 { // The write stopped before it reached the doc missing a key
+		return res, nil
+	}
+	res.Failed += missing.Failed
+	if res.FirstFailure == "" {
+		res.FirstFailure = missing.FirstFailure
+	}
+	return res, nil
+}
+
+// upsertFilter matches the values doc holds for keys. It returns a nil filter and the first key doc is missing
+func upsertFilter(doc bson.D, keys []string) (bson.D, string) {
+	filter := bson.D{}
+	for _, key := range keys {
+		value, ok := lookupPath(doc, key)
+		if !ok {
+			return nil, key
+		}
+		filter = append(filter, bson.E{Key: key, Value: value})
+	}
+	return filter, ""
+}
+
+// docReader reads the documents of an import one at a time. next returns io.EOF once there are no more
+type docReader interface {
+	next() (bson.D, error)
+}
+
+func newDocReader(r *bufio.Reader, opts ImportOptions) (docReader, error) {
+	format := opts.Format
+	if format == ImportAuto {
+		var err error
+		if format, err = detectImportFormat(opts.Path, r); err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case ImportJSON:
+		return &jsonDocReader{decoder: json.NewDecoder(r), array: true}, nil
+	case ImportNDJSON:
+		return &jsonDocReader{decoder: json.NewDecoder(r)}, nil
+	case ImportCSV:
+		return newCSVDocReader(r)
+	default:
+		return nil, fmt.Errorf("unknown import format %d", format)
+	}
+}
+
+// detectImportFormat treats a file with the .csv extension as CSV. Anything else must be JSON, which is an array
+// when it starts with [ and a sequence of documents otherwise
+func detectImportFormat(path string, r *bufio.Reader) (ImportFormat, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ImportCSV, nil
+	}
+	for {
+		c, _, err := r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			return ImportNDJSON, nil // An empty file holds no documents whatever the format
+		} else if err != nil {
+			return 0, err
+		}
+		if unicode.IsSpace(c) || c == '\uFEFF' { // Skip whitespace and a byte order mark
+			continue
+		}
+		if err := r.UnreadRune(); err != nil {
+			return 0, err
+		}
+		switch c {
+		case '[':
+			return ImportJSON, nil
+		case '{':
+			return ImportNDJSON, nil
+		default:
+			return 0, fmt.Errorf("could not detect the format of %s, choose json, ndjson or csv", path)
+		}
+	}
+}
+
+// jsonDocReader reads documents in Extended JSON, which also accepts plain JSON, either from a JSON array or from
+// a sequence of documents
+type jsonDocReader struct {
+	decoder *json.Decoder
+	array   bool
+	started bool
+}
+
+func (r *jsonDocReader) next() (bson.D, error) {
+	if r.array && !r.started {
+		r.started = true
+		if token, err := r.decoder.Token(); err != nil {
+			return nil, err
+		} else if token != json.Delim('[') {
+			return nil, fmt.Errorf("expected a JSON array of documents")
+		}
+	}
+	if r.array && !r.decoder.More() {
+		return nil, io.EOF
+	}
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(raw, false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// csvColumnHint matches a column with a type hint, such as age.int32() or created.date(2006-01-02), in the same
+// syntax as mongoimport's --columnsHaveTypes
+var csvColumnHint = regexp.MustCompile(`^(.+)\.(\w+)\((.*)\)$`)
+
+// csvParsers convert a cell to the type named by a column's hint. arg is whatever is between the parentheses
+var csvParsers = map[string]func(cell, arg string) (any, error){
+	"auto":   func(cell, _ string) (any, error) { return autoValue(cell), nil },
+	"string": func(cell, _ string) (any, error) { return cell, nil },
+	"int32": func(cell, _ string) (any, error) {
+		n, err := strconv.ParseInt(cell, 10, 32)
+		return int32(n), err
+	},
+	"int64":   func(cell, _ string) (any, error) { return strconv.ParseInt(cell, 10, 64) },
+	"double":  func(cell, _ string) (any, error) { return strconv.ParseFloat(cell, 64) },
+	"decimal": func(cell, _ string) (any, error) { return bson.ParseDecimal128(cell) },
+	"boolean": func(cell, _ string) (any, error) { return strconv.ParseBool(cell) },
+	"date": func(cell, layout string) (any, error) {
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		t, err := time.Parse(layout, cell)
+		return bson.NewDateTimeFromTime(t), err
+	},
+	"objectId": func(cell, _ string) (any, error) { return bson.ObjectIDFromHex(cell) },
+}
+
+// csvFloat matches the decimal numbers that autoValue parses, leaving words such as NaN and Inf as strings
+var csvFloat = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+type csvColumn struct {
+	header string
+	path   []string
+	parse  func(cell string) (any, error)
+}
+
+// csvDocReader builds a document from each row of a CSV file. Columns without a type hint are parsed as auto and
+// empty cells are left out of the document
+type csvDocReader struct {
+	reader  *csv.Reader
+	columns []csvColumn
+}
+
+func newCSVDocReader(r io.Reader) (*csvDocReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return &csvDocReader{reader: reader}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	columns := make([]csvColumn, len(header))
+	for i, h := range header {
+		if columns[i], err = parseCSVColumn(h); err != nil {
+			return nil, err
+		}
+	}
+	return &csvDocReader{reader: reader, columns: columns}, nil
+}
+
+func parseCSVColumn(header string) (csvColumn, error) {
+	name, typeName, arg := header, "auto", ""
+	if match := csvColumnHint.FindStringSubmatch(header); match != nil {
+		name, typeName, arg = match[1], match[2], match[3]
+	}
+	parse, ok := csvParsers[typeName]
+	if !ok {
+		return csvColumn{}, fmt.Errorf("unknown type %s in the CSV column %s", typeName, header)
+	}
+	if name == "" {
+		return csvColumn{}, fmt.Errorf("the CSV header has an empty column name")
+	}
+	return csvColumn{
+		header: header,
+		path:   strings.Split(name, "."),
+		parse:  func(cell string) (any, error) { return parse(cell, arg) },
+	}, nil
+}
+
+func (r *csvDocReader) next() (bson.D, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.D{}
+	for i, column := range r.columns {
+		if record[i] == "" {
+			continue
+		}
+		value, err := column.parse(record[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.header, err)
+		}
+		doc = setPath(doc, column.path, value)
+	}
+	for i := range doc {
+		doc[i].Value = arraysFromIndexes(doc[i].Value)
+	}
+	return doc, nil
+}
+
+// autoValue parses a cell without a type hint as a boolean or number when it looks like one, or as the {} and []
+// written by exports for empty documents and arrays. Anything else is kept as a string
+func autoValue(cell string) any {
+	switch cell {
+	case "true", "false":
+		return cell == "true"
+	case "{}":
+		return bson.D{}
+	case "[]":
+		return bson.A{}
+	}
+	if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		if n == int64(int32(n)) {
+			return int32(n)
+		}
+		return n
+	}
+	if csvFloat.MatchString(cell) {
+		if f, err := strconv.ParseFloat(cell, 64); err == nil {
+			return f
+		}
+	}
+	return cell
+}
+
+// arraysFromIndexes turns the embedded docs whose fields are 0, 1, 2 and so on into arrays so that the dotted
+// array paths written by a CSV export, such as tags.0, are imported as arrays again
+func arraysFromIndexes(value any) any {
+	doc, ok := value.(bson.D)
+	if !ok {
+		return value
+	}
+	isArray := len(doc) > 0
+	for i := range doc {
+		doc[i].Value = arraysFromIndexes(doc[i].Value)
+		isArray = isArray && doc[i].Key == strconv.Itoa(i)
+	}
+	if !isArray {
+		return doc
+	}
+	arr := make(bson.A, len(doc))
+	for i, elem := range doc {
+		arr[i] = elem.Value
+	}
+	return arr
+}
@@ -0,0 +1,159 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string // Copied to a temporary file unless it is a path to a fixture
+		content     string
+		coll        string
+		format      string
+		ordered     string
+		upsertKeys  string
+		wantSummary string
+		wantDocs    string // Every doc in the collection afterwards as relaxed Extended JSON, one per line
+	}{
+		{
+			name:        "demo json array",
+			file:        "../../docs/demo/onlineShop.products.json",
+			coll:        "products",
+			wantSummary: "imported 4 documents into shop.products",
+		},
+		{
+			name:        "ndjson with duplicate keys",
+			file:        "users.ndjson",
+			content:     `{"_id":4,"name":"Ann"}` + "\n" + `{"_id":1,"name":"Kev"}` + "\n" + `{"_id":5,"created":{"$date":"2024-01-02T03:04:05Z"}}` + "\n",
+			coll:        "users",
+			wantSummary: "imported 2 documents into shop.users, 1 failed: E11000 duplicate key error collection: shop.users index: _id_ dup key: { _id: 1 }",
+		},
+		{
+			name:        "ordered stops at the first failure",
+			file:        "users.json",
+			content:     `{"_id":1,"name":"Kev"} {"_id":4,"name":"Ann"}`,
+			coll:        "users",
+			ordered:     "yes",
+			wantSummary: "imported 0 documents into shop.users, 1 failed: E11000 duplicate key error collection: shop.users index: _id_ dup key: { _id: 1 }",
+		},
+		{
+			name:    "csv with type hints",
+			file:    "people.csv",
+			content: "_id.int32(),name,age.double(),joined.date(2006-01-02),tags.0,tags.1,address.city,owner.objectId(),zip.string()\n" + "7,Ann,30,2024-01-02,admin,dev,Boston,65a0c0ffee0000000000abcd,02134\n" + "8,true,,,,,,,\n",
+			coll:    "people",
+			wantDocs: `{"_id":7,"name":"Ann","age":30.0,"joined":{"$date":"2024-01-02T00:00:00Z"},"tags":["admin","dev"],"address":{"city":"Boston"},"owner":{"$oid":"65a0c0ffee0000000000abcd"},"zip":"02134"}` + "\n" +
+				`{"_id":8,"name":true}`,
+			wantSummary: "imported 2 documents into shop.people",
+		},
+		{
+			name:        "upsert by key",
+			file:        "users.ndjson",
+			content:     `{"name":"Kevin","age":31}` + "\n" + `{"_id":4,"name":"Ann"}`,
+			coll:        "users",
+			upsertKeys:  "name",
+			wantSummary: "imported 2 documents into shop.users",
+			wantDocs: `{"_id":1,"name":"Kevin","age":31}` + "\n" + `{"_id":2,"name":"Sally","age":25,"address":{"city":"Boston"}}` + "\n" +
+				`{"_id":3,"name":"George","age":41.5}` + "\n" + `{"_id":4,"name":"Ann"}`,
+		},
+		{
+			name:        "upsert skips docs missing the key",
+			file:        "users.ndjson",
+			content:     `{"age":99}` + "\n" + `{"name":"Kevin","age":31}` + "\n" + `{"_id":4,"address":{"zip":"02134"}}`,
+			coll:        "users",
+			upsertKeys:  "name",
+			wantSummary: "imported 1 documents into shop.users, 2 failed: document 1 is missing the upsert key name",
+			wantDocs: `{"_id":1,"name":"Kevin","age":31}` + "\n" + `{"_id":2,"name":"Sally","age":25,"address":{"city":"Boston"}}` + "\n" +
+				`{"_id":3,"name":"George","age":41.5}`,
+		},
+		{
+			name:        "ordered upsert stops at a doc missing the key",
+			file:        "users.ndjson",
+			content:     `{"name":"Sally","age":26,"address":{"city":"Boston"}}` + "\n" + `{"_id":4,"name":"Ann"}` + "\n" + `{"_id":5,"name":"Bob","address":{"city":"Austin"}}`,
+			coll:        "users",
+			ordered:     "yes",
+			upsertKeys:  "name,address.city",
+			wantSummary: "imported 1 documents into shop.users, 1 failed: document 2 is missing the upsert key address.city",
+			wantDocs: `{"_id":1,"name":"Kevin","age":30,"tags":["admin","dev"]}` + "\n" + `{"_id":2,"name":"Sally","age":26,"address":{"city":"Boston"}}` + "\n" +
+				`{"_id":3,"name":"George","age":41.5}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			path := tt.file
+			if tt.content != "" {
+				path = filepath.Join(t.TempDir(), tt.file)
+				if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+			format, ordered := "auto", "no"
+			if tt.format != "" {
+				format = tt.format
+			}
+			if tt.ordered != "" {
+				ordered = tt.ordered
+			}
+			opts, err := ParseImportOptions(path, format, ordered, tt.upsertKeys)
+			if err != nil {
+				t.Fatalf("ParseImportOptions() error = %v", err)
+			}
+
+			msg, ok := finishJob(t, e.Import("shop", tt.coll, opts)).(JobFinishedMsg)
+			if !ok || msg.Summary != tt.wantSummary || !msg.DatabasesRefreshed {
+				t.Fatalf("Import() = %#v, want the summary %q", msg, tt.wantSummary)
+			}
+			if tt.wantDocs == "" {
+				return
+			}
+			var docs []bson.D
+			if err := backend.Find(context.Background(), "shop", tt.coll, bson.D{}, FindOptions{}, &docs); err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			var lines []string
+			for _, doc := range docs {
+				data, err := bson.MarshalExtJSON(doc, false, false)
+				if err != nil {
+					t.Fatalf("MarshalExtJSON() error = %v", err)
+				}
+				lines = append(lines, string(data))
+			}
+			if got := strings.Join(lines, "\n"); got != tt.wantDocs {
+				t.Errorf("imported docs\n%s\nwant\n%s", got, tt.wantDocs)
+			}
+		})
+	}
+}
+
+func TestImportInvalidFile(t *testing.T) {
+	tests := []struct {
+		name, file, content, format string
+	}{
+		{name: "not json", file: "users.json", content: "name,age\n", format: "auto"},
+		{name: "not a document", file: "users.json", content: "[1]", format: "json"},
+		{name: "unknown type hint", file: "users.csv", content: "age.float()\n1\n", format: "auto"},
+		{name: "value not of the hinted type", file: "users.csv", content: "age.int32()\nold\n", format: "csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			opts, err := ParseImportOptions(path, tt.format, "no", "")
+			if err != nil {
+				t.Fatalf("ParseImportOptions() error = %v", err)
+			}
+			if msg, ok := finishJob(t, New(newSeededBackend(t)).Import("shop", "users", opts)).(JobFinishedMsg); ok {
+				t.Errorf("Import() = %#v, want an error", msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package mongoengine
+
+// The methods contained within this file list, create and drop the indexes of the selected collection
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"strings"
+	"time"
+)
+
+// Index describes a single index of a collection. The size and usage are only known when the server supports
+// the $collStats and $indexStats stages and the user is allowed to run them
+type Index struct {
+	Name                    string
+	Keys                    bson.D
+	Unique                  bool
+	Sparse                  bool
+	ExpireAfterSeconds      *int64 // Set for TTL indexes
+	PartialFilterExpression bson.D
+
+	Size     int64 // In bytes. -1 when unknown
+	HasUsage bool
+	Ops      int64     // Number of operations that used the index since the server started tracking it
+	Since    time.Time // When the server started tracking the usage of the index
+}
+
+// indexSpec is the format of each index returned by the listIndexes command
+type indexSpec struct {
+	Name                    string `bson:"name"`
+	Key                     bson.D `bson:"key"`
+	Unique                  bool   `bson:"unique"`
+	Sparse                  bool   `bson:"sparse"`
+	ExpireAfterSeconds      *int64 `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.D `bson:"partialFilterExpression"`
+}
+
+// LoadIndexes fetches the indexes of the selected collection along with their size and usage where available
+func (e *Engine) LoadIndexes() tea.Cmd {
+	return func() tea.Msg {
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+
+		indexes, err := e.fetchIndexes(dbName, collName)
+		if err != nil {
+			return modal.ErrModalMsg{Err: err}
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.server.cachedIndexes = indexes
+		return RedrawMessage{}
+	}
+}
+
+func (e *Engine) fetchIndexes(dbName, collName string) ([]Index, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	var specs []indexSpec
+	if err := e.backend.ListIndexes(ctx, dbName, collName, &specs); err != nil {
+		return nil, fmt.Errorf("could not list indexes of %s.%s: %w", dbName, collName, err)
+	}
+	sizes := e.fetchIndexSizes(ctx, dbName, collName)
+	usage := e.fetchIndexUsage(ctx, dbName, collName)
+
+	indexes := make([]Index, 0, len(specs))
+	for _, spec := range specs {
+		index := Index{
+			Name:                    spec.Name,
+			Keys:                    spec.Key,
+			Unique:                  spec.Unique,
+			Sparse:                  spec.Sparse,
+			ExpireAfterSeconds:      spec.ExpireAfterSeconds,
+			PartialFilterExpression: spec.PartialFilterExpression,
+			Size:                    -1,
+		}
+		if size, ok := sizes[spec.Name]; ok {
+			index.Size = size
+		}
+		if stats, ok := usage[spec.Name]; ok {
+			index.HasUsage = true
+			index.Ops = stats.Ops
+			index.Since = stats.Since
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// fetchIndexSizes returns the size of each index by name. The sizes are optional so errors are ignored
+func (e *Engine) fetchIndexSizes(ctx context.Context, dbName, collName string) map[string]int64 {
+	pipeline := bson.A{bson.D{{Key: "$collStats", Value: bson.D{{Key: "storageStats", Value: bson.D{}}}}}}
+	var results []bson.Raw
+	if err := e.backend.Aggregate(ctx, dbName, collName, pipeline, &results); err != nil || len(results) == 0 {
+		return nil
+	}
+	indexSizes, ok := results[0].Lookup("storageStats", "indexSizes").DocumentOK()
+	if !ok {
+		return nil
+	}
+	elems, _ := indexSizes.Elements()
+	sizes := make(map[string]int64, len(elems))
+	for _, elem := range elems {
+		if size, ok := elem.Value().AsInt64OK(); ok {
+			sizes[elem.Key()] = size
+		}
+	}
+	return sizes
+}
+
+type indexUsage struct {
+	Ops   int64     `bson:"ops"`
+	Since time.Time `bson:"since"`
+}
+
+// fetchIndexUsage returns how often each index has been used by name. The usage is optional so errors are ignored
+func (e *Engine) fetchIndexUsage(ctx context.Context, dbName, collName string) map[string]indexUsage {
+	pipeline := bson.A{bson.D{{Key: "$indexStats", Value: bson.D{}}}}
+	var results []struct {
+		Name     string     `bson:"name"`
+		Accesses indexUsage `bson:"accesses"`
+	}
+	if err := e.backend.Aggregate(ctx, dbName, collName, pipeline, &results); err != nil {
+		return nil
+	}
+	usage := make(map[string]indexUsage, len(results))
+	for _, result := range results {
+		usage[result.Name] = result.Accesses
+	}
+	return usage
+}
+
+// CreateIndex creates an index on the selected collection from a spec such as {"key": {"a": 1}, "unique": true}.
+// The spec may contain any option supported by the createIndexes command. A name is generated if none is given
+func (e *Engine) CreateIndex(spec bson.D) tea.Cmd {
+	return func() tea.Msg {
+		keys, ok := lookupField(spec, "key")
+		if keysDoc, isDoc := keys.(bson.D); !ok || !isDoc || len(keysDoc) == 0 {
+			return modal.ErrModalMsg{Err: fmt.Errorf("the index spec requires a key document with at least one field")}
+		} else if name, ok := lookupField(spec, "name"); !ok || name == "" {
+			spec = append(bson.D{{Key: "name", Value: defaultIndexName(keysDoc)}}, removeField(spec, "name")...)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+		if err := e.backend.CreateIndex(ctx, dbName, collName, spec); err != nil {
+			return modal.ErrModalMsg{Err: fmt.Errorf("could not create index: %w", err)}
+		}
+		return e.LoadIndexes()()
+	}
+}
+
+// DropIndex drops an index of the selected collection by name
+func (e *Engine) DropIndex(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+		if err := e.backend.DropIndex(ctx, dbName, collName, name); err != nil {
+			return modal.ErrModalMsg{Err: fmt.Errorf("could not drop index %s: %w", name, err)}
+		}
+		return e.LoadIndexes()()
+	}
+}
+
+// defaultIndexName generates the same name that MongoDB would for an index such as {a: 1, b: -1} -> a_1_b_-1
+func defaultIndexName(keys bson.D) string {
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// removeField returns a copy of doc without the top level field
+func removeField(doc bson.D, key string) bson.D {
+	var removed bson.D
+	for _, elem := range doc {
+		if elem.Key != key {
+			removed = append(removed, elem)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,113 @@
+package mongoengine
+
+// The methods contained within this file run long jobs, such as exports, in the background. Unlike an operation a
+// job is not bound by Timeout and is not replaced by the next query. Only one job runs at a time
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"sync/atomic"
+	"time"
+)
+
+const jobProgressInterval = 250 * time.Millisecond // How often the progress of a running job is redrawn
+
+type job struct {
+	id          uint64
+	description string
+	done        atomic.Int64 // Number of docs processed so far
+	total       atomic.Int64 // Number of docs expected to be processed. 0 when unknown
+	cancel      context.CancelCauseFunc
+	// refreshDatabases is set by jobs that write to the databases so they are reloaded once the job finishes
+	refreshDatabases bool
+}
+
+// JobProgress describes how far along the running job is
+type JobProgress struct {
+	Description string
+	Done        int64
+	Total       int64 // 0 when the number of docs to process is unknown
+}
+
+// JobProgressMsg is sent periodically while a job is running so that its progress is redrawn. It must be passed
+// to NextJobProgress to keep receiving them
+type JobProgressMsg struct {
+	jobId uint64
+}
+
+// JobFinishedMsg is sent once a job has completed or was cancelled
+type JobFinishedMsg struct {
+	Summary string
+	// DatabasesRefreshed is set when the cached databases were replaced as the job wrote to them, in which case
+	// their collections must be loaded again in the same way as after a DatabasesRefreshedMsg
+	DatabasesRefreshed bool
+}
+
+// runJob starts run in the background. run reports its progress through the job and returns a summary of what it
+// did. The returned command also starts the ticks that redraw the progress
+func (e *Engine) runJob(description string, run func(ctx context.Context, j *job) (string, error)) tea.Cmd {
+	e.mu.Lock()
+	if e.job != nil {
+		e.mu.Unlock()
+		return modal.DisplayErrorModal(fmt.Errorf("%s is still running, wait for it to finish or cancel it with ctrl+x", e.job.description))
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	e.jobId++
+	j := &job{id: e.jobId, description: description, cancel: cancel}
+	e.job = j
+	e.mu.Unlock()
+
+	return tea.Batch(func() tea.Msg {
+		summary, err := run(ctx, j)
+		cancelled := errors.Is(context.Cause(ctx), errOperationCancelled)
+		cancel(nil)
+		e.mu.Lock()
+		if e.job == j {
+			e.job = nil
+		}
+		e.mu.Unlock()
+
+		if j.refreshDatabases { // A failed or cancelled job may still have written part of its data
+			if refreshErr := e.RefreshDbAndCollections(); refreshErr != nil && err == nil && !cancelled {
+				err = fmt.Errorf("error refreshing data: %w", refreshErr)
+			}
+		}
+		if cancelled {
+			return JobFinishedMsg{Summary: fmt.Sprintf("cancelled %s after %d documents", description, j.done.Load()), DatabasesRefreshed: j.refreshDatabases}
+		}
+		if err != nil {
+			return modal.ErrModalMsg{Err: fmt.Errorf("%s failed: %w", description, err)}
+		}
+		return JobFinishedMsg{Summary: summary, DatabasesRefreshed: j.refreshDatabases}
+	}, jobProgressTick(j.id))
+}
+
+func jobProgressTick(id uint64) tea.Cmd {
+	return tea.Tick(jobProgressInterval, func(time.Time) tea.Msg {
+		return JobProgressMsg{jobId: id}
+	})
+}
+
+// NextJobProgress schedules the next redraw of the job's progress as long as it is still running
+func (e *Engine) NextJobProgress(msg JobProgressMsg) tea.Cmd {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.job == nil || e.job.id != msg.jobId {
+		return nil
+	}
+	return jobProgressTick(msg.jobId)
+}
+
+// CancelJob cancels the running job. It returns false if no job is running
+func (e *Engine) CancelJob() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.job == nil {
+		return false
+	}
+	e.job.cancel(errOperationCancelled)
+	return true
+}
@@ -0,0 +1,168 @@
+package mongoengine
+
+// The methods contained within this file keep a journal of the documents inserted, edited and deleted during the
+// session so that the latest of these writes can be undone
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
+)
+
+const maxJournalEntries = 100 // The oldest writes can no longer be undone once more than this have been made
+
+type WriteKind int
+
+const (
+	DocInserted WriteKind = iota
+	DocEdited
+	DocDeleted
+)
+
+// JournalEntry is a single write made to a document along with the images needed to reverse it
+type JournalEntry struct {
+	Id         uint64
+	Kind       WriteKind
+	Db         string
+	Collection string
+	Before     bson.M // The document before the write. nil for inserts
+	After      bson.M // The document after the write. nil for deletes
+	Time       time.Time
+}
+
+// Describe explains what undoing the write will do
+func (j JournalEntry) Describe() string {
+	ns := j.Db + "." + j.Collection
+	switch j.Kind {
+	case DocInserted:
+		return fmt.Sprintf("delete the document %s that was inserted into %s", idText(j.After), ns)
+	case DocEdited:
+		return fmt.Sprintf("restore the previous version of the document %s in %s", idText(j.Before), ns)
+	default:
+		return fmt.Sprintf("re-insert the document %s that was deleted from %s", idText(j.Before), ns)
+	}
+}
+
+// Summary names the kind of write along with the document it was made to
+func (j JournalEntry) Summary() string {
+	verb, doc := "delete", j.Before
+	switch j.Kind {
+	case DocInserted:
+		verb, doc = "insert", j.After
+	case DocEdited:
+		verb, doc = "edit", j.After
+	}
+	return fmt.Sprintf("%s %s in %s.%s", verb, idText(doc), j.Db, j.Collection)
+}
+
+// idText displays the _id of a document or the whole document if it has none
+func idText(doc bson.M) string {
+	var filter any = doc
+	if id, ok := doc["_id"]; ok {
+		filter = bson.D{{Key: "_id", Value: id}}
+	}
+	text, err := bson.MarshalExtJSON(filter, false, false)
+	if err != nil {
+		return fmt.Sprint(filter)
+	}
+	return string(text)
+}
+
+// recordWrite adds a write to the journal
+func (e *Engine) recordWrite(kind WriteKind, dbName, collName string, before, after bson.M) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.journalId++
+	e.journal = append(e.journal, JournalEntry{
+		Id:         e.journalId,
+		Kind:       kind,
+		Db:         dbName,
+		Collection: collName,
+		Before:     before,
+		After:      after,
+		Time:       time.Now(),
+	})
+	if len(e.journal) > maxJournalEntries {
+		e.journal = e.journal[len(e.journal)-maxJournalEntries:]
+	}
+}
+
+// PreviewUndo asks the user to confirm the undoing of the latest write
+func (e *Engine) PreviewUndo() tea.Cmd {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if len(e.journal) == 0 {
+		return modal.DisplayErrorModal(fmt.Errorf("there is nothing to undo"))
+	}
+	entry := e.journal[len(e.journal)-1]
+	return modal.DisplayUndoModal(entry.Id, entry.Describe())
+}
+
+// Undo reverses the latest write as long as it is still the write with the given id. It is only removed from the
+// journal once it has been reversed. A write is not reversed if the document has been changed since
+func (e *Engine) Undo(id uint64) tea.Cmd {
+	return func() tea.Msg {
+		e.mu.RLock()
+		var entry JournalEntry
+		if len(e.journal) > 0 {
+			entry = e.journal[len(e.journal)-1]
+		}
+		e.mu.RUnlock()
+		if entry.Id != id || id == 0 {
+			return modal.ErrModalMsg{Err: fmt.Errorf("could not undo as another write was made in the meantime")}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		if err := e.undo(ctx, entry); err != nil {
+			return modal.ErrModalMsg{Err: fmt.Errorf("could not undo: %w", err)}
+		}
+
+		e.mu.Lock()
+		if n := len(e.journal); n > 0 && e.journal[n-1].Id == id {
+			e.journal = e.journal[:n-1]
+		}
+		e.mu.Unlock()
+		return e.RerunLastCollectionQuery()()
+	}
+}
+
+// undo makes the write that reverses entry
+func (e *Engine) undo(ctx context.Context, entry JournalEntry) error {
+	return e.applyWrite(ctx, entry.inverse())
+}
+
+// inverse returns the write that reverses the entry
+func (j JournalEntry) inverse() JournalEntry {
+	inverse := j
+	inverse.Before, inverse.After = j.After, j.Before
+	switch j.Kind {
+	case DocInserted:
+		inverse.Kind = DocDeleted
+	case DocDeleted:
+		inverse.Kind = DocInserted
+	}
+	return inverse
+}
+
+// applyWrite makes the write described by entry. Edits and deletes are refused if the document no longer matches
+// entry.Before
+func (e *Engine) applyWrite(ctx context.Context, entry JournalEntry) error {
+	switch entry.Kind {
+	case DocInserted:
+		_, err := e.backend.InsertOne(ctx, entry.Db, entry.Collection, entry.After)
+		return err
+	case DocEdited, DocDeleted:
+		return e.writeUnchanged(ctx, entry.Db, entry.Collection, entry.Before, func(filter bson.D) (int64, error) {
+			if entry.Kind == DocDeleted {
+				return e.backend.DeleteOne(ctx, entry.Db, entry.Collection, filter)
+			}
+			return e.backend.ReplaceOne(ctx, entry.Db, entry.Collection, filter, entry.After)
+		})
+	default:
+		return fmt.Errorf("unknown write kind %d", entry.Kind)
+	}
+}
@@ -0,0 +1,125 @@
+package mongoengine
+
+import (
+	"context"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	ctx := context.Background()
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+
+	if _, ok := e.PreviewUndo()().(modal.ErrModalMsg); !ok {
+		t.Fatalf("PreviewUndo() should fail when nothing was written")
+	}
+
+	inserted := bson.M{"_id": 4, "name": "Ann"}
+	if err := e.InsertDocument(inserted); err != nil {
+		t.Fatalf("InsertDocument() error = %v", err)
+	}
+	loaded := loadDoc(t, backend, 4)
+	if err := e.UpdateDocument(loaded, bson.M{"_id": 4, "name": "Anne"}); err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	deleted := loadDoc(t, backend, 1)
+	if msg := e.DeleteDocument(&deleted)(); msg != (RedrawMessage{}) {
+		t.Fatalf("DeleteDocument() = %#v", msg)
+	}
+	if got := len(e.GetJournal()); got != 3 {
+		t.Fatalf("len(GetJournal()) = %d, want 3", got)
+	}
+
+	// Undo each write from the latest to the first
+	tests := []struct {
+		name      string
+		wantCount int64
+		wantName  any // Name of the doc with _id 4. nil when it should not exist
+	}{
+		{name: "delete", wantCount: 4, wantName: "Anne"},
+		{name: "edit", wantCount: 4, wantName: "Ann"},
+		{name: "insert", wantCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := e.GetJournal()
+			if msg := e.Undo(journal[len(journal)-1].Id)(); msg != (RedrawMessage{}) {
+				t.Fatalf("Undo() = %#v", msg)
+			}
+			if n, _ := backend.CountDocuments(ctx, "shop", "users", bson.D{}, 0); n != tt.wantCount {
+				t.Errorf("CountDocuments() = %d, want %d", n, tt.wantCount)
+			}
+			var docs []bson.M
+			_ = backend.Find(ctx, "shop", "users", bson.D{{Key: "_id", Value: 4}}, FindOptions{}, &docs)
+			var gotName any
+			if len(docs) > 0 {
+				gotName = docs[0]["name"]
+			}
+			if gotName != tt.wantName {
+				t.Errorf("name of the doc = %v, want %v", gotName, tt.wantName)
+			}
+		})
+	}
+	if len(e.GetJournal()) != 0 {
+		t.Errorf("GetJournal() = %v, want every write undone", e.GetJournal())
+	}
+}
+
+func TestUndoRefused(t *testing.T) {
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+
+	loaded := loadDoc(t, backend, 2)
+	if err := e.UpdateDocument(loaded, bson.M{"_id": 2, "name": "Sal"}); err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	id := e.GetJournal()[0].Id
+	if _, ok := e.Undo(id + 1)().(modal.ErrModalMsg); !ok {
+		t.Errorf("Undo() of a write that is not the latest should fail")
+	}
+
+	// Someone else changes the doc after the edit so restoring the previous version would lose their change
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "Sally"}}}}
+	if _, _, err := backend.UpdateMany(context.Background(), "shop", "users", bson.D{{Key: "_id", Value: 2}}, update); err != nil {
+		t.Fatalf("UpdateMany() error = %v", err)
+	}
+	if _, ok := e.Undo(id)().(modal.ErrModalMsg); !ok {
+		t.Errorf("Undo() of a doc changed by someone else should fail")
+	}
+	if len(e.GetJournal()) != 1 {
+		t.Errorf("a write that was not undone should stay in the journal")
+	}
+}
+
+func TestUndoInsertWithoutId(t *testing.T) {
+	ctx := context.Background()
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+
+	// Two docs that are equal other than their generated _id
+	for range 2 {
+		if err := e.InsertDocument(bson.M{"name": "Ann"}); err != nil {
+			t.Fatalf("InsertDocument() error = %v", err)
+		}
+	}
+	journal := e.GetJournal()
+	if _, ok := journal[0].After["_id"]; !ok {
+		t.Fatalf("the journal entry of the insert = %v, want the _id generated for it", journal[0].After)
+	}
+
+	if msg := e.Undo(journal[1].Id)(); msg != (RedrawMessage{}) {
+		t.Fatalf("Undo() = %#v", msg)
+	}
+	var docs []bson.M
+	if err := backend.Find(ctx, "shop", "users", bson.D{{Key: "name", Value: "Ann"}}, FindOptions{}, &docs); err != nil || len(docs) != 1 {
+		t.Fatalf("Find() = %v, %v, want the doc inserted first to remain", docs, err)
+	}
+	if docs[0]["_id"] != journal[0].After["_id"] {
+		t.Errorf("remaining doc = %v, want the one inserted first with the _id %v", docs[0], journal[0].After["_id"])
+	}
+}
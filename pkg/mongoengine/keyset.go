@@ -0,0 +1,112 @@
+package mongoengine
+
+// The functions contained within this file are used for keyset pagination. Rather than skipping over every doc
+// on the previous pages, the next page is fetched by filtering on the sort key of the last doc of the current page
+// which lets MongoDB jump straight to the page via an index
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type PaginationMode int
+
+const (
+	SkipPagination PaginationMode = iota
+	KeysetPagination
+)
+
+func (p PaginationMode) String() string {
+	if p == KeysetPagination {
+		return "keyset"
+	}
+	return "skip"
+}
+
+// keysetSort returns the sort to use when paginating the query by keyset. Only queries sorted on _id or on a
+// single field can be paginated this way as _id is added as a tiebreaker so that every sort key is unique.
+// Any other sort, and aggregations, fall back to skip pagination
+func keysetSort(query Query) (bson.D, bool) {
+	if query.IsAggregation() {
+		return nil, false
+	}
+	switch len(query.Sort) {
+	case 0:
+		return bson.D{{Key: "_id", Value: 1}}, true
+	case 1:
+		direction, ok := sortDirection(query.Sort[0].Value)
+		if !ok {
+			return nil, false
+		}
+		if query.Sort[0].Key == "_id" {
+			return bson.D{{Key: "_id", Value: direction}}, true
+		}
+		return bson.D{{Key: query.Sort[0].Key, Value: direction}, {Key: "_id", Value: direction}}, true
+	default:
+		return nil, false
+	}
+}
+
+// sortDirection converts the value of a sort field into 1 or -1. Sorts such as {$meta: "textScore"} are rejected
+func sortDirection(v any) (int, bool) {
+	f, ok := toFloat(v)
+	switch {
+	case !ok:
+		return 0, false
+	case f > 0:
+		return 1, true
+	case f < 0:
+		return -1, true
+	default:
+		return 0, false
+	}
+}
+
+// keysetFilter adds the conditions that only match docs sorted after the sort key to the filter. For a sort of
+// {a: 1, _id: 1} this is {$or: [{a: {$gt: key.a}}, {a: key.a, _id: {$gt: key._id}}]}
+func keysetFilter(filter bson.D, sort bson.D, key bson.D) bson.D {
+	var or bson.A
+	for i, field := range sort {
+		condition := bson.D{}
+		for _, equalField := range key[:i] {
+			condition = append(condition, bson.E{Key: equalField.Key, Value: equalField.Value})
+		}
+		op := "$gt"
+		if direction, _ := sortDirection(field.Value); direction < 0 {
+			op = "$lt"
+		}
+		condition = append(condition, bson.E{Key: field.Key, Value: bson.D{{Key: op, Value: key[i].Value}}})
+		or = append(or, condition)
+	}
+
+	var keyset bson.D
+	if len(or) == 1 {
+		keyset = or[0].(bson.D)
+	} else {
+		keyset = bson.D{{Key: "$or", Value: or}}
+	}
+	if len(filter) == 0 {
+		return keyset
+	}
+	return bson.D{{Key: "$and", Value: bson.A{filter, keyset}}}
+}
+
+// sortKeyOf returns the values of the sort fields within doc. nil is returned if a field is missing, for example
+// because it was removed by a projection, or if it is an array as neither can be compared with $gt or $lt
+func sortKeyOf(doc *bson.M, sort bson.D) bson.D {
+	if doc == nil {
+		return nil
+	}
+	d, err := toDoc(doc)
+	if err != nil {
+		return nil
+	}
+	var key bson.D
+	for _, field := range sort {
+		v, ok := lookupPath(d, field.Key)
+		if _, isArray := v.(bson.A); !ok || isArray || v == nil {
+			return nil
+		}
+		key = append(key, bson.E{Key: field.Key, Value: v})
+	}
+	return key
+}
@@ -0,0 +1,79 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	const docCount = 60
+	backend := NewMemoryBackend()
+	for i := range docCount {
+		doc := bson.D{{Key: "_id", Value: i}, {Key: "group", Value: i % 3}}
+		if _, err := backend.InsertOne(context.Background(), "shop", "items", doc); err != nil {
+			t.Fatalf("failed to seed backend: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		query       Query
+		wantKeyset  bool
+		wantFirstId int32
+	}{
+		{name: "natural order", query: Query{}, wantKeyset: true, wantFirstId: 0},
+		{name: "single field sort with duplicate values", query: Query{Sort: bson.D{{Key: "group", Value: -1}}}, wantKeyset: true, wantFirstId: 59},
+		{name: "filtered", query: Query{Filter: bson.D{{Key: "group", Value: 1}}}, wantKeyset: true, wantFirstId: 1},
+		{name: "compound sort falls back to skip", query: Query{Sort: bson.D{{Key: "group", Value: 1}, {Key: "_id", Value: -1}}}, wantFirstId: 57},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(backend)
+			e.SetSelectedCollection("shop", "items")
+			if msg := e.TogglePaginationMode()(); msg != (RedrawMessage{}) {
+				t.Fatalf("TogglePaginationMode() returned %#v", msg)
+			}
+			if msg := e.QueryCollection(tt.query)(); msg != (RedrawMessage{}) {
+				t.Fatalf("QueryCollection() returned %#v", msg)
+			}
+			if _, keyset := e.GetPaginationMode(); keyset != tt.wantKeyset {
+				t.Errorf("keyset paginated = %v, want %v", keyset, tt.wantKeyset)
+			}
+			if got := (*e.GetQueriedDocs()[0])["_id"]; got != tt.wantFirstId {
+				t.Errorf("first _id = %v, want %d", got, tt.wantFirstId)
+			}
+
+			seen := make(map[any]bool)
+			for {
+				for _, doc := range e.GetQueriedDocs() {
+					if seen[(*doc)["_id"]] {
+						t.Fatalf("doc %v was returned on more than one page", (*doc)["_id"])
+					}
+					seen[(*doc)["_id"]] = true
+				}
+				if e.Skip+Limit >= e.DocCount {
+					break
+				}
+				if msg := e.NextPage()(); msg != (RedrawMessage{}) {
+					t.Fatalf("NextPage() returned %#v", msg)
+				}
+			}
+			if int64(len(seen)) != e.DocCount {
+				t.Errorf("paged through %d docs, want %d", len(seen), e.DocCount)
+			}
+
+			if e.DocCount <= Limit {
+				return
+			}
+			lastSkip := e.Skip
+			if msg := e.PreviousPage()(); msg != (RedrawMessage{}) {
+				t.Fatalf("PreviousPage() returned %#v", msg)
+			}
+			if e.Skip != lastSkip-Limit || len(e.GetQueriedDocs()) != Limit {
+				t.Errorf("PreviousPage() moved to skip %d with %d docs, want %d with %d", e.Skip, len(e.GetQueriedDocs()), lastSkip-Limit, Limit)
+			}
+		})
+	}
+}
@@ -9,138 +9,323 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"slices"
 	"strings"
 )
 
+// RefreshDbAndCollections clears all cached data and fetches the name of every database. Collections are
+// loaded per database via LoadCollections and LoadAllCollections, apart from the selected database whose
+// collections are fetched right away so that they do not disappear after an insert or drop
 func (e *Engine) RefreshDbAndCollections() error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	e.server = &server{ // Clear all cached data
-		databases: make(map[string]database),
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
-	dbNames, err := e.Client.ListDatabaseNames(ctx, bson.D{})
+	dbNames, err := e.backend.ListDatabaseNames(ctx)
 	if err != nil {
 		return err
 	}
 
-	e.server.databases = make(map[string]database)
+	e.mu.Lock()
+	e.server = &server{ // Clear all cached data
+		databases: make(map[string]database, len(dbNames)),
+	}
 	for _, dbName := range dbNames {
-		if err := e.fetchCollectionsPerDb(dbName); err != nil {
-			return err
-		}
+		e.server.databases[dbName] = database{}
 	}
-	e.server.cachedDocs = nil
-	e.server.cachedDocSummaries = nil
 	e.DocCount = 0
+	selectedDb := e.selectedDb
+	e.mu.Unlock()
+
+	if srv, ok := e.claimCollectionsLoad(selectedDb, false); ok {
+		e.fetchCollectionsPerDb(srv, selectedDb)
+	}
 	return nil
 }
 
-// fetchCollectionsPerDb will add all collections to a database entry within the mongoengine Server struct
-func (e *Engine) fetchCollectionsPerDb(dbName string) error {
-	db := e.Client.Database(dbName)
+// LoadCollections fetches the collections of a single database. It is used when a database is highlighted so it
+// does not wait behind the databases queued by LoadAllCollections and it retries databases that failed to load
+func (e *Engine) LoadCollections(dbName string) tea.Cmd {
+	srv, ok := e.claimCollectionsLoad(dbName, true)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		e.fetchCollectionsPerDb(srv, dbName)
+		return RedrawMessage{}
+	}
+}
+
+// LoadAllCollections fetches the collections of every database that has not been loaded yet in the background.
+// At most maxConcurrentCollectionLoads databases are fetched at once and the view is redrawn as each one finishes
+func (e *Engine) LoadAllCollections() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, dbName := range e.GetDatabases() {
+		cmds = append(cmds, func() tea.Msg {
+			e.loadSem <- struct{}{}
+			defer func() { <-e.loadSem }()
+
+			srv, ok := e.claimCollectionsLoad(dbName, false)
+			if !ok { // Already loaded because the database was highlighted while queued
+				return nil
+			}
+			e.fetchCollectionsPerDb(srv, dbName)
+			return RedrawMessage{}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// claimCollectionsLoad marks the collections of a database as loading so that no other load is started for it.
+// It returns the server the collections should be stored in or false if there is nothing to load
+func (e *Engine) claimCollectionsLoad(dbName string, retryFailed bool) (*server, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	db, ok := e.server.databases[dbName]
+	if !ok || !(db.state == CollectionsNotLoaded || (retryFailed && db.state == CollectionsFailed)) {
+		return nil, false
+	}
+	db.state = CollectionsLoading
+	e.server.databases[dbName] = db
+	return e.server, true
+}
+
+// fetchCollectionsPerDb will add all collections to a database entry within the mongoengine Server struct. The
+// database is marked as failed rather than returning an error so that the other databases can still be browsed
+func (e *Engine) fetchCollectionsPerDb(srv *server, dbName string) {
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
 
-	collectionNames, err := db.ListCollectionNames(ctx, bson.D{})
+	collectionNames, err := e.backend.ListCollectionNames(ctx, dbName)
+	slices.Sort(collectionNames)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.server != srv { // The cache was refreshed while the collections were loading
+		return
+	}
+	db := srv.databases[dbName] // Keep any stats that were loaded alongside the collections
 	if err != nil {
-		return fmt.Errorf("could not list collections for database %s: %v", dbName, err)
+		db.collections, db.state = nil, CollectionsFailed
+		db.err = fmt.Errorf("could not list collections for database %s: %v", dbName, err)
+	} else {
+		db.collections, db.state, db.err = collectionNames, CollectionsLoaded, nil
 	}
-	slices.Sort(collectionNames)
-	e.server.databases[dbName] = database{collections: collectionNames} // zero out the Documents
-	return nil
+	srv.databases[dbName] = db
 }
 
 // QueryCollection fetches all the data from a given collection in a given database given a particular query
-func (e *Engine) QueryCollection(query bson.D) tea.Cmd {
-	return func() tea.Msg {
-		e.mu.Lock()
-		defer e.mu.Unlock()
-
-		e.Skip = 0
-		err := e.executeQuery(query)
-		if err != nil {
-			return modal.ErrModalMsg{Err: err}
-		}
-
-		e.lastExecutedQuery = query
-		return RedrawMessage{}
-	}
+func (e *Engine) QueryCollection(query Query) tea.Cmd {
+	return e.queryPage(query, pageRequest{})
 }
 
 // RerunLastCollectionQuery will rerun the last query that was just run against the database. This is useful
 // after doc edits or pagination
 func (e *Engine) RerunLastCollectionQuery() tea.Cmd {
-	return func() tea.Msg {
-		e.mu.Lock()
-		defer e.mu.Unlock()
-
-		err := e.executeQuery(e.lastExecutedQuery)
-		if err != nil {
-			return modal.ErrModalMsg{Err: err}
-		}
-		return RedrawMessage{}
-	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.queryPage(e.lastExecutedQuery, pageRequest{skip: e.Skip, afterKeys: e.pageAfterKeys})
 }
 
+// NextPage fetches the page after the current one. The docs are not counted again when turning pages
 func (e *Engine) NextPage() tea.Cmd {
-	if e.Skip+Limit < e.DocCount {
-		e.Skip += Limit
-		return e.RerunLastCollectionQuery()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.hasNextPage() {
+		// lastPageKey is nil when paginating by skip which makes the next page fall back to skip as well
+		afterKeys := append(slices.Clone(e.pageAfterKeys), e.lastPageKey)
+		return e.queryPage(e.lastExecutedQuery, pageRequest{skip: e.Skip + Limit, afterKeys: afterKeys, count: e.currentDocCount()})
 	} else {
 		return modal.DisplayErrorModal(fmt.Errorf("already on last document page"))
 	}
 }
 
+// PreviousPage fetches the page before the current one. The docs are not counted again when turning pages
 func (e *Engine) PreviousPage() tea.Cmd {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	if e.Skip > 0 {
-		e.Skip -= Limit
-		return e.RerunLastCollectionQuery()
+		var afterKeys []bson.D
+		if len(e.pageAfterKeys) > 0 {
+			afterKeys = e.pageAfterKeys[:len(e.pageAfterKeys)-1]
+		}
+		return e.queryPage(e.lastExecutedQuery, pageRequest{skip: e.Skip - Limit, afterKeys: afterKeys, count: e.currentDocCount()})
 	} else {
 		return modal.DisplayErrorModal(fmt.Errorf("already on first document page"))
 	}
 }
 
-func (e *Engine) getDocCount(query bson.D) (int64, error) {
-	db := e.Client.Database(e.selectedDb)
-	coll := db.Collection(e.selectedCollection)
-	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
-	defer cancel()
+// TogglePaginationMode switches between skip and keyset pagination and then reruns the last query from its
+// first page
+func (e *Engine) TogglePaginationMode() tea.Cmd {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.paginationMode == SkipPagination {
+		e.paginationMode = KeysetPagination
+	} else {
+		e.paginationMode = SkipPagination
+	}
+	return e.queryPage(e.lastExecutedQuery, pageRequest{})
+}
 
-	return coll.CountDocuments(ctx, query)
+// CycleCountMode switches filtered queries between exact, capped and no counts and then reruns the last query
+func (e *Engine) CycleCountMode() tea.Cmd {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch e.countMode {
+	case CountExact:
+		e.countMode = CountCapped
+	case CountCapped:
+		e.countMode = CountNone
+	default:
+		e.countMode = CountExact
+	}
+	return e.queryPage(e.lastExecutedQuery, pageRequest{skip: e.Skip, afterKeys: e.pageAfterKeys})
 }
 
-// executeQuery is used internally to handle an initial query sent by QueryCollection as well as the nextPage and
-// previousPage pagination functions that use the m.lastExecutedQuery variable
-func (e *Engine) executeQuery(query bson.D) error {
-	db := e.Client.Database(e.selectedDb)
-	coll := db.Collection(e.selectedCollection)
-	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
-	defer cancel()
+// hasNextPage reports if there are docs after the current page. Capped and missing counts can not tell how many
+// docs there are past the count so a full page is taken to mean that there are more. The caller must hold the lock
+func (e *Engine) hasNextPage() bool {
+	next := e.Skip + Limit
+	if e.lastExecutedQuery.Limit > 0 && next >= e.lastExecutedQuery.Limit {
+		return false
+	}
+	switch e.docCountKind {
+	case CountCapped, CountNone:
+		return next < e.DocCount || len(e.server.cachedDocs) == Limit
+	default:
+		return next < e.DocCount
+	}
+}
 
-	count, err := e.getDocCount(query)
-	if err != nil {
-		return err
+// currentDocCount returns the count of the current query so that it can be reused. The caller must hold the lock
+func (e *Engine) currentDocCount() *docCount {
+	return &docCount{n: e.DocCount, kind: e.docCountKind}
+}
+
+// pageRequest describes which page of a query should be fetched
+type pageRequest struct {
+	skip      int64     // Number of docs on the previous pages
+	afterKeys []bson.D  // Sort keys that each page up to and including the requested one started after
+	count     *docCount // The count of the query from a previous page. nil if the docs should be counted
+}
+
+// after returns the sort key of the last doc on the previous page, or nil if the page should be fetched by skip
+func (r pageRequest) after() bson.D {
+	if len(r.afterKeys) == 0 {
+		return nil
 	}
-	e.DocCount = count
+	return r.afterKeys[len(r.afterKeys)-1]
+}
 
-	findOptions := options.Find().SetSkip(e.Skip).SetLimit(int64(Limit))
-	cur, err := coll.Find(ctx, query, findOptions)
-	if err != nil {
-		return err
+// queryPage cancels the query that is still in flight, if any, and then fetches the requested page of docs.
+// The network calls are made without holding the lock so that the UI can keep rendering and cancel the query
+func (e *Engine) queryPage(query Query, req pageRequest) tea.Cmd {
+	ctx, id := e.startOperation()
+	return func() tea.Msg {
+		defer e.finishOperation(id)
+
+		e.mu.RLock()
+		dbName, collName, pagination, counting := e.selectedDb, e.selectedCollection, e.paginationMode, e.countMode
+		e.mu.RUnlock()
+
+		page, err := e.fetchPage(ctx, dbName, collName, query, req, pagination, counting)
+		if err != nil {
+			return operationErrMsg(ctx, err)
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if ctx.Err() != nil { // Do not overwrite the results of a newer query
+			return operationErrMsg(ctx, ctx.Err())
+		}
+		e.Skip = req.skip
+		e.DocCount = page.count.n
+		e.docCountKind = page.count.kind
+		e.lastExecutedQuery = query
+		e.keysetPaginated = page.keysetSort != nil
+		e.pageAfterKeys = req.afterKeys
+		e.lastPageKey = nil
+		if page.keysetSort != nil && len(page.docs) > 0 {
+			e.lastPageKey = sortKeyOf(page.docs[len(page.docs)-1], page.keysetSort)
+		}
+		e.cacheDocs(page.docs)
+		return RedrawMessage{}
+	}
+}
+
+// page holds a single page of docs along with the total number of docs matched by the query
+type page struct {
+	docs       []*bson.M
+	count      docCount
+	keysetSort bson.D // The sort used to fetch the docs when paginating by keyset
+}
+
+// fetchPage runs either a find or an aggregation to fetch the requested page of docs. With keyset pagination the
+// page is found by filtering on the sort key of the previous page, if it is known, instead of skipping to it
+func (e *Engine) fetchPage(ctx context.Context, dbName, collName string, query Query, req pageRequest, pagination PaginationMode, counting CountKind) (page, error) {
+	if query.IsAggregation() {
+		return e.fetchAggregationPage(ctx, dbName, collName, query, req.skip)
+	}
+
+	var count docCount
+	if req.count != nil {
+		count = *req.count
+	} else {
+		var err error
+		if count, err = e.countDocs(ctx, dbName, collName, query, counting); err != nil {
+			return page{}, err
+		}
+	}
+
+	filter, opts := query.filter(), findOptions(query, req.skip)
+	var sort bson.D
+	if pagination == KeysetPagination {
+		if keyset, ok := keysetSort(query); ok {
+			sort = keyset
+			opts.Sort = keyset
+			if after := req.after(); after != nil {
+				filter = keysetFilter(filter, keyset, after)
+				opts.Skip = 0
+			}
+		}
 	}
 
 	var data []*bson.M
-	if err = cur.All(ctx, &data); err != nil {
-		return err
+	if err := e.backend.Find(ctx, dbName, collName, filter, opts, &data); err != nil {
+		return page{}, err
+	}
+	return page{docs: data, count: count, keysetSort: sort}, nil
+}
+
+// fetchAggregationPage runs the pipeline of the query with a $facet stage appended so that a single page of
+// results as well as the total number of results are returned in one round trip
+func (e *Engine) fetchAggregationPage(ctx context.Context, dbName, collName string, query Query, skip int64) (page, error) {
+	pipeline := append(slices.Clone(query.Pipeline), bson.D{{Key: "$facet", Value: bson.D{
+		{Key: "docs", Value: bson.A{bson.D{{Key: "$skip", Value: skip}}, bson.D{{Key: "$limit", Value: Limit}}}},
+		{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "count"}}}},
+	}}})
+	var results []struct {
+		Docs  []*bson.M `bson:"docs"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := e.backend.Aggregate(ctx, dbName, collName, pipeline, &results); err != nil {
+		return page{}, err
 	}
 
-	// Create doc summary cache
+	var p page
+	if len(results) > 0 {
+		p.docs = results[0].Docs
+		if len(results[0].Total) > 0 { // $count does not output a doc when there are no results
+			p.count.n = results[0].Total[0].Count
+		}
+	}
+	return p, nil
+}
+
+// cacheDocs stores the docs of the current page along with the summaries that the doclist component displays.
+// The caller must hold the write lock
+func (e *Engine) cacheDocs(data []*bson.M) {
 	var newDocsSummaries []docSummary
 	for _, doc := range data {
 		var row docSummary
@@ -164,7 +349,21 @@ func (e *Engine) executeQuery(query bson.D) error {
 
 	e.server.cachedDocSummaries = newDocsSummaries
 	e.server.cachedDocs = data
-	return nil
+}
+
+// findOptions builds the options for fetching the page of docs starting at skip given the sort, projection and
+// limit that were entered alongside the filter
+func findOptions(query Query, skip int64) FindOptions {
+	pageSize := int64(Limit)
+	if query.Limit > 0 && query.Limit-skip < pageSize { // Do not go past the limit the user requested
+		pageSize = query.Limit - skip
+	}
+	return FindOptions{
+		Skip:       skip,
+		Limit:      pageSize,
+		Sort:       query.Sort,
+		Projection: query.Projection,
+	}
 }
 
 func getFieldType(value interface{}) string {
@@ -0,0 +1,59 @@
+package mongoengine
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"testing"
+)
+
+// failingCollectionsBackend fails to list the collections of a single database
+type failingCollectionsBackend struct {
+	*MemoryBackend
+	failingDb string
+}
+
+func (b *failingCollectionsBackend) ListCollectionNames(ctx context.Context, db string) ([]string, error) {
+	if db == b.failingDb {
+		return nil, fmt.Errorf("not authorized on %s", db)
+	}
+	return b.MemoryBackend.ListCollectionNames(ctx, db)
+}
+
+func TestLoadCollections(t *testing.T) {
+	backend := &failingCollectionsBackend{MemoryBackend: newSeededBackend(t), failingDb: "secret"}
+	for _, db := range []string{"secret", "warehouse"} {
+		if err := backend.CreateCollection(context.Background(), db, "items", nil); err != nil {
+			t.Fatalf("failed to seed backend: %v", err)
+		}
+	}
+	e := New(backend)
+	e.SetSelectedDatabase("shop")
+	if err := e.RefreshDbAndCollections(); err != nil {
+		t.Fatalf("RefreshDbAndCollections() error = %v", err)
+	}
+
+	wantStates := map[string]CollectionsState{"shop": CollectionsLoaded, "secret": CollectionsNotLoaded, "warehouse": CollectionsNotLoaded}
+	for db, want := range wantStates {
+		if got, _ := e.GetCollectionsState(db); got != want {
+			t.Errorf("after refresh GetCollectionsState(%s) = %v, want %v", db, got, want)
+		}
+	}
+
+	for _, cmd := range e.LoadAllCollections()().(tea.BatchMsg) {
+		cmd()
+	}
+	wantStates = map[string]CollectionsState{"shop": CollectionsLoaded, "secret": CollectionsFailed, "warehouse": CollectionsLoaded}
+	for db, want := range wantStates {
+		if got, err := e.GetCollectionsState(db); got != want || (err != nil) != (want == CollectionsFailed) {
+			t.Errorf("after loading GetCollectionsState(%s) = %v, %v, want %v", db, got, err, want)
+		}
+	}
+
+	if e.LoadCollections("warehouse") != nil {
+		t.Errorf("LoadCollections() should not reload a database that has already been loaded")
+	}
+	if e.LoadCollections("secret") == nil {
+		t.Errorf("LoadCollections() should retry a database that failed to load")
+	}
+}
@@ -0,0 +1,722 @@
+package mongoengine
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"sync"
+)
+
+// MemoryBackend implements Backend by keeping every database in memory. It understands basic filters, sorts,
+// projections and aggregation stages which makes it suitable for testing components without a MongoDB server
+type MemoryBackend struct {
+	mu        sync.Mutex
+	databases map[string]map[string][]bson.D // database name -> collection name -> docs in insertion order
+	indexes   map[string]map[string][]bson.D // database name -> collection name -> index specs other than _id_
+	options   map[string]map[string]bson.D   // database name -> collection name -> options it was created with
+
+	watchers     map[string][]*memoryChangeStream // Namespace -> change streams opened by Watch
+	eventCounter int64                            // Used as the resume token and ordering of change events
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		databases: make(map[string]map[string][]bson.D),
+		indexes:   make(map[string]map[string][]bson.D),
+		options:   make(map[string]map[string]bson.D),
+		watchers:  make(map[string][]*memoryChangeStream),
+	}
+}
+
+func (b *MemoryBackend) ListDatabaseNames(_ context.Context) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name := range b.databases {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (b *MemoryBackend) ListCollectionNames(_ context.Context, db string) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name := range b.databases[db] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (b *MemoryBackend) Find(_ context.Context, db, coll string, filter any, opts FindOptions, results any) error {
+	docs, err := b.find(db, coll, filter, opts)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, results)
+}
+
+func (b *MemoryBackend) FindCursor(_ context.Context, db, coll string, filter any, opts FindOptions) (Cursor, error) {
+	docs, err := b.find(db, coll, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
+
+func (b *MemoryBackend) find(db, coll string, filter any, opts FindOptions) ([]bson.D, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	docs, err := b.matchingDocs(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortDocs(docs, opts.Sort); err != nil {
+		return nil, err
+	}
+	docs = skipAndLimit(docs, opts.Skip, opts.Limit)
+	if len(opts.Projection) > 0 {
+		if docs, err = projectDocs(docs, opts.Projection); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (b *MemoryBackend) Aggregate(_ context.Context, db, coll string, pipeline any, results any) error {
+	docs, err := b.aggregate(db, coll, pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, results)
+}
+
+func (b *MemoryBackend) AggregateCursor(_ context.Context, db, coll string, pipeline any) (Cursor, error) {
+	docs, err := b.aggregate(db, coll, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCursor{docs: docs}, nil
+}
+
+func (b *MemoryBackend) aggregate(db, coll string, pipeline any) ([]bson.D, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stages, err := toDocSlice(pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	docs := slices.Clone(b.databases[db][coll])
+	if len(stages) > 0 && len(stages[0]) > 0 && stages[0][0].Key == "$collStats" { // Only valid as the first stage
+		stats, err := b.collStats(db, coll)
+		if err != nil {
+			return nil, err
+		}
+		docs, stages = []bson.D{stats}, stages[1:]
+	}
+	return runPipeline(docs, stages)
+}
+
+func (b *MemoryBackend) CountDocuments(ctx context.Context, db, coll string, filter any, limit int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	docs, err := b.matchingDocs(db, coll, filter)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(len(docs))
+	if limit > 0 && count > limit {
+		count = limit
+	}
+	return count, nil
+}
+
+func (b *MemoryBackend) EstimatedDocumentCount(_ context.Context, db, coll string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.databases[db][coll])), nil
+}
+
+func (b *MemoryBackend) InsertOne(_ context.Context, db, coll string, doc any) (any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, err := toDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := lookupField(d, "_id"); !ok { // The driver generates an _id client side when one is not provided
+		d = append(bson.D{{Key: "_id", Value: bson.NewObjectID()}}, d...)
+	}
+	id, _ := lookupField(d, "_id")
+	for _, existing := range b.databases[db][coll] {
+		if existingId, _ := lookupField(existing, "_id"); valuesEqual(existingId, id) {
+			return nil, fmt.Errorf("E11000 duplicate key error collection: %s.%s index: _id_ dup key: { _id: %v }", db, coll, id)
+		}
+	}
+	b.ensureCollection(db, coll)
+	b.databases[db][coll] = append(b.databases[db][coll], d)
+	b.publish(db, coll, "insert", id, d)
+	return id, nil
+}
+
+func (b *MemoryBackend) ReplaceOne(_ context.Context, db, coll string, filter, replacement any) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i, err := b.firstMatchIndex(db, coll, filter)
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	newDoc, err := toDoc(replacement)
+	if err != nil {
+		return 0, err
+	}
+	oldId, _ := lookupField(b.databases[db][coll][i], "_id")
+	if newId, ok := lookupField(newDoc, "_id"); ok && !valuesEqual(oldId, newId) {
+		return 0, fmt.Errorf("the (immutable) field '_id' was found to have been altered")
+	} else if !ok {
+		newDoc = append(bson.D{{Key: "_id", Value: oldId}}, newDoc...)
+	}
+	b.databases[db][coll][i] = newDoc
+	b.publish(db, coll, "replace", oldId, newDoc)
+	return 1, nil
+}
+
+func (b *MemoryBackend) DeleteOne(_ context.Context, db, coll string, filter any) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i, err := b.firstMatchIndex(db, coll, filter)
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	id, _ := lookupField(b.databases[db][coll][i], "_id")
+	b.databases[db][coll] = slices.Delete(b.databases[db][coll], i, i+1)
+	b.publish(db, coll, "delete", id, nil)
+	return 1, nil
+}
+
+func (b *MemoryBackend) DeleteMany(ctx context.Context, db, coll string, filter any) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, err := toDoc(filter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid filter: %w", err)
+	}
+	var kept []bson.D
+	var deleted int64
+	for _, doc := range b.databases[db][coll] {
+		matched, err := matchDoc(doc, f)
+		if err != nil {
+			return 0, err
+		}
+		if !matched {
+			kept = append(kept, doc)
+			continue
+		}
+		id, _ := lookupField(doc, "_id")
+		b.publish(db, coll, "delete", id, nil)
+		deleted++
+	}
+	if deleted > 0 {
+		b.databases[db][coll] = append([]bson.D{}, kept...)
+	}
+	return deleted, nil
+}
+
+func (b *MemoryBackend) InsertMany(ctx context.Context, db, coll string, docs []bson.D, ordered bool) (BulkResult, error) {
+	return writeEach(docs, ordered, func(i int) error {
+		_, err := b.InsertOne(ctx, db, coll, docs[i])
+		return err
+	}), nil
+}
+
+func (b *MemoryBackend) UpsertMany(ctx context.Context, db, coll string, filters, docs []bson.D, ordered bool) (BulkResult, error) {
+	return writeEach(docs, ordered, func(i int) error {
+		matched, err := b.ReplaceOne(ctx, db, coll, filters[i], docs[i])
+		if err != nil || matched > 0 {
+			return err
+		}
+		_, err = b.InsertOne(ctx, db, coll, docs[i])
+		return err
+	}), nil
+}
+
+// writeEach writes the docs of a bulk write one at a time, stopping at the first failure when ordered
+func writeEach(docs []bson.D, ordered bool, write func(i int) error) BulkResult {
+	var res BulkResult
+	for i := range docs {
+		if err := write(i); err != nil {
+			if res.Failed == 0 {
+				res.FirstFailure = err.Error()
+			}
+			res.Failed++
+			if ordered {
+				break
+			}
+			continue
+		}
+		res.Written++
+	}
+	return res
+}
+
+// RunCommand supports the explain, dbStats and listCollections commands
+func (b *MemoryBackend) RunCommand(_ context.Context, db string, cmd any, result any) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, err := toDoc(cmd)
+	if err != nil {
+		return err
+	}
+	if len(c) == 0 {
+		return fmt.Errorf("command is empty")
+	}
+
+	var reply bson.D
+	switch c[0].Key {
+	case "explain":
+		reply, err = b.explain(db, c)
+	case "dbStats":
+		reply, err = b.dbStats(db)
+	case "listCollections":
+		reply, err = b.listCollections(db, c)
+	default:
+		return fmt.Errorf("command is not supported by the memory backend: %v", c)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := bson.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, result)
+}
+
+// explain builds the reply of the explain command. As there are no indexes every plan is a collection scan
+func (b *MemoryBackend) explain(db string, c bson.D) (bson.D, error) {
+	explained, ok := c[0].Value.(bson.D)
+	if !ok || len(explained) == 0 {
+		return nil, fmt.Errorf("explain requires a command to explain")
+	}
+	coll, _ := explained[0].Value.(string)
+
+	var returned []bson.D
+	var winningPlan bson.D
+	switch explained[0].Key {
+	case "find":
+		filter, _ := lookupField(explained, "filter")
+		var err error
+		if returned, err = b.matchingDocs(db, coll, filter); err != nil {
+			return nil, err
+		}
+		if limit, ok := lookupField(explained, "limit"); ok {
+			n, _ := toFloat(limit)
+			returned = skipAndLimit(returned, 0, int64(n))
+		}
+		winningPlan = bson.D{{Key: "stage", Value: "COLLSCAN"}, {Key: "filter", Value: filter}, {Key: "direction", Value: "forward"}}
+		if sort, ok := lookupField(explained, "sort"); ok {
+			winningPlan = bson.D{{Key: "stage", Value: "SORT"}, {Key: "sortPattern", Value: sort}, {Key: "inputStage", Value: winningPlan}}
+		}
+	case "aggregate":
+		pipeline, _ := lookupField(explained, "pipeline")
+		stages, err := toDocSlice(pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipeline: %w", err)
+		}
+		if returned, err = runPipeline(slices.Clone(b.databases[db][coll]), stages); err != nil {
+			return nil, err
+		}
+		winningPlan = bson.D{{Key: "stage", Value: "COLLSCAN"}, {Key: "direction", Value: "forward"}}
+	default:
+		return nil, fmt.Errorf("explain of %s is not supported by the memory backend", explained[0].Key)
+	}
+
+	return bson.D{
+		{Key: "queryPlanner", Value: bson.D{
+			{Key: "namespace", Value: db + "." + coll},
+			{Key: "winningPlan", Value: winningPlan},
+			{Key: "rejectedPlans", Value: bson.A{}},
+		}},
+		{Key: "executionStats", Value: bson.D{
+			{Key: "nReturned", Value: int64(len(returned))},
+			{Key: "executionTimeMillis", Value: int64(0)},
+			{Key: "totalKeysExamined", Value: int64(0)},
+			{Key: "totalDocsExamined", Value: int64(len(b.databases[db][coll]))},
+		}},
+		{Key: "ok", Value: 1.0},
+	}, nil
+}
+
+// dbStats builds the reply of the dbStats command. The storage size is the size of the data as nothing is compressed
+func (b *MemoryBackend) dbStats(db string) (bson.D, error) {
+	var objects, size, indexes int64
+	for coll, docs := range b.databases[db] {
+		objects += int64(len(docs))
+		size += dataSize(docs)
+		indexes += int64(1 + len(b.indexes[db][coll]))
+	}
+	return bson.D{
+		{Key: "db", Value: db},
+		{Key: "collections", Value: int64(len(b.databases[db]))},
+		{Key: "views", Value: int64(0)},
+		{Key: "objects", Value: objects},
+		{Key: "avgObjSize", Value: averageSize(size, objects)},
+		{Key: "dataSize", Value: size},
+		{Key: "storageSize", Value: size},
+		{Key: "indexes", Value: indexes},
+		{Key: "indexSize", Value: int64(0)},
+		{Key: "ok", Value: 1.0},
+	}, nil
+}
+
+// listCollections builds the reply of the listCollections command with every collection returned in the first batch
+func (b *MemoryBackend) listCollections(db string, c bson.D) (bson.D, error) {
+	filter, _ := lookupField(c, "filter")
+	f, err := toDoc(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	var names []string
+	for name := range b.databases[db] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	firstBatch := bson.A{}
+	for _, name := range names {
+		opts := b.options[db][name]
+		if opts == nil {
+			opts = bson.D{}
+		}
+		info := bson.D{
+			{Key: "name", Value: name},
+			{Key: "type", Value: collectionType(opts)},
+			{Key: "options", Value: opts},
+			{Key: "info", Value: bson.D{{Key: "readOnly", Value: false}}},
+		}
+		if matched, err := matchDoc(info, f); err != nil {
+			return nil, err
+		} else if matched {
+			firstBatch = append(firstBatch, info)
+		}
+	}
+	return bson.D{
+		{Key: "cursor", Value: bson.D{
+			{Key: "id", Value: int64(0)},
+			{Key: "ns", Value: db + ".$cmd.listCollections"},
+			{Key: "firstBatch", Value: firstBatch},
+		}},
+		{Key: "ok", Value: 1.0},
+	}, nil
+}
+
+// collStats builds the output of the $collStats stage with the storageStats option
+func (b *MemoryBackend) collStats(db, coll string) (bson.D, error) {
+	docs, ok := b.databases[db][coll]
+	if !ok {
+		return nil, fmt.Errorf("ns does not exist: %s.%s", db, coll)
+	}
+	size := dataSize(docs)
+	capped, _ := lookupField(b.options[db][coll], "capped")
+	return bson.D{
+		{Key: "ns", Value: db + "." + coll},
+		{Key: "storageStats", Value: bson.D{
+			{Key: "size", Value: size},
+			{Key: "count", Value: int64(len(docs))},
+			{Key: "avgObjSize", Value: averageSize(size, int64(len(docs)))},
+			{Key: "storageSize", Value: size},
+			{Key: "nindexes", Value: int64(1 + len(b.indexes[db][coll]))},
+			{Key: "totalIndexSize", Value: int64(0)},
+			{Key: "capped", Value: capped == true},
+		}},
+	}, nil
+}
+
+// collectionType returns the type listCollections reports for a collection created with opts
+func collectionType(opts bson.D) string {
+	if _, ok := lookupField(opts, "viewOn"); ok {
+		return "view"
+	}
+	if _, ok := lookupField(opts, "timeseries"); ok {
+		return "timeseries"
+	}
+	return "collection"
+}
+
+// dataSize returns the size of the docs once encoded as BSON
+func dataSize(docs []bson.D) int64 {
+	var size int64
+	for _, doc := range docs {
+		if data, err := bson.Marshal(doc); err == nil {
+			size += int64(len(data))
+		}
+	}
+	return size
+}
+
+func averageSize(size, count int64) int64 {
+	if count == 0 {
+		return 0
+	}
+	return size / count
+}
+
+func (b *MemoryBackend) ListIndexes(_ context.Context, db, coll string, results any) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.databases[db][coll]; !ok {
+		return fmt.Errorf("ns does not exist: %s.%s", db, coll)
+	}
+	specs := []bson.D{{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}}}
+	return decodeDocs(append(specs, b.indexes[db][coll]...), results)
+}
+
+// CreateIndex stores the spec of the index so that it is listed. The index is not used to enforce uniqueness
+func (b *MemoryBackend) CreateIndex(_ context.Context, db, coll string, spec any) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, err := toDoc(spec)
+	if err != nil {
+		return err
+	}
+	name, _ := lookupField(d, "name")
+	if name == "_id_" || b.indexPosition(db, coll, name) >= 0 {
+		return fmt.Errorf("an index with the name %v already exists", name)
+	}
+	b.ensureCollection(db, coll)
+	if _, ok := b.indexes[db]; !ok {
+		b.indexes[db] = make(map[string][]bson.D)
+	}
+	b.indexes[db][coll] = append(b.indexes[db][coll], append(bson.D{{Key: "v", Value: 2}}, d...))
+	return nil
+}
+
+func (b *MemoryBackend) DropIndex(_ context.Context, db, coll, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if name == "_id_" {
+		return fmt.Errorf("cannot drop _id index")
+	}
+	i := b.indexPosition(db, coll, name)
+	if i < 0 {
+		return fmt.Errorf("index not found with name [%s]", name)
+	}
+	b.indexes[db][coll] = slices.Delete(b.indexes[db][coll], i, i+1)
+	return nil
+}
+
+// indexPosition returns the position of the named index within the indexes of a collection or -1 if there is none
+func (b *MemoryBackend) indexPosition(db, coll string, name any) int {
+	return slices.IndexFunc(b.indexes[db][coll], func(spec bson.D) bool {
+		specName, _ := lookupField(spec, "name")
+		return specName == name
+	})
+}
+
+// CreateCollection only records the options so that they are reported by listCollections. They are not enforced
+func (b *MemoryBackend) CreateCollection(_ context.Context, db, coll string, opts bson.D) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.databases[db][coll]; ok {
+		return fmt.Errorf("collection %s.%s already exists", db, coll)
+	}
+	if viewOn, ok := lookupField(opts, "viewOn"); ok {
+		if _, ok := b.databases[db][fmt.Sprint(viewOn)]; !ok {
+			return fmt.Errorf("cannot create view %s.%s on %s as it does not exist", db, coll, viewOn)
+		}
+	}
+	b.ensureCollection(db, coll)
+	if len(opts) > 0 {
+		if _, ok := b.options[db]; !ok {
+			b.options[db] = make(map[string]bson.D)
+		}
+		b.options[db][coll] = opts
+	}
+	return nil
+}
+
+func (b *MemoryBackend) DropDatabase(_ context.Context, db string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.databases, db)
+	delete(b.indexes, db)
+	delete(b.options, db)
+	return nil
+}
+
+func (b *MemoryBackend) DropCollection(_ context.Context, db, coll string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.databases[db], coll)
+	delete(b.indexes[db], coll)
+	delete(b.options[db], coll)
+	if len(b.databases[db]) == 0 { // Like MongoDB, a database without collections no longer exists
+		delete(b.databases, db)
+	}
+	return nil
+}
+
+func (b *MemoryBackend) RenameCollection(_ context.Context, db, coll, toDb, toColl string, dropTarget bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	docs, ok := b.databases[db][coll]
+	if !ok {
+		return fmt.Errorf("source namespace %s.%s does not exist", db, coll)
+	}
+	if db == toDb && coll == toColl {
+		return fmt.Errorf("can't rename a collection to itself")
+	}
+	if _, ok := b.databases[toDb][toColl]; ok && !dropTarget {
+		return fmt.Errorf("target namespace %s.%s exists", toDb, toColl)
+	}
+	b.ensureCollection(toDb, toColl)
+	b.databases[toDb][toColl] = docs
+	moveSetting(b.indexes, db, coll, toDb, toColl)
+	moveSetting(b.options, db, coll, toDb, toColl)
+	delete(b.databases[db], coll)
+	if len(b.databases[db]) == 0 {
+		delete(b.databases, db)
+	}
+	return nil
+}
+
+// moveSetting moves whatever is kept for a collection in one of the per collection maps, such as its indexes, to
+// another collection replacing anything the target had
+func moveSetting[T any](settings map[string]map[string]T, db, coll, toDb, toColl string) {
+	setting, ok := settings[db][coll]
+	delete(settings[toDb], toColl)
+	if !ok {
+		return
+	}
+	delete(settings[db], coll)
+	if _, ok := settings[toDb]; !ok {
+		settings[toDb] = make(map[string]T)
+	}
+	settings[toDb][toColl] = setting
+}
+
+// WithTransaction snapshots the docs of every collection before running fn and restores them if fn fails. Unlike
+// MongoDB, the writes made by fn are visible to other operations before it returns and their change events are
+// published even if the transaction is aborted
+func (b *MemoryBackend) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	snapshot := make(map[string]map[string][]bson.D, len(b.databases))
+	for db, colls := range b.databases {
+		snapshot[db] = make(map[string][]bson.D, len(colls))
+		for coll, docs := range colls {
+			snapshot[db][coll] = slices.Clone(docs) // Writes replace docs rather than modifying them
+		}
+	}
+	b.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		b.mu.Lock()
+		b.databases = snapshot
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (b *MemoryBackend) ensureCollection(db, coll string) {
+	if _, ok := b.databases[db]; !ok {
+		b.databases[db] = make(map[string][]bson.D)
+	}
+	if _, ok := b.databases[db][coll]; !ok {
+		b.databases[db][coll] = []bson.D{}
+	}
+}
+
+// matchingDocs returns every doc within a collection that matches the filter
+func (b *MemoryBackend) matchingDocs(db, coll string, filter any) ([]bson.D, error) {
+	f, err := toDoc(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	var docs []bson.D
+	for _, doc := range b.databases[db][coll] {
+		matched, err := matchDoc(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// firstMatchIndex returns the index of the first doc matching the filter or -1 if there is no match
+func (b *MemoryBackend) firstMatchIndex(db, coll string, filter any) (int, error) {
+	f, err := toDoc(filter)
+	if err != nil {
+		return -1, fmt.Errorf("invalid filter: %w", err)
+	}
+	for i, doc := range b.databases[db][coll] {
+		matched, err := matchDoc(doc, f)
+		if err != nil {
+			return -1, err
+		}
+		if matched {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// toDoc converts any value the driver would accept as a document into a bson.D. Round tripping through
+// bson also gives callers a copy so that stored docs can not be modified from outside the backend
+func toDoc(v any) (bson.D, error) {
+	if v == nil {
+		return bson.D{}, nil
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var d bson.D
+	if err := bson.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// toDocSlice converts a pipeline or any other array of documents into a slice of bson.D
+func toDocSlice(v any) ([]bson.D, error) {
+	t, data, err := bson.MarshalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.D
+	if err := bson.UnmarshalValue(t, data, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// decodeDocs decodes docs into results the same way that a driver cursor's All method would
+func decodeDocs(docs []bson.D, results any) error {
+	if docs == nil {
+		docs = []bson.D{}
+	}
+	t, data, err := bson.MarshalValue(docs)
+	if err != nil {
+		return err
+	}
+	return bson.UnmarshalValue(t, data, results)
+}
+
+// SetConcerns has no effect as the memory backend has no replica set members to read from or acknowledge writes
+func (b *MemoryBackend) SetConcerns(Concerns) {}
@@ -0,0 +1,118 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func newSeededBackend(t *testing.T) *MemoryBackend {
+	t.Helper()
+	b := NewMemoryBackend()
+	docs := []bson.D{
+		{{Key: "_id", Value: 1}, {Key: "name", Value: "Kevin"}, {Key: "age", Value: 30}, {Key: "tags", Value: bson.A{"admin", "dev"}}},
+		{{Key: "_id", Value: 2}, {Key: "name", Value: "Sally"}, {Key: "age", Value: int64(25)}, {Key: "address", Value: bson.D{{Key: "city", Value: "Boston"}}}},
+		{{Key: "_id", Value: 3}, {Key: "name", Value: "George"}, {Key: "age", Value: 41.5}},
+	}
+	for _, doc := range docs {
+		if _, err := b.InsertOne(context.Background(), "shop", "users", doc); err != nil {
+			t.Fatalf("failed to seed backend: %v", err)
+		}
+	}
+	return b
+}
+
+func TestMemoryBackendFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  bson.D
+		opts    FindOptions
+		wantIds []int32
+		wantErr bool
+	}{
+		{name: "empty filter", filter: bson.D{}, wantIds: []int32{1, 2, 3}},
+		{name: "equality", filter: bson.D{{Key: "name", Value: "Sally"}}, wantIds: []int32{2}},
+		{name: "numbers compare across types", filter: bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 30}}}}, wantIds: []int32{1, 3}},
+		{name: "array element equality", filter: bson.D{{Key: "tags", Value: "dev"}}, wantIds: []int32{1}},
+		{name: "dotted path", filter: bson.D{{Key: "address.city", Value: "Boston"}}, wantIds: []int32{2}},
+		{name: "exists", filter: bson.D{{Key: "address", Value: bson.D{{Key: "$exists", Value: false}}}}, wantIds: []int32{1, 3}},
+		{name: "in", filter: bson.D{{Key: "name", Value: bson.D{{Key: "$in", Value: bson.A{"Kevin", "George"}}}}}, wantIds: []int32{1, 3}},
+		{name: "or", filter: bson.D{{Key: "$or", Value: bson.A{bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "_id", Value: 3}}}}}, wantIds: []int32{1, 3}},
+		{name: "sort descending", filter: bson.D{}, opts: FindOptions{Sort: bson.D{{Key: "age", Value: -1}}}, wantIds: []int32{3, 1, 2}},
+		{name: "skip and limit", filter: bson.D{}, opts: FindOptions{Skip: 1, Limit: 1}, wantIds: []int32{2}},
+		{name: "unsupported operator", filter: bson.D{{Key: "name", Value: bson.D{{Key: "$regex", Value: "K"}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newSeededBackend(t)
+			var results []bson.M
+			err := b.Find(context.Background(), "shop", "users", tt.filter, tt.opts, &results)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var gotIds []int32
+			for _, doc := range results {
+				gotIds = append(gotIds, doc["_id"].(int32))
+			}
+			if len(gotIds) != len(tt.wantIds) {
+				t.Fatalf("Find() returned ids %v, want %v", gotIds, tt.wantIds)
+			}
+			for i := range gotIds {
+				if gotIds[i] != tt.wantIds[i] {
+					t.Fatalf("Find() returned ids %v, want %v", gotIds, tt.wantIds)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryBackendWrites(t *testing.T) {
+	ctx := context.Background()
+	b := newSeededBackend(t)
+
+	if _, err := b.InsertOne(ctx, "shop", "users", bson.M{"_id": 1}); err == nil {
+		t.Errorf("InsertOne() with a duplicate _id should fail")
+	}
+	if matched, err := b.ReplaceOne(ctx, "shop", "users", bson.M{"_id": 2}, bson.M{"name": "Sal"}); err != nil || matched != 1 {
+		t.Errorf("ReplaceOne() = %d, %v, want 1, nil", matched, err)
+	}
+	if count, _ := b.CountDocuments(ctx, "shop", "users", bson.M{"name": "Sal", "_id": 2}, 0); count != 1 {
+		t.Errorf("ReplaceOne() should keep the original _id")
+	}
+	if deleted, err := b.DeleteOne(ctx, "shop", "users", bson.M{"name": "Nobody"}); err != nil || deleted != 0 {
+		t.Errorf("DeleteOne() = %d, %v, want 0, nil", deleted, err)
+	}
+	if count, _ := b.CountDocuments(ctx, "shop", "users", bson.M{}, 2); count != 2 {
+		t.Errorf("CountDocuments() should stop at its limit, got %d", count)
+	}
+	if err := b.DropCollection(ctx, "shop", "users"); err != nil {
+		t.Fatalf("DropCollection() error = %v", err)
+	}
+	if dbs, _ := b.ListDatabaseNames(ctx); len(dbs) != 0 {
+		t.Errorf("a database without collections should not be listed, got %v", dbs)
+	}
+}
+
+func TestMemoryBackendAggregate(t *testing.T) {
+	b := newSeededBackend(t)
+	pipeline := []bson.D{
+		{{Key: "$match", Value: bson.D{{Key: "age", Value: bson.D{{Key: "$lt", Value: 40}}}}}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "docs", Value: bson.A{bson.D{{Key: "$skip", Value: 1}}, bson.D{{Key: "$limit", Value: 5}}}},
+			{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "count"}}}},
+		}}},
+	}
+	var results []struct {
+		Docs  []bson.M `bson:"docs"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := b.Aggregate(context.Background(), "shop", "users", pipeline, &results); err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if len(results) != 1 || len(results[0].Docs) != 1 || results[0].Total[0].Count != 2 {
+		t.Errorf("Aggregate() = %+v, want one doc and a total of 2", results)
+	}
+}
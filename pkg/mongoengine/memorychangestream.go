@@ -0,0 +1,102 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"sync"
+	"time"
+)
+
+const memoryChangeStreamBuffer = 100 // Events received by a stream that is not being read beyond this are dropped
+
+// memoryChangeStream receives the events published by the writes made to a MemoryBackend collection
+type memoryChangeStream struct {
+	backend *MemoryBackend
+	ns      string
+	events  chan bson.D
+	current bson.D
+
+	mu        sync.Mutex
+	err       error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *MemoryBackend) Watch(_ context.Context, db, coll string) (ChangeStream, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stream := &memoryChangeStream{
+		backend: b,
+		ns:      db + "." + coll,
+		events:  make(chan bson.D, memoryChangeStreamBuffer),
+		done:    make(chan struct{}),
+	}
+	b.watchers[stream.ns] = append(b.watchers[stream.ns], stream)
+	return stream, nil
+}
+
+// publish sends a change event in the format of a MongoDB change stream to every stream watching the collection.
+// fullDocument is omitted when nil. The caller must hold b.mu
+func (b *MemoryBackend) publish(db, coll, operationType string, id any, fullDocument bson.D) {
+	b.eventCounter++
+	now := time.Now()
+	event := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: b.eventCounter}}},
+		{Key: "operationType", Value: operationType},
+		{Key: "clusterTime", Value: bson.Timestamp{T: uint32(now.Unix()), I: uint32(b.eventCounter)}},
+		{Key: "wallTime", Value: bson.NewDateTimeFromTime(now)},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: db}, {Key: "coll", Value: coll}}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: id}}},
+	}
+	if fullDocument != nil {
+		event = append(event, bson.E{Key: "fullDocument", Value: fullDocument})
+	}
+	for _, stream := range b.watchers[db+"."+coll] {
+		select {
+		case stream.events <- event:
+		default: // The stream is not being read. Blocking here would block every write to the backend
+		}
+	}
+}
+
+func (s *memoryChangeStream) Next(ctx context.Context) bool {
+	select {
+	case event := <-s.events:
+		s.current = event
+		return true
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.err = ctx.Err()
+		return false
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *memoryChangeStream) Decode(val any) error {
+	data, err := bson.Marshal(s.current)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, val)
+}
+
+func (s *memoryChangeStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *memoryChangeStream) Close(_ context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.backend.mu.Lock()
+		defer s.backend.mu.Unlock()
+		s.backend.watchers[s.ns] = slices.DeleteFunc(s.backend.watchers[s.ns], func(stream *memoryChangeStream) bool {
+			return stream == s
+		})
+	})
+	return nil
+}
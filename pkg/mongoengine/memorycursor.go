@@ -0,0 +1,47 @@
+package mongoengine
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memoryCursor returns the documents of a MemoryBackend query one at a time. The documents are all fetched when
+// the cursor is opened
+type memoryCursor struct {
+	docs    []bson.D
+	current bson.D
+	err     error
+}
+
+func (c *memoryCursor) Next(ctx context.Context) bool {
+	if c.err == nil {
+		c.err = ctx.Err()
+	}
+	if c.err != nil || len(c.docs) == 0 {
+		c.current = nil
+		return false
+	}
+	c.current, c.docs = c.docs[0], c.docs[1:]
+	return true
+}
+
+func (c *memoryCursor) Decode(val any) error {
+	if c.current == nil {
+		return fmt.Errorf("there is no current document to decode")
+	}
+	data, err := bson.Marshal(c.current)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, val)
+}
+
+func (c *memoryCursor) Err() error {
+	return c.err
+}
+
+func (c *memoryCursor) Close(_ context.Context) error {
+	c.docs, c.current = nil, nil
+	return nil
+}
@@ -0,0 +1,510 @@
+package mongoengine
+
+// The functions contained in this file evaluate queries against the docs stored by the MemoryBackend.
+// Only a commonly used subset of the MongoDB query language is supported.
+
+import (
+	"bytes"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// matchDoc reports if doc matches every condition within the filter
+func matchDoc(doc, filter bson.D) (bool, error) {
+	for _, cond := range filter {
+		var matched bool
+		var err error
+		switch cond.Key {
+		case "$and", "$or", "$nor":
+			matched, err = matchLogical(doc, cond.Key, cond.Value)
+		case "$expr":
+			var val any
+			val, _, err = evalExpression(doc, cond.Value)
+			matched = isTruthy(val)
+		default:
+			if strings.HasPrefix(cond.Key, "$") {
+				return false, fmt.Errorf("unsupported top level operator %s", cond.Key)
+			}
+			matched, err = matchField(doc, cond.Key, cond.Value)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.D, op string, val any) (bool, error) {
+	subFilters, ok := val.(bson.A)
+	if !ok || len(subFilters) == 0 {
+		return false, fmt.Errorf("%s must be a nonempty array", op)
+	}
+	for _, sub := range subFilters {
+		subFilter, ok := sub.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("%s entries must be objects", op)
+		}
+		matched, err := matchDoc(doc, subFilter)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// matchField evaluates a single field condition. The condition is either a value that the field must equal
+// or a document of query operators such as {"$gt": 5}
+func matchField(doc bson.D, path string, cond any) (bool, error) {
+	val, exists := lookupPath(doc, path)
+	ops, isOps := cond.(bson.D)
+	if !isOps || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		return fieldEquals(val, exists, cond), nil
+	}
+
+	for _, op := range ops {
+		var matched bool
+		switch op.Key {
+		case "$eq":
+			matched = fieldEquals(val, exists, op.Value)
+		case "$ne":
+			matched = !fieldEquals(val, exists, op.Value)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = exists && fieldCompares(val, op.Key, op.Value)
+		case "$in", "$nin":
+			options, ok := op.Value.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("%s needs an array", op.Key)
+			}
+			matched = slices.ContainsFunc(options, func(o any) bool { return fieldEquals(val, exists, o) })
+			if op.Key == "$nin" {
+				matched = !matched
+			}
+		case "$exists":
+			matched = exists == isTruthy(op.Value)
+		case "$not":
+			notOps, ok := op.Value.(bson.D)
+			if !ok {
+				return false, fmt.Errorf("$not needs an object")
+			}
+			notMatched, err := matchField(doc, path, notOps)
+			if err != nil {
+				return false, err
+			}
+			matched = !notMatched
+		default:
+			return false, fmt.Errorf("unsupported query operator %s", op.Key)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fieldEquals follows MongoDB's equality semantics where a missing field equals null and an array
+// field matches if either the whole array or one of its elements is equal
+func fieldEquals(val any, exists bool, target any) bool {
+	if !exists {
+		return target == nil
+	}
+	if valuesEqual(val, target) {
+		return true
+	}
+	if arr, ok := val.(bson.A); ok {
+		return slices.ContainsFunc(arr, func(elem any) bool { return valuesEqual(elem, target) })
+	}
+	return false
+}
+
+func fieldCompares(val any, op string, target any) bool {
+	candidates := []any{val}
+	if arr, ok := val.(bson.A); ok {
+		candidates = arr
+	}
+	for _, c := range candidates {
+		cmp, ok := compareValues(c, target)
+		if !ok {
+			continue
+		}
+		if (op == "$gt" && cmp > 0) || (op == "$gte" && cmp >= 0) || (op == "$lt" && cmp < 0) || (op == "$lte" && cmp <= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField returns the value of a top level field
+func lookupField(doc bson.D, key string) (any, bool) {
+	for _, elem := range doc {
+		if elem.Key == key {
+			return elem.Value, true
+		}
+	}
+	return nil, false
+}
+
+// lookupPath returns the value of a dotted field path such as address.city. Arrays along the path are
+// traversed either by index or by collecting the field from every embedded document
+func lookupPath(doc bson.D, path string) (any, bool) {
+	return lookupValuePath(doc, strings.Split(path, "."))
+}
+
+func lookupValuePath(val any, parts []string) (any, bool) {
+	if len(parts) == 0 {
+		return val, true
+	}
+	switch v := val.(type) {
+	case bson.D:
+		next, ok := lookupField(v, parts[0])
+		if !ok {
+			return nil, false
+		}
+		return lookupValuePath(next, parts[1:])
+	case bson.A:
+		var idx int
+		if _, err := fmt.Sscanf(parts[0], "%d", &idx); err == nil && idx >= 0 && idx < len(v) {
+			return lookupValuePath(v[idx], parts[1:])
+		}
+		var collected bson.A
+		for _, elem := range v {
+			if found, ok := lookupValuePath(elem, parts); ok {
+				collected = append(collected, found)
+			}
+		}
+		return collected, len(collected) > 0
+	default:
+		return nil, false
+	}
+}
+
+func isTruthy(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case nil:
+		return false
+	default:
+		if f, ok := toFloat(v); ok {
+			return f != 0
+		}
+		return true
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// valuesEqual compares two bson values. Numbers are compared by value regardless of their type while
+// embedded documents must have the same fields in the same order just like they would in MongoDB
+func valuesEqual(a, b any) bool {
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp == 0
+	}
+	switch av := a.(type) {
+	case bson.D:
+		bv, ok := b.(bson.D)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i].Key != bv[i].Key || !valuesEqual(av[i].Value, bv[i].Value) {
+				return false
+			}
+		}
+		return true
+	case bson.A:
+		bv, ok := b.(bson.A)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return b == nil
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// compareValues orders two scalar values of comparable types. ok is false if the values can not be ordered
+func compareValues(a, b any) (int, bool) {
+	if af, aIsNum := toFloat(a); aIsNum {
+		if bf, bIsNum := toFloat(b); bIsNum {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+		return 0, false
+	}
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, true
+			case !av:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	case bson.ObjectID:
+		if bv, ok := b.(bson.ObjectID); ok {
+			return bytes.Compare(av[:], bv[:]), true
+		}
+	case bson.DateTime:
+		if bv, ok := b.(bson.DateTime); ok {
+			switch {
+			case av < bv:
+				return -1, true
+			case av > bv:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// sortDocs sorts docs in place given a sort specification such as {"age": -1, "name": 1}
+func sortDocs(docs []bson.D, sortSpec bson.D) error {
+	for _, s := range sortSpec {
+		if dir, ok := toFloat(s.Value); !ok || (dir != 1 && dir != -1) {
+			return fmt.Errorf("sort direction for %s must be 1 or -1", s.Key)
+		}
+	}
+	slices.SortStableFunc(docs, func(a, b bson.D) int {
+		for _, s := range sortSpec {
+			dir, _ := toFloat(s.Value)
+			av, _ := lookupPath(a, s.Key)
+			bv, _ := lookupPath(b, s.Key)
+			if cmp := sortCompare(av, bv); cmp != 0 {
+				return cmp * int(dir)
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// sortCompare orders values of differing types by placing missing values first like MongoDB does
+func sortCompare(a, b any) int {
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp
+	}
+	switch {
+	case a == nil && b != nil:
+		return -1
+	case a != nil && b == nil:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func skipAndLimit(docs []bson.D, skip, limit int64) []bson.D {
+	if skip >= int64(len(docs)) {
+		return nil
+	}
+	docs = docs[skip:]
+	if limit > 0 && limit < int64(len(docs)) {
+		docs = docs[:limit]
+	}
+	return docs
+}
+
+// projectDocs applies an inclusion or exclusion projection on the top level fields of each doc
+func projectDocs(docs []bson.D, projection bson.D) ([]bson.D, error) {
+	includeId := true
+	var inclusion, exclusion bool
+	fields := make(map[string]bool)
+	for _, p := range projection {
+		included := isTruthy(p.Value)
+		if p.Key == "_id" {
+			includeId = included
+			continue
+		}
+		fields[p.Key] = true
+		inclusion = inclusion || included
+		exclusion = exclusion || !included
+	}
+	if inclusion && exclusion {
+		return nil, fmt.Errorf("cannot mix inclusion and exclusion in a projection")
+	}
+
+	projected := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		var newDoc bson.D
+		for _, elem := range doc {
+			var keep bool
+			if elem.Key == "_id" {
+				keep = includeId
+			} else if inclusion {
+				keep = fields[elem.Key]
+			} else {
+				keep = !fields[elem.Key]
+			}
+			if keep {
+				newDoc = append(newDoc, elem)
+			}
+		}
+		projected = append(projected, newDoc)
+	}
+	return projected, nil
+}
+
+// runPipeline evaluates the supported aggregation stages one after another
+func runPipeline(docs []bson.D, stages []bson.D) ([]bson.D, error) {
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage must contain exactly one field")
+		}
+		var err error
+		name, spec := stage[0].Key, stage[0].Value
+		switch name {
+		case "$match":
+			filter, ok := spec.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$match must be an object")
+			}
+			var matched []bson.D
+			for _, doc := range docs {
+				ok, err := matchDoc(doc, filter)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					matched = append(matched, doc)
+				}
+			}
+			docs = matched
+		case "$sort":
+			sortSpec, ok := spec.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$sort must be an object")
+			}
+			err = sortDocs(docs, sortSpec)
+		case "$skip", "$limit", "$sample":
+			var n float64
+			if name == "$sample" {
+				size, _ := spec.(bson.D)
+				sizeVal, _ := lookupField(size, "size")
+				n, _ = toFloat(sizeVal)
+			} else {
+				n, _ = toFloat(spec)
+			}
+			if name == "$skip" {
+				docs = skipAndLimit(docs, int64(n), 0)
+			} else { // The sample is not random so that results are predictable in tests
+				docs = skipAndLimit(docs, 0, int64(n))
+			}
+		case "$project":
+			projection, ok := spec.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$project must be an object")
+			}
+			docs, err = projectDocs(docs, projection)
+		case "$set", "$addFields", "$unset":
+			docs, err = updateDocs(docs, name, spec)
+		case "$count":
+			field, ok := spec.(string)
+			if !ok {
+				return nil, fmt.Errorf("$count must be a string")
+			}
+			if len(docs) > 0 {
+				docs = []bson.D{{{Key: field, Value: int32(len(docs))}}}
+			}
+		case "$facet":
+			docs, err = runFacet(docs, spec)
+		default:
+			return nil, fmt.Errorf("unsupported aggregation stage %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// updateDocs applies a $set, $addFields or $unset stage to each doc
+func updateDocs(docs []bson.D, name string, spec any) ([]bson.D, error) {
+	fields, isDoc := spec.(bson.D)
+	if name != "$unset" && !isDoc {
+		return nil, fmt.Errorf("%s must be an object", name)
+	}
+	updated := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		var err error
+		if name == "$unset" {
+			doc, err = unsetFields(doc, spec)
+		} else {
+			doc, err = addFields(doc, fields)
+		}
+		if err != nil {
+			return nil, err
+		}
+		updated = append(updated, doc)
+	}
+	return updated, nil
+}
+
+func runFacet(docs []bson.D, spec any) ([]bson.D, error) {
+	facets, ok := spec.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$facet must be an object")
+	}
+	var result bson.D
+	for _, facet := range facets {
+		subStages, err := toDocSlice(facet.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $facet %s: %w", facet.Key, err)
+		}
+		subDocs, err := runPipeline(slices.Clone(docs), subStages)
+		if err != nil {
+			return nil, err
+		}
+		arr := bson.A{}
+		for _, d := range subDocs {
+			arr = append(arr, d)
+		}
+		result = append(result, bson.E{Key: facet.Key, Value: arr})
+	}
+	return []bson.D{result}, nil
+}
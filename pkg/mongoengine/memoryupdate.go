@@ -0,0 +1,331 @@
+package mongoengine
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"math"
+	"slices"
+	"strings"
+)
+
+// UpdateMany supports the $set, $unset, $inc and $rename operators as well as pipeline updates made of the
+// stages understood by runPipeline
+func (b *MemoryBackend) UpdateMany(ctx context.Context, db, coll string, filter, update any) (int64, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, err := toDoc(filter)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid filter: %w", err)
+	}
+	docs := b.databases[db][coll]
+	updated := make(map[int]bson.D)
+	var matched int64
+	for i, doc := range docs {
+		ok, err := matchDoc(doc, f)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !ok {
+			continue
+		}
+		matched++
+		newDoc, err := applyUpdate(doc, update)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !valuesEqual(doc, newDoc) {
+			updated[i] = newDoc
+		}
+	}
+
+	// Like MongoDB, every matching doc is checked before any is written as the whole update fails on an error
+	for i, newDoc := range updated {
+		docs[i] = newDoc
+		id, _ := lookupField(newDoc, "_id")
+		b.publish(db, coll, "update", id, newDoc)
+	}
+	return matched, int64(len(updated)), nil
+}
+
+// applyUpdate returns a copy of doc with an update document or pipeline applied
+func applyUpdate(doc bson.D, update any) (bson.D, error) {
+	var newDoc bson.D
+	if operators, ok := update.(bson.D); ok {
+		var err error
+		if newDoc, err = applyUpdateOperators(doc, operators); err != nil {
+			return nil, err
+		}
+	} else {
+		stages, err := toDocSlice(update)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update: %w", err)
+		}
+		updated, err := runPipeline([]bson.D{doc}, stages)
+		if err != nil {
+			return nil, err
+		}
+		if len(updated) != 1 {
+			return nil, fmt.Errorf("an update pipeline must return exactly one document")
+		}
+		newDoc = updated[0]
+	}
+
+	oldId, _ := lookupField(doc, "_id")
+	if newId, ok := lookupField(newDoc, "_id"); !ok || !valuesEqual(oldId, newId) {
+		return nil, fmt.Errorf("the (immutable) field '_id' was found to have been altered")
+	}
+	return newDoc, nil
+}
+
+func applyUpdateOperators(doc bson.D, operators bson.D) (bson.D, error) {
+	if len(operators) == 0 || !strings.HasPrefix(operators[0].Key, "$") {
+		return nil, fmt.Errorf("an update document must only contain update operators")
+	}
+	for _, op := range operators {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object", op.Key)
+		}
+		for _, field := range fields {
+			path := strings.Split(field.Key, ".")
+			switch op.Key {
+			case "$set":
+				doc = setPath(doc, path, field.Value)
+			case "$unset":
+				doc = unsetPath(doc, path)
+			case "$inc":
+				current, exists := lookupPath(doc, field.Key)
+				if !exists {
+					current = int32(0)
+				}
+				sum, err := addNumbers(current, field.Value)
+				if err != nil {
+					return nil, fmt.Errorf("cannot apply $inc to %s: %w", field.Key, err)
+				}
+				doc = setPath(doc, path, sum)
+			case "$rename":
+				newName, ok := field.Value.(string)
+				if !ok {
+					return nil, fmt.Errorf("the new name of %s must be a string", field.Key)
+				}
+				if val, exists := lookupPath(doc, field.Key); exists {
+					doc = setPath(unsetPath(doc, path), strings.Split(newName, "."), val)
+				}
+			default:
+				return nil, fmt.Errorf("unsupported update operator %s", op.Key)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// setPath returns a copy of doc with the field at path set to val. Missing embedded documents are created
+func setPath(doc bson.D, path []string, val any) bson.D {
+	doc = slices.Clone(doc)
+	i := slices.IndexFunc(doc, func(elem bson.E) bool { return elem.Key == path[0] })
+	if len(path) > 1 {
+		embedded := bson.D{}
+		if i >= 0 {
+			embedded, _ = doc[i].Value.(bson.D)
+		}
+		val = setPath(embedded, path[1:], val)
+	}
+	if i < 0 {
+		return append(doc, bson.E{Key: path[0], Value: val})
+	}
+	doc[i].Value = val
+	return doc
+}
+
+// unsetPath returns a copy of doc without the field at path
+func unsetPath(doc bson.D, path []string) bson.D {
+	i := slices.IndexFunc(doc, func(elem bson.E) bool { return elem.Key == path[0] })
+	if i < 0 {
+		return doc
+	}
+	if len(path) == 1 {
+		return slices.Delete(slices.Clone(doc), i, i+1)
+	}
+	embedded, ok := doc[i].Value.(bson.D)
+	if !ok {
+		return doc
+	}
+	doc = slices.Clone(doc)
+	doc[i].Value = unsetPath(embedded, path[1:])
+	return doc
+}
+
+// addNumbers adds two numbers keeping the narrowest type that holds the result, as MongoDB does
+func addNumbers(a, b any) (any, error) {
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+	if !okA || !okB {
+		return nil, fmt.Errorf("cannot add %v and %v as they are not both numbers", a, b)
+	}
+	_, floatA := a.(float64)
+	_, floatB := b.(float64)
+	switch sum := x + y; {
+	case floatA || floatB:
+		return sum, nil
+	case sum >= math.MinInt32 && sum <= math.MaxInt32 && !isInt64(a) && !isInt64(b):
+		return int32(sum), nil
+	default:
+		return int64(sum), nil
+	}
+}
+
+func isInt64(v any) bool {
+	_, ok := v.(int64)
+	return ok
+}
+
+// evalExpression evaluates an aggregation expression against doc. Field paths, $$ROOT, $literal, $add, $ifNull,
+// $eq, $size and $objectToArray are supported. exists is false when the expression refers to a missing field
+func evalExpression(doc bson.D, expr any) (val any, exists bool, err error) {
+	switch e := expr.(type) {
+	case string:
+		if e == "$$ROOT" {
+			return doc, true, nil
+		}
+		if strings.HasPrefix(e, "$") {
+			val, exists = lookupPath(doc, e[1:])
+			return val, exists, nil
+		}
+		return e, true, nil
+	case bson.A:
+		evaluated := bson.A{}
+		for _, elem := range e {
+			v, _, err := evalExpression(doc, elem)
+			if err != nil {
+				return nil, false, err
+			}
+			evaluated = append(evaluated, v)
+		}
+		return evaluated, true, nil
+	case bson.D:
+		if len(e) == 1 && strings.HasPrefix(e[0].Key, "$") {
+			return evalOperator(doc, e[0].Key, e[0].Value)
+		}
+		evaluated := bson.D{}
+		for _, field := range e {
+			v, fieldExists, err := evalExpression(doc, field.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			if fieldExists {
+				evaluated = append(evaluated, bson.E{Key: field.Key, Value: v})
+			}
+		}
+		return evaluated, true, nil
+	default:
+		return expr, true, nil
+	}
+}
+
+func evalOperator(doc bson.D, op string, arg any) (any, bool, error) {
+	if op == "$literal" {
+		return arg, true, nil
+	}
+	args, ok := arg.(bson.A)
+	if !ok {
+		args = bson.A{arg}
+	}
+	switch op {
+	case "$add":
+		var sum any = int32(0)
+		for _, a := range args {
+			v, exists, err := evalExpression(doc, a)
+			if err != nil {
+				return nil, false, err
+			}
+			if !exists || v == nil {
+				return nil, true, nil
+			}
+			if sum, err = addNumbers(sum, v); err != nil {
+				return nil, false, err
+			}
+		}
+		return sum, true, nil
+	case "$ifNull":
+		for _, a := range args {
+			v, exists, err := evalExpression(doc, a)
+			if err != nil {
+				return nil, false, err
+			}
+			if exists && v != nil {
+				return v, true, nil
+			}
+		}
+		return nil, true, nil
+	case "$eq":
+		if len(args) != 2 {
+			return nil, false, fmt.Errorf("$eq needs 2 arguments")
+		}
+		evaluated, _, err := evalExpression(doc, args)
+		if err != nil {
+			return nil, false, err
+		}
+		operands := evaluated.(bson.A)
+		return valuesEqual(operands[0], operands[1]), true, nil
+	case "$size":
+		v, _, err := evalExpression(doc, args[0])
+		if err != nil {
+			return nil, false, err
+		}
+		arr, ok := v.(bson.A)
+		if !ok {
+			return nil, false, fmt.Errorf("the argument to $size must be an array")
+		}
+		return int32(len(arr)), true, nil
+	case "$objectToArray":
+		v, _, err := evalExpression(doc, args[0])
+		if err != nil {
+			return nil, false, err
+		}
+		obj, ok := v.(bson.D)
+		if !ok {
+			return nil, false, fmt.Errorf("the argument to $objectToArray must be an object")
+		}
+		pairs := bson.A{}
+		for _, field := range obj {
+			pairs = append(pairs, bson.D{{Key: "k", Value: field.Key}, {Key: "v", Value: field.Value}})
+		}
+		return pairs, true, nil
+	default:
+		return nil, false, fmt.Errorf("unsupported expression operator %s", op)
+	}
+}
+
+// addFields evaluates each field of a $set or $addFields stage. Fields whose expression is missing are not added
+func addFields(doc bson.D, spec bson.D) (bson.D, error) {
+	for _, field := range spec {
+		val, exists, err := evalExpression(doc, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			doc = setPath(doc, strings.Split(field.Key, "."), val)
+		}
+	}
+	return doc, nil
+}
+
+// unsetFields removes the fields of an $unset stage, which is either a single field or an array of them
+func unsetFields(doc bson.D, spec any) (bson.D, error) {
+	fields, ok := spec.(bson.A)
+	if !ok {
+		fields = bson.A{spec}
+	}
+	for _, field := range fields {
+		name, ok := field.(string)
+		if !ok {
+			return nil, fmt.Errorf("$unset must be a field name or an array of them")
+		}
+		doc = unsetPath(doc, strings.Split(name, "."))
+	}
+	return doc, nil
+}
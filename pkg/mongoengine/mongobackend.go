@@ -0,0 +1,319 @@
+package mongoengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"strings"
+	"sync"
+)
+
+// MongoBackend implements Backend using a connected MongoDB client
+type MongoBackend struct {
+	client *mongo.Client
+
+	mu       sync.Mutex
+	concerns Concerns // Applied to every Database handle
+}
+
+func NewMongoBackend(client *mongo.Client) *MongoBackend {
+	return &MongoBackend{client: client}
+}
+
+// connectMongoBackend connects to the server of a connection string, such as the target of a copy, and checks that
+// it can be reached. The returned func disconnects from the server
+func connectMongoBackend(ctx context.Context, connectionString string) (Backend, func(), error) {
+	if !strings.Contains(connectionString, "://") {
+		connectionString = "mongodb://" + connectionString
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(connectionString).SetTimeout(Timeout))
+	if err != nil {
+		return nil, nil, err
+	}
+	disconnect := func() { _ = client.Disconnect(context.Background()) }
+	if err := client.Ping(ctx, nil); err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	return NewMongoBackend(client), disconnect, nil
+}
+
+func (b *MongoBackend) SetConcerns(c Concerns) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.concerns = c
+}
+
+// database returns the handle of a database that its collections and commands inherit the concerns from
+func (b *MongoBackend) database(db string) *mongo.Database {
+	b.mu.Lock()
+	c := b.concerns
+	b.mu.Unlock()
+	opts := options.Database()
+	if c.ReadPreference != nil {
+		opts.SetReadPreference(c.ReadPreference)
+	}
+	if c.ReadConcern != "" {
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: c.ReadConcern})
+	}
+	if wc := c.WriteConcern(); wc != nil {
+		opts.SetWriteConcern(wc)
+	}
+	return b.client.Database(db, opts)
+}
+
+// errWriteTimedOut is the cause of a write abandoned after the write timeout. The driver no longer sends wtimeout to
+// the server so the timeout only stops the client from waiting and the server may still apply the write
+var errWriteTimedOut = errors.New("the write timed out, its outcome is unknown and it may have been applied")
+
+// writeContext bounds how long the client waits for a write by the write timeout of the concerns
+func (b *MongoBackend) writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	b.mu.Lock()
+	writeTimeout := b.concerns.WriteTimeout
+	b.mu.Unlock()
+	if writeTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, writeTimeout, errWriteTimedOut)
+}
+
+// writeErr tells that a write whose context was given by writeContext timed out, as it may have been applied
+func writeErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), errWriteTimedOut) {
+		return fmt.Errorf("%w: %w", errWriteTimedOut, err)
+	}
+	return err
+}
+
+func (b *MongoBackend) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	return b.client.ListDatabaseNames(ctx, bson.D{})
+}
+
+func (b *MongoBackend) ListCollectionNames(ctx context.Context, db string) ([]string, error) {
+	return b.database(db).ListCollectionNames(ctx, bson.D{})
+}
+
+func (b *MongoBackend) Find(ctx context.Context, db, coll string, filter any, opts FindOptions, results any) error {
+	cur, err := b.find(ctx, db, coll, filter, opts)
+	if err != nil {
+		return err
+	}
+	return cur.All(ctx, results)
+}
+
+func (b *MongoBackend) FindCursor(ctx context.Context, db, coll string, filter any, opts FindOptions) (Cursor, error) {
+	cur, err := b.find(ctx, db, coll, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+func (b *MongoBackend) find(ctx context.Context, db, coll string, filter any, opts FindOptions) (*mongo.Cursor, error) {
+	findOptions := options.Find()
+	if opts.Skip > 0 {
+		findOptions.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		findOptions.SetLimit(opts.Limit)
+	}
+	if len(opts.Sort) > 0 {
+		findOptions.SetSort(opts.Sort)
+	}
+	if len(opts.Projection) > 0 {
+		findOptions.SetProjection(opts.Projection)
+	}
+
+	return b.database(db).Collection(coll).Find(ctx, filter, findOptions)
+}
+
+func (b *MongoBackend) Aggregate(ctx context.Context, db, coll string, pipeline any, results any) error {
+	cur, err := b.database(db).Collection(coll).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cur.All(ctx, results)
+}
+
+func (b *MongoBackend) AggregateCursor(ctx context.Context, db, coll string, pipeline any) (Cursor, error) {
+	cur, err := b.database(db).Collection(coll).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+func (b *MongoBackend) CountDocuments(ctx context.Context, db, coll string, filter any, limit int64) (int64, error) {
+	countOptions := options.Count()
+	if limit > 0 {
+		countOptions.SetLimit(limit)
+	}
+	return b.database(db).Collection(coll).CountDocuments(ctx, filter, countOptions)
+}
+
+func (b *MongoBackend) EstimatedDocumentCount(ctx context.Context, db, coll string) (int64, error) {
+	return b.database(db).Collection(coll).EstimatedDocumentCount(ctx)
+}
+
+func (b *MongoBackend) InsertOne(ctx context.Context, db, coll string, doc any) (any, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	res, err := b.database(db).Collection(coll).InsertOne(ctx, doc)
+	if err != nil {
+		return nil, writeErr(ctx, err)
+	}
+	if !res.Acknowledged {
+		return nil, fmt.Errorf("document insertion was not acknowledged")
+	}
+	return res.InsertedID, nil
+}
+
+func (b *MongoBackend) ReplaceOne(ctx context.Context, db, coll string, filter, replacement any) (int64, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	res, err := b.database(db).Collection(coll).ReplaceOne(ctx, filter, replacement)
+	if err != nil {
+		return 0, writeErr(ctx, err)
+	}
+	return res.MatchedCount, nil
+}
+
+func (b *MongoBackend) DeleteOne(ctx context.Context, db, coll string, filter any) (int64, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	res, err := b.database(db).Collection(coll).DeleteOne(ctx, filter)
+	if err != nil {
+		return 0, writeErr(ctx, err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (b *MongoBackend) UpdateMany(ctx context.Context, db, coll string, filter, update any) (int64, int64, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	res, err := b.database(db).Collection(coll).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, 0, writeErr(ctx, err)
+	}
+	return res.MatchedCount, res.ModifiedCount, nil
+}
+
+func (b *MongoBackend) DeleteMany(ctx context.Context, db, coll string, filter any) (int64, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	res, err := b.database(db).Collection(coll).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, writeErr(ctx, err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (b *MongoBackend) InsertMany(ctx context.Context, db, coll string, docs []bson.D, ordered bool) (BulkResult, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	_, err := b.database(db).Collection(coll).InsertMany(ctx, docs, options.InsertMany().SetOrdered(ordered))
+	return bulkResult(len(docs), ordered, writeErr(ctx, err))
+}
+
+func (b *MongoBackend) UpsertMany(ctx context.Context, db, coll string, filters, docs []bson.D, ordered bool) (BulkResult, error) {
+	ctx, cancel := b.writeContext(ctx)
+	defer cancel()
+	models := make([]mongo.WriteModel, len(docs))
+	for i, doc := range docs {
+		models[i] = mongo.NewReplaceOneModel().SetFilter(filters[i]).SetReplacement(doc).SetUpsert(true)
+	}
+	_, err := b.database(db).Collection(coll).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+	return bulkResult(len(docs), ordered, writeErr(ctx, err))
+}
+
+// bulkResult counts the docs of a batch of n that were written from the error returned by the bulk write. Errors
+// that are not about individual docs, such as a network error, fail the whole batch
+func bulkResult(n int, ordered bool, err error) (BulkResult, error) {
+	if err == nil {
+		return BulkResult{Written: int64(n)}, nil
+	}
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		return BulkResult{}, err
+	}
+	res := BulkResult{Failed: int64(len(bulkErr.WriteErrors)), FirstFailure: bulkErr.WriteErrors[0].Message}
+	if ordered {
+		res.Written = int64(bulkErr.WriteErrors[0].Index)
+	} else {
+		res.Written = int64(n) - res.Failed
+	}
+	return res, nil
+}
+
+func (b *MongoBackend) RunCommand(ctx context.Context, db string, cmd any, result any) error {
+	return b.database(db).RunCommand(ctx, cmd).Decode(result)
+}
+
+func (b *MongoBackend) ListIndexes(ctx context.Context, db, coll string, results any) error {
+	cur, err := b.database(db).Collection(coll).Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	return cur.All(ctx, results)
+}
+
+// CreateIndex runs the createIndexes command directly, rather than using the IndexView, so that every option
+// of the spec is passed through to the server
+func (b *MongoBackend) CreateIndex(ctx context.Context, db, coll string, spec any) error {
+	cmd := bson.D{{Key: "createIndexes", Value: coll}, {Key: "indexes", Value: bson.A{spec}}}
+	return b.database(db).RunCommand(ctx, cmd).Err()
+}
+
+func (b *MongoBackend) DropIndex(ctx context.Context, db, coll, name string) error {
+	return b.database(db).Collection(coll).Indexes().DropOne(ctx, name)
+}
+
+// CreateCollection runs the create command directly, rather than using options.CreateCollection, so that every
+// option is passed through to the server including those the driver does not know of yet
+func (b *MongoBackend) CreateCollection(ctx context.Context, db, coll string, opts bson.D) error {
+	cmd := append(bson.D{{Key: "create", Value: coll}}, opts...)
+	return b.database(db).RunCommand(ctx, cmd).Err()
+}
+
+func (b *MongoBackend) DropDatabase(ctx context.Context, db string) error {
+	return b.database(db).Drop(ctx)
+}
+
+func (b *MongoBackend) DropCollection(ctx context.Context, db, coll string) error {
+	return b.database(db).Collection(coll).Drop(ctx)
+}
+
+// RenameCollection runs the renameCollection command which must be run against the admin database
+func (b *MongoBackend) RenameCollection(ctx context.Context, db, coll, toDb, toColl string, dropTarget bool) error {
+	cmd := bson.D{
+		{Key: "renameCollection", Value: db + "." + coll},
+		{Key: "to", Value: toDb + "." + toColl},
+		{Key: "dropTarget", Value: dropTarget},
+	}
+	return b.database("admin").RunCommand(ctx, cmd).Err()
+}
+
+func (b *MongoBackend) Watch(ctx context.Context, db, coll string) (ChangeStream, error) {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	return b.database(db).Collection(coll).Watch(ctx, mongo.Pipeline{}, opts)
+}
+
+// WithTransaction requires the server to be a replica set member or a mongos. The session is passed to the
+// operations run by fn through its ctx. Transactions must read from the primary so the read preference chosen by
+// the user is not applied to them
+func (b *MongoBackend) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := b.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	}, options.Transaction().SetReadPreference(readpref.Primary()))
+	return err
+}
@@ -4,17 +4,19 @@ package mongoengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kreulenk/mongotui/pkg/components/modal"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
+	"maps"
 	"sync"
 	"time"
 )
 
 const Timeout = 10 * time.Second
 const Limit = 25 // Page size for doclist component
+const maxConcurrentCollectionLoads = 8
 
 type server struct {
 	databases map[string]database
@@ -22,10 +24,51 @@ type server struct {
 	// Info about docs being displayed in doclist component
 	cachedDocSummaries []docSummary
 	cachedDocs         []*bson.M
+
+	cachedIndexes []Index // Indexes of the selected collection displayed in the indexlist component
 }
 
 type database struct {
 	collections []string
+	state       CollectionsState
+	err         error // Set when the collections failed to load
+
+	stats           *DatabaseStats              // nil until LoadDatabaseStats is first called for the database
+	collectionStats map[string]*CollectionStats // Collection name -> stats, filled in by LoadCollectionStats
+}
+
+// CollectionsState describes how far along the loading of a database's collections is
+type CollectionsState int
+
+const (
+	CollectionsNotLoaded CollectionsState = iota
+	CollectionsLoading
+	CollectionsLoaded
+	CollectionsFailed
+)
+
+// Query holds the filter as well as the find options entered in the querysearch component.
+// If a Pipeline is set, the query is run as an aggregation and the find fields are ignored
+type Query struct {
+	Filter     bson.D
+	Sort       bson.D
+	Projection bson.D
+	Limit      int64 // Max number of docs returned across all pages. 0 means no limit
+
+	Pipeline []bson.D
+}
+
+// IsAggregation reports if the query should be run with Aggregate rather than Find
+func (q Query) IsAggregation() bool {
+	return q.Pipeline != nil
+}
+
+// filter returns the query filter, defaulting to an empty filter as the driver will not accept a nil one
+func (q Query) filter() bson.D {
+	if q.Filter == nil {
+		return bson.D{}
+	}
+	return q.Filter
 }
 
 type docSummary []fieldSummary // Used to display information about each doc in the doclist component
@@ -37,26 +80,64 @@ type fieldSummary struct {
 }
 
 type Engine struct {
-	Client *mongo.Client
-	server *server
+	backend Backend
+	server  *server
 
 	selectedDb         string
 	selectedCollection string
 	selectedDoc        *bson.M
 
-	lastExecutedQuery bson.D // Used to refresh db after deletion operation and for pagination
-	Skip              int64  // Used for pagination when querying docs
-	DocCount          int64  // Used for pagination
+	lastExecutedQuery Query // Used to refresh db after deletion operation and for pagination
+	Skip              int64 // Used for pagination when querying docs
+	DocCount          int64 // Used for pagination
+	docCountKind      CountKind
+	countMode         CountKind // How filtered queries are counted. Cycled via CycleCountMode
+
+	paginationMode  PaginationMode
+	keysetPaginated bool     // If the docs currently cached were fetched by keyset rather than by skip
+	pageAfterKeys   []bson.D // The sort key each page up to the current one started after. nil for the first page
+	lastPageKey     bson.D   // The sort key of the last doc on the current page. nil when paginating by skip
+
+	explain *Explain // The plan of the last query that was explained
+	schema  *Schema  // The schema last inferred by InferSchema
+
+	followId     uint64             // Incremented for every change stream opened by Follow
+	followCancel context.CancelFunc // Cancels the change stream that is open. nil when not following
+	changeEvents []ChangeEvent      // Events received from the change stream, oldest first
 
-	mu sync.RWMutex // bubbletea sends updates in go routines concurrently
+	journal   []JournalEntry // Writes that can be undone, oldest first
+	journalId uint64         // Incremented for every write added to the journal or staged
+
+	staging bool           // If inserts, edits and deletes are queued rather than made. Toggled via ToggleStaging
+	staged  []JournalEntry // Changes that are waiting to be committed, in the order they were staged
+
+	concerns Concerns // Read preference, read concern and write concern that queries and writes are sent with
+
+	job   *job   // The job running in the background. nil when none is
+	jobId uint64 // Incremented for every job started
+
+	// connect opens a backend for another server from its connection string, such as the target of a copy
+	connect func(ctx context.Context, connectionString string) (backend Backend, disconnect func(), err error)
+
+	mu      sync.RWMutex  // bubbletea sends updates in go routines concurrently
+	loadSem chan struct{} // Bounds the number of databases whose collections are loaded at once
+
+	opMu     sync.Mutex              // Guards the fields of the operation that is in flight
+	opId     uint64                  // Incremented for every new operation
+	opCancel context.CancelCauseFunc // Cancels the operation that is in flight. nil when nothing is running
+
+	pinnedId     uint64                  // Incremented for every new pinned operation, which is guarded by opMu
+	pinnedCancel context.CancelCauseFunc // Cancels the pinned operation that is in flight. nil when none is
 }
 
-func New(client *mongo.Client) *Engine {
+func New(backend Backend) *Engine {
 	return &Engine{
-		Client: client,
+		backend: backend,
 		server: &server{
 			databases: make(map[string]database),
 		},
+		loadSem: make(chan struct{}, maxConcurrentCollectionLoads),
+		connect: connectMongoBackend,
 	}
 }
 
@@ -82,28 +163,25 @@ func (e *Engine) SetSelectedDocument(d *bson.M) {
 
 func (e *Engine) DropDatabase(databaseName string) tea.Cmd {
 	return func() tea.Msg {
-		db := e.Client.Database(databaseName)
 		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 		defer cancel()
 
-		if err := db.Drop(ctx); err != nil {
+		if err := e.backend.DropDatabase(ctx, databaseName); err != nil {
 			return modal.ErrModalMsg{Err: err}
 		}
 		if err := e.RefreshDbAndCollections(); err != nil {
 			return modal.ErrModalMsg{Err: err}
 		}
-		return RedrawMessage{}
+		return DatabasesRefreshedMsg{}
 	}
 }
 
 func (e *Engine) DropCollection(databaseName, collectionName string) tea.Cmd {
 	return func() tea.Msg {
-		db := e.Client.Database(databaseName)
-		col := db.Collection(collectionName)
 		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 		defer cancel()
 
-		if err := col.Drop(ctx); err != nil {
+		if err := e.backend.DropCollection(ctx, databaseName, collectionName); err != nil {
 			return modal.ErrModalMsg{Err: err}
 		}
 
@@ -113,70 +191,162 @@ func (e *Engine) DropCollection(databaseName, collectionName string) tea.Cmd {
 		if err := e.RefreshDbAndCollections(); err != nil {
 			return modal.ErrModalMsg{Err: err}
 		}
-		return RedrawMessage{}
+		return DatabasesRefreshedMsg{}
 	}
 }
 
-// DeleteDocument will drop a document from the collection that was selected using SetSelectedCollection
-// We do not use _id as not every doc will have one so we match the entire doc
-func (e *Engine) DeleteDocument(doc *bson.M) tea.Cmd {
+// RenameCollection renames a collection, possibly moving it into another database, and selects it under its new
+// name so that it stays highlighted once the databases are refreshed
+func (e *Engine) RenameCollection(databaseName, collectionName, toDatabaseName, toCollectionName string, dropTarget bool) tea.Cmd {
 	return func() tea.Msg {
-		db := e.Client.Database(e.selectedDb)
-		coll := db.Collection(e.selectedCollection)
 		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 		defer cancel()
 
-		res, err := coll.DeleteOne(ctx, doc)
-		if err != nil {
+		if err := e.backend.RenameCollection(ctx, databaseName, collectionName, toDatabaseName, toCollectionName, dropTarget); err != nil {
+			return modal.ErrModalMsg{Err: err}
+		}
+		e.SetSelectedCollection(toDatabaseName, toCollectionName)
+		if err := e.RefreshDbAndCollections(); err != nil {
+			return modal.ErrModalMsg{Err: err}
+		}
+		return CollectionRenamedMsg{DbName: toDatabaseName, CollectionName: toCollectionName}
+	}
+}
+
+// CheckDocsWritable returns why the documents of the last query can not be written back to the collection, or nil
+// if they can. action names what the user tried to do, such as edit, for the error
+func (e *Engine) CheckDocsWritable(action string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.lastExecutedQuery.IsAggregation() {
+		return fmt.Errorf("cannot %s a document returned by an aggregation as it may not be a stored document, switch to a find filter first", action)
+	}
+	if len(e.lastExecutedQuery.Projection) > 0 {
+		return fmt.Errorf("cannot %s a document loaded with a projection as the fields left out would be lost, clear the projection first", action)
+	}
+	return nil
+}
+
+// DeleteDocument will drop a document from the collection that was selected using SetSelectedCollection.
+// The document is only deleted if it is still as it was loaded. A conflict modal is displayed instead if it has
+// changed since. While staging, the deletion is only queued
+func (e *Engine) DeleteDocument(doc *bson.M) tea.Cmd {
+	return func() tea.Msg {
+		if err := e.CheckDocsWritable("delete"); err != nil {
 			return modal.ErrModalMsg{Err: err}
 		}
-		if res.DeletedCount == 0 {
-			return modal.ErrModalMsg{Err: fmt.Errorf("no document was deleted")}
+		if e.IsStaging() {
+			e.stage(*doc, nil)
+			return RedrawMessage{}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+
+		var conflict *ConflictError
+		err := e.writeUnchanged(ctx, e.selectedDb, e.selectedCollection, *doc, func(filter bson.D) (int64, error) {
+			return e.backend.DeleteOne(ctx, e.selectedDb, e.selectedCollection, filter)
+		})
+		if errors.As(err, &conflict) {
+			return modal.DisplayDocConflictModal(*doc, conflict.Current, nil)()
+		} else if err != nil {
+			return modal.ErrModalMsg{Err: err}
 		}
+		e.recordWrite(DocDeleted, e.selectedDb, e.selectedCollection, *doc, nil)
 		return e.RerunLastCollectionQuery()() // Double call as we are already calling it in a query
 	}
 }
 
-// UpdateDocument will find and replace a given oldDoc with a newDoc within the db/collection
-// that was selected using the SetSelectedCollection method
-// We do not use _id as not every doc will have one so we match the entire doc
+// UpdateDocument will replace oldDoc with newDoc within the db/collection that was selected using the
+// SetSelectedCollection method. oldDoc is only replaced if the document is still as it was loaded.
+// A *ConflictError is returned if the document has changed since oldDoc was loaded. While staging, the edit is
+// only queued
 func (e *Engine) UpdateDocument(oldDoc, newDoc bson.M) error {
-	db := e.Client.Database(e.selectedDb)
-	coll := db.Collection(e.selectedCollection)
+	if err := e.CheckDocsWritable("edit"); err != nil {
+		return err
+	}
+	if e.IsStaging() {
+		e.stage(oldDoc, newDoc)
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
 
-	res := coll.FindOneAndReplace(ctx, oldDoc, newDoc)
-	if res.Err() != nil {
-		return fmt.Errorf("failed to update document: %w", res.Err())
+	err := e.writeUnchanged(ctx, e.selectedDb, e.selectedCollection, oldDoc, func(filter bson.D) (int64, error) {
+		n, err := e.backend.ReplaceOne(ctx, e.selectedDb, e.selectedCollection, filter, newDoc)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update document: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
 	}
+	e.recordWrite(DocEdited, e.selectedDb, e.selectedCollection, oldDoc, newDoc)
 	return nil
 }
 
+// InsertDocument inserts doc into the collection that was selected using SetSelectedCollection. While staging,
+// the insert is only queued
 func (e *Engine) InsertDocument(doc bson.M) error {
-	db := e.Client.Database(e.selectedDb)
-	coll := db.Collection(e.selectedCollection)
+	if e.IsStaging() {
+		e.stageInsert(doc)
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
 
-	res, err := coll.InsertOne(ctx, doc)
+	id, err := e.backend.InsertOne(ctx, e.selectedDb, e.selectedCollection, doc)
 	if err != nil {
 		return err
 	}
-	if !res.Acknowledged {
-		return fmt.Errorf("document insertion was not acknowledged")
+	if _, ok := doc["_id"]; !ok { // Recorded so that undoing the insert deletes this doc rather than any equal one
+		doc = maps.Clone(doc)
+		doc["_id"] = id
 	}
+	e.recordWrite(DocInserted, e.selectedDb, e.selectedCollection, nil, doc)
 	return nil
 }
 
-func (e *Engine) InsertDatabaseAndCollection(databaseName, collectionName string) error {
-	db := e.Client.Database(databaseName)
+// InsertDatabaseAndCollection creates a collection, and the database if it does not exist yet, with the options of
+// the create command. Options left as null are dropped so that a template of every option can be edited
+func (e *Engine) InsertDatabaseAndCollection(databaseName, collectionName string, opts bson.D) error {
+	if databaseName == "" || collectionName == "" {
+		return fmt.Errorf("a database and collection name are required")
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
 	defer cancel()
 
-	return db.CreateCollection(ctx, collectionName)
+	return e.backend.CreateCollection(ctx, databaseName, collectionName, removeNulls(opts))
+}
+
+// removeNulls returns doc without the fields that are null. Embedded documents that are left empty are dropped too
+func removeNulls(doc bson.D) bson.D {
+	var cleaned bson.D
+	for _, elem := range doc {
+		if embedded, ok := elem.Value.(bson.D); ok {
+			if embedded = removeNulls(embedded); embedded == nil {
+				continue
+			}
+			elem.Value = embedded
+		}
+		if elem.Value == nil {
+			continue
+		}
+		cleaned = append(cleaned, elem)
+	}
+	return cleaned
 }
 
 // RedrawMessage is used to trigger a bubbletea update so that the components refresh their View functions
 // whenever the underlying data within mongoengine has updated
 type RedrawMessage struct{}
+
+// DatabasesRefreshedMsg is sent after RefreshDbAndCollections has replaced the cached databases so that the
+// collections of every database can be loaded again
+type DatabasesRefreshedMsg struct{}
+
+// CollectionRenamedMsg is sent once a collection was renamed and the databases were refreshed. It holds the new name
+type CollectionRenamedMsg struct {
+	DbName         string
+	CollectionName string
+}
@@ -0,0 +1,105 @@
+package mongoengine
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestInsertDatabaseAndCollection(t *testing.T) {
+	e := New(newSeededBackend(t))
+
+	tests := []struct {
+		name       string
+		dbName     string
+		collName   string
+		opts       bson.D
+		wantType   string
+		wantCapped bool
+		wantViewOn string
+		wantErr    bool
+	}{
+		{name: "without options", dbName: "shop", collName: "orders", wantType: "collection"},
+		{
+			name:     "null options are dropped",
+			dbName:   "shop",
+			collName: "carts",
+			opts: bson.D{
+				{Key: "capped", Value: nil},
+				{Key: "timeseries", Value: bson.D{{Key: "timeField", Value: nil}}},
+				{Key: "viewOn", Value: nil},
+			},
+			wantType: "collection",
+		},
+		{
+			name:       "capped",
+			dbName:     "shop",
+			collName:   "logs",
+			opts:       bson.D{{Key: "capped", Value: true}, {Key: "size", Value: int32(4096)}, {Key: "max", Value: nil}},
+			wantType:   "collection",
+			wantCapped: true,
+		},
+		{
+			name:     "time series",
+			dbName:   "metrics",
+			collName: "readings",
+			opts:     bson.D{{Key: "timeseries", Value: bson.D{{Key: "timeField", Value: "ts"}, {Key: "granularity", Value: nil}}}},
+			wantType: "timeseries",
+		},
+		{
+			name:       "view",
+			dbName:     "shop",
+			collName:   "adults",
+			opts:       bson.D{{Key: "viewOn", Value: "users"}, {Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}}}}}}}}},
+			wantType:   "view",
+			wantViewOn: "users",
+		},
+		{name: "view on a missing collection", dbName: "shop", collName: "ghosts", opts: bson.D{{Key: "viewOn", Value: "missing"}}, wantErr: true},
+		{name: "existing collection", dbName: "shop", collName: "users", wantErr: true},
+		{name: "missing name", dbName: "shop", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.InsertDatabaseAndCollection(tt.dbName, tt.collName, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InsertDatabaseAndCollection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if err := e.RefreshDbAndCollections(); err != nil {
+				t.Fatalf("RefreshDbAndCollections() error = %v", err)
+			}
+			e.LoadCollectionStats(tt.dbName, tt.collName)()
+			stats, _ := e.GetCollectionStats(tt.dbName, tt.collName)
+			if stats.Err != nil || stats.Type != tt.wantType || stats.Capped != tt.wantCapped || stats.ViewOn != tt.wantViewOn {
+				t.Errorf("GetCollectionStats() = %+v, want type %q, capped %v and view on %q", stats, tt.wantType, tt.wantCapped, tt.wantViewOn)
+			}
+		})
+	}
+}
+
+func TestDocumentText(t *testing.T) {
+	owner, _ := bson.ObjectIDFromHex("65a0c0ffee0000000000abcd")
+	doc := bson.M{"_id": owner, "name": "Kevin", "tags": bson.A{"admin", "dev"}, "address": bson.M{"city": "Boston", "zip": int32(2134)}}
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "_id", want: `{` + "\n" + `  "$oid": "65a0c0ffee0000000000abcd"` + "\n" + `}`},
+		{path: "name", want: "Kevin"},
+		{path: "address.zip", want: "2134"},
+		{path: "tags", want: "[\n  \"admin\",\n  \"dev\"\n]"},
+		{path: "tags.1", want: "dev"},
+		{path: "address.street", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DocumentText(&doc, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("DocumentText(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+	if _, err := DocumentText(nil, ""); err == nil {
+		t.Errorf("DocumentText() of no document should fail")
+	}
+}
@@ -0,0 +1,133 @@
+package mongoengine
+
+// The methods contained in this file keep track of the query that is currently in flight so that it can be
+// cancelled either by the user or by a newer query. Writes are tracked separately as pinned operations so that
+// the queries started while they run, such as by moving the cursor, do not cancel them
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"time"
+)
+
+var (
+	errOperationSuperseded = errors.New("operation was replaced by a newer operation")
+	errOperationCancelled  = errors.New("operation was cancelled")
+)
+
+// OperationCancelledMsg is sent instead of an error modal when the user cancels an operation
+type OperationCancelledMsg struct{}
+
+// noTimeout is passed to startPinnedOperation for writes that must run until they complete or the user cancels them
+const noTimeout time.Duration = 0
+
+// newOperationContext returns a context that is cancelled either with a cause or once timeout has passed. A timeout
+// of noTimeout never expires
+func newOperationContext(timeout time.Duration) (context.Context, context.CancelCauseFunc) {
+	causeCtx, cancelCause := context.WithCancelCause(context.Background())
+	if timeout == noTimeout {
+		return causeCtx, cancelCause
+	}
+	ctx, cancelTimeout := context.WithTimeout(causeCtx, timeout)
+	return ctx, func(cause error) {
+		cancelCause(cause)
+		cancelTimeout()
+	}
+}
+
+// startOperation cancels the operation that is currently in flight and returns the context for a new one
+// along with an id that must be passed to finishOperation once the operation completes
+func (e *Engine) startOperation() (context.Context, uint64) {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	if e.opCancel != nil {
+		e.opCancel(errOperationSuperseded)
+	}
+
+	var ctx context.Context
+	ctx, e.opCancel = newOperationContext(Timeout)
+	e.opId++
+	return ctx, e.opId
+}
+
+// finishOperation releases the context of an operation if it has not already been replaced by a newer one
+func (e *Engine) finishOperation(id uint64) {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	if id == e.opId && e.opCancel != nil {
+		e.opCancel(nil)
+		e.opCancel = nil
+	}
+}
+
+// startPinnedOperation returns the context of an operation that is not cancelled by the operations started after
+// it, only by CancelOperation or once timeout has passed. Only one can be in flight at a time. The id must be passed
+// to finishPinnedOperation
+func (e *Engine) startPinnedOperation(timeout time.Duration) (context.Context, uint64, error) {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	if e.pinnedCancel != nil {
+		return nil, 0, fmt.Errorf("another write is still in progress, wait for it to complete or cancel it first")
+	}
+
+	var ctx context.Context
+	ctx, e.pinnedCancel = newOperationContext(timeout)
+	e.pinnedId++
+	return ctx, e.pinnedId, nil
+}
+
+// finishPinnedOperation releases the context of a pinned operation if it was not cancelled
+func (e *Engine) finishPinnedOperation(id uint64) {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	if id == e.pinnedId && e.pinnedCancel != nil {
+		e.pinnedCancel(nil)
+		e.pinnedCancel = nil
+	}
+}
+
+// CancelOperation cancels the operations that are currently in flight. It returns false if nothing was running
+func (e *Engine) CancelOperation() bool {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	cancelled := false
+	for _, cancel := range []*context.CancelCauseFunc{&e.opCancel, &e.pinnedCancel} {
+		if *cancel != nil {
+			(*cancel)(errOperationCancelled)
+			*cancel = nil
+			cancelled = true
+		}
+	}
+	return cancelled
+}
+
+// IsOperationRunning reports if there is an operation in flight that can be cancelled
+func (e *Engine) IsOperationRunning() bool {
+	e.opMu.Lock()
+	defer e.opMu.Unlock()
+	return e.opCancel != nil || e.pinnedCancel != nil
+}
+
+// operationErrMsg converts the error returned by a cancellable operation into the message that should be sent
+func operationErrMsg(ctx context.Context, err error) tea.Msg {
+	switch context.Cause(ctx) {
+	case errOperationSuperseded:
+		return nil // The newer operation will trigger the redraw
+	case errOperationCancelled:
+		return OperationCancelledMsg{}
+	default:
+		return modal.ErrModalMsg{Err: err}
+	}
+}
+
+// writeErrMsg converts the error returned by a pinned write into an error modal. Unlike a query, a cancelled write
+// is reported as an error since part of it may have already been applied
+func writeErrMsg(ctx context.Context, err error) tea.Msg {
+	if context.Cause(ctx) == errOperationCancelled {
+		err = fmt.Errorf("%w: %w", errOperationCancelled, err)
+	}
+	return modal.ErrModalMsg{Err: err}
+}
@@ -0,0 +1,37 @@
+package mongoengine
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestQueryCancellation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cancel  func(e *Engine)
+		wantMsg any
+	}{
+		{name: "completed query", cancel: func(e *Engine) {}, wantMsg: RedrawMessage{}},
+		{name: "cancelled by the user", cancel: func(e *Engine) { e.CancelOperation() }, wantMsg: OperationCancelledMsg{}},
+		{name: "replaced by a newer query", cancel: func(e *Engine) { e.QueryCollection(Query{}) }, wantMsg: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(newSeededBackend(t))
+			e.SetSelectedCollection("shop", "users")
+			cmd := e.QueryCollection(Query{Filter: bson.D{{Key: "name", Value: "Kevin"}}})
+			if !e.IsOperationRunning() {
+				t.Fatalf("IsOperationRunning() should be true once a query has been started")
+			}
+
+			tt.cancel(e)
+			if msg := cmd(); msg != tt.wantMsg {
+				t.Errorf("query returned %#v, want %#v", msg, tt.wantMsg)
+			}
+			if tt.wantMsg != (RedrawMessage{}) && len(e.GetDocumentSummaries()) != 0 {
+				t.Errorf("the results of a cancelled query should not be cached")
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package mongoengine
+
+// The functions contained within this file infer the shape of a collection from a random sample of its docs. This
+// is the quickest way to learn the fields of a collection that has no documented schema
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"strings"
+)
+
+const schemaSampleSize = 1000 // Number of docs sampled by InferSchema
+
+// SchemaReadyMsg is sent once InferSchema has cached the schema of a collection so that it can be displayed
+type SchemaReadyMsg struct{}
+
+// Schema is the tree of fields found within the docs sampled from a collection
+type Schema struct {
+	SampledDocs int64
+	Fields      []*SchemaField
+}
+
+// SchemaField describes a field found at the same path within the sampled docs. Docs embedded within arrays add
+// their fields as children of the array field, the same way that dot notation reaches into arrays in queries
+type SchemaField struct {
+	Name  string
+	Path  string // Dot separated path from the root of the doc
+	Count int64  // Number of sampled docs the field was found in
+
+	Types []SchemaType // Types of the values found, ordered from the most to the least common
+
+	// Min and Max are the smallest and largest values that could be compared with each other, such as numbers or
+	// dates. Example is the first value found and is only kept if the values could not be compared
+	Min, Max any
+	Example  any
+
+	Fields []*SchemaField
+}
+
+// SchemaType is the number of values of a single type found for a field. The names are the aliases accepted by
+// the $type query operator
+type SchemaType struct {
+	Name  string
+	Count int64
+}
+
+// InferSchema samples up to schemaSampleSize docs of the selected collection with $sample and infers a schema
+// from them. Like any other query it can be cancelled and is cancelled by newer queries
+func (e *Engine) InferSchema() tea.Cmd {
+	ctx, id := e.startOperation()
+	return func() tea.Msg {
+		defer e.finishOperation(id)
+
+		e.mu.RLock()
+		dbName, collName := e.selectedDb, e.selectedCollection
+		e.mu.RUnlock()
+
+		pipeline := bson.A{bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: schemaSampleSize}}}}}
+		var docs []bson.D
+		if err := e.backend.Aggregate(ctx, dbName, collName, pipeline, &docs); err != nil {
+			return operationErrMsg(ctx, fmt.Errorf("could not sample %s.%s: %w", dbName, collName, err))
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.schema = inferSchema(docs)
+		return SchemaReadyMsg{}
+	}
+}
+
+// inferSchema merges the fields of every doc into a single tree
+func inferSchema(docs []bson.D) *Schema {
+	root := &SchemaField{}
+	for _, doc := range docs {
+		root.addDoc(doc, make(map[*SchemaField]bool))
+	}
+	sortSchemaFields(root.Fields)
+	return &Schema{SampledDocs: int64(len(docs)), Fields: root.Fields}
+}
+
+// addDoc adds the fields of an embedded doc. counted tracks the fields that were already found within the
+// current root doc so that a field within an array of docs is only counted once per root doc
+func (f *SchemaField) addDoc(doc bson.D, counted map[*SchemaField]bool) {
+	for _, elem := range doc {
+		f.child(elem.Key).addValue(elem.Value, counted)
+	}
+}
+
+func (f *SchemaField) addValue(v any, counted map[*SchemaField]bool) {
+	if !counted[f] {
+		counted[f] = true
+		f.Count++
+	}
+	f.addType(bsonTypeAlias(v))
+
+	switch val := v.(type) {
+	case bson.D:
+		f.addDoc(val, counted)
+	case bson.A:
+		for _, elem := range val {
+			if doc, ok := elem.(bson.D); ok {
+				f.addDoc(doc, counted)
+			}
+		}
+	case nil:
+	default:
+		f.addScalar(val)
+	}
+}
+
+func (f *SchemaField) addType(name string) {
+	for i := range f.Types {
+		if f.Types[i].Name == name {
+			f.Types[i].Count++
+			return
+		}
+	}
+	f.Types = append(f.Types, SchemaType{Name: name, Count: 1})
+}
+
+// addScalar widens the min and max to include v. Values that can not be compared with the current min, such as a
+// string once a number was found, are skipped
+func (f *SchemaField) addScalar(v any) {
+	if f.Example == nil {
+		f.Example = v
+	}
+	if f.Min == nil {
+		if _, comparable := compareValues(v, v); comparable {
+			f.Min, f.Max = v, v
+		}
+		return
+	}
+	if cmp, ok := compareValues(v, f.Min); ok && cmp < 0 {
+		f.Min = v
+	}
+	if cmp, ok := compareValues(v, f.Max); ok && cmp > 0 {
+		f.Max = v
+	}
+}
+
+func (f *SchemaField) child(name string) *SchemaField {
+	for _, child := range f.Fields {
+		if child.Name == name {
+			return child
+		}
+	}
+	path := name
+	if f.Path != "" {
+		path = f.Path + "." + name
+	}
+	child := &SchemaField{Name: name, Path: path}
+	f.Fields = append(f.Fields, child)
+	return child
+}
+
+// sortSchemaFields orders fields by name, apart from _id which is always first, and types by how common they are
+func sortSchemaFields(fields []*SchemaField) {
+	slices.SortFunc(fields, func(a, b *SchemaField) int {
+		switch {
+		case a.Name == "_id":
+			return -1
+		case b.Name == "_id":
+			return 1
+		default:
+			return strings.Compare(a.Name, b.Name)
+		}
+	})
+	for _, field := range fields {
+		slices.SortStableFunc(field.Types, func(a, b SchemaType) int {
+			return int(b.Count - a.Count)
+		})
+		sortSchemaFields(field.Fields)
+	}
+}
+
+// bsonTypeAlias returns the $type alias of a value decoded by the driver
+func bsonTypeAlias(v any) string {
+	switch v.(type) {
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case bson.D, bson.M:
+		return "object"
+	case bson.A:
+		return "array"
+	case bson.Binary:
+		return "binData"
+	case bson.Undefined:
+		return "undefined"
+	case bson.ObjectID:
+		return "objectId"
+	case bool:
+		return "bool"
+	case bson.DateTime:
+		return "date"
+	case nil, bson.Null:
+		return "null"
+	case bson.Regex:
+		return "regex"
+	case bson.DBPointer:
+		return "dbPointer"
+	case bson.JavaScript:
+		return "javascript"
+	case bson.Symbol:
+		return "symbol"
+	case bson.CodeWithScope:
+		return "javascriptWithScope"
+	case int32:
+		return "int"
+	case bson.Timestamp:
+		return "timestamp"
+	case int64:
+		return "long"
+	case bson.Decimal128:
+		return "decimal"
+	case bson.MinKey:
+		return "minKey"
+	case bson.MaxKey:
+		return "maxKey"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
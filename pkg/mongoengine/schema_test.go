@@ -0,0 +1,72 @@
+package mongoengine
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	docs := []bson.D{
+		{{Key: "_id", Value: int32(1)}, {Key: "name", Value: "Kevin"}, {Key: "age", Value: int32(30)}, {Key: "address", Value: bson.D{{Key: "city", Value: "Boston"}}}},
+		{{Key: "_id", Value: int32(2)}, {Key: "name", Value: "Sally"}, {Key: "age", Value: 41.5}, {Key: "orders", Value: bson.A{
+			bson.D{{Key: "sku", Value: "a"}},
+			bson.D{{Key: "sku", Value: "b"}},
+		}}},
+		{{Key: "_id", Value: int32(3)}, {Key: "name", Value: nil}, {Key: "age", Value: "unknown"}},
+	}
+	schema := inferSchema(docs)
+	if schema.SampledDocs != 3 {
+		t.Errorf("SampledDocs = %d, want 3", schema.SampledDocs)
+	}
+
+	fields := make(map[string]*SchemaField)
+	var index func([]*SchemaField)
+	index = func(children []*SchemaField) {
+		for _, field := range children {
+			fields[field.Path] = field
+			index(field.Fields)
+		}
+	}
+	index(schema.Fields)
+
+	tests := []struct {
+		path      string
+		wantCount int64
+		wantTypes []SchemaType
+		wantMin   any
+		wantMax   any
+	}{
+		{path: "_id", wantCount: 3, wantTypes: []SchemaType{{"int", 3}}, wantMin: int32(1), wantMax: int32(3)},
+		{path: "name", wantCount: 3, wantTypes: []SchemaType{{"string", 2}, {"null", 1}}, wantMin: "Kevin", wantMax: "Sally"},
+		{path: "age", wantCount: 3, wantTypes: []SchemaType{{"int", 1}, {"double", 1}, {"string", 1}}, wantMin: int32(30), wantMax: 41.5},
+		{path: "address", wantCount: 1, wantTypes: []SchemaType{{"object", 1}}},
+		{path: "address.city", wantCount: 1, wantTypes: []SchemaType{{"string", 1}}, wantMin: "Boston", wantMax: "Boston"},
+		{path: "orders.sku", wantCount: 1, wantTypes: []SchemaType{{"string", 2}}, wantMin: "a", wantMax: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			field, ok := fields[tt.path]
+			if !ok {
+				t.Fatalf("no field was inferred at %s", tt.path)
+			}
+			if field.Count != tt.wantCount {
+				t.Errorf("Count = %d, want %d", field.Count, tt.wantCount)
+			}
+			if len(field.Types) != len(tt.wantTypes) {
+				t.Fatalf("Types = %v, want %v", field.Types, tt.wantTypes)
+			}
+			for i := range field.Types {
+				if field.Types[i] != tt.wantTypes[i] {
+					t.Errorf("Types = %v, want %v", field.Types, tt.wantTypes)
+				}
+			}
+			if field.Min != tt.wantMin || field.Max != tt.wantMax {
+				t.Errorf("Min, Max = %v, %v, want %v, %v", field.Min, field.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+
+	if schema.Fields[0].Name != "_id" || schema.Fields[1].Name != "address" {
+		t.Errorf("fields are not sorted with _id first: %s, %s", schema.Fields[0].Name, schema.Fields[1].Name)
+	}
+}
@@ -0,0 +1,150 @@
+package mongoengine
+
+// The methods contained within this file queue the documents inserted, edited and deleted while staging is enabled
+// so that they can be reviewed together and then committed in a single transaction or discarded
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"maps"
+	"slices"
+	"time"
+)
+
+// StagedCommittedMsg is sent once CommitStaged has committed the staged changes
+type StagedCommittedMsg struct {
+	Count int
+}
+
+// ToggleStaging starts or stops the staging of writes. Staging can not be stopped while changes are pending
+func (e *Engine) ToggleStaging() tea.Cmd {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.staging && len(e.staged) > 0 {
+		return modal.DisplayErrorModal(fmt.Errorf("commit or discard the %d staged changes before staging is stopped", len(e.staged)))
+	}
+	e.staging = !e.staging
+	return nil
+}
+
+// stage queues a write to the selected collection. A write to a document that already has a staged change is
+// merged into it so that the document is written once with its final version
+func (e *Engine) stage(before, after bson.M) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	change := JournalEntry{Db: e.selectedDb, Collection: e.selectedCollection, Before: before, After: after, Time: time.Now()}
+	if i := e.stagedIndex(change); i >= 0 {
+		change.Before = e.staged[i].Before
+		e.staged = slices.Delete(e.staged, i, i+1)
+	}
+	switch {
+	case change.Before == nil && change.After == nil: // A staged insert was deleted again
+		return
+	case change.Before == nil:
+		change.Kind = DocInserted
+	case change.After == nil:
+		change.Kind = DocDeleted
+	default:
+		change.Kind = DocEdited
+	}
+	e.journalId++
+	change.Id = e.journalId
+	e.staged = append(e.staged, change)
+}
+
+// stagedIndex returns the position of the staged change made to the same document as change or -1 if there is
+// none. Documents without an _id are never merged. The caller must hold e.mu
+func (e *Engine) stagedIndex(change JournalEntry) int {
+	id, ok := stagedId(change)
+	if !ok {
+		return -1
+	}
+	return slices.IndexFunc(e.staged, func(staged JournalEntry) bool {
+		stagedDocId, ok := stagedId(staged)
+		return ok && staged.Db == change.Db && staged.Collection == change.Collection && valuesEqual(stagedDocId, id)
+	})
+}
+
+func stagedId(change JournalEntry) (any, bool) {
+	if change.After != nil {
+		id, ok := change.After["_id"]
+		return id, ok
+	}
+	id, ok := change.Before["_id"]
+	return id, ok
+}
+
+// stageInsert gives doc an _id, as the driver would when inserting it, so that later writes to the doc are merged
+// into its insert
+func (e *Engine) stageInsert(doc bson.M) {
+	if _, ok := doc["_id"]; !ok {
+		doc = maps.Clone(doc)
+		doc["_id"] = bson.NewObjectID()
+	}
+	e.stage(nil, doc)
+}
+
+// UnstageChange removes a single staged change without writing it
+func (e *Engine) UnstageChange(id uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.staged = slices.DeleteFunc(e.staged, func(change JournalEntry) bool {
+		return change.Id == id
+	})
+}
+
+// DiscardStaged removes every staged change without writing any of them
+func (e *Engine) DiscardStaged() tea.Cmd {
+	return func() tea.Msg {
+		e.mu.Lock()
+		e.staged = nil
+		e.mu.Unlock()
+		return RedrawMessage{}
+	}
+}
+
+// CommitStaged makes every staged change inside a single transaction so that either all or none of them are made.
+// Edits and deletes are checked for conflicts as they would be if they were made directly, and a conflict aborts
+// the transaction. Like other writes, a commit is not cancelled by the queries started while it runs. The committed
+// changes are added to the journal so that they can be undone one at a time
+func (e *Engine) CommitStaged() tea.Cmd {
+	ctx, id, err := e.startPinnedOperation(Timeout)
+	if err != nil {
+		return modal.DisplayErrorModal(err)
+	}
+	return func() tea.Msg {
+		defer e.finishPinnedOperation(id)
+
+		e.mu.RLock()
+		changes := slices.Clone(e.staged)
+		e.mu.RUnlock()
+		if len(changes) == 0 {
+			return modal.ErrModalMsg{Err: fmt.Errorf("there are no staged changes to commit")}
+		}
+
+		err := e.backend.WithTransaction(ctx, func(ctx context.Context) error {
+			for _, change := range changes {
+				if err := e.applyWrite(ctx, change); err != nil {
+					return fmt.Errorf("could not %s: %w", change.Summary(), err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return writeErrMsg(ctx, fmt.Errorf("none of the staged changes were committed: %w", err))
+		}
+
+		e.mu.Lock()
+		e.staged = slices.DeleteFunc(e.staged, func(staged JournalEntry) bool {
+			return slices.ContainsFunc(changes, func(change JournalEntry) bool { return change.Id == staged.Id })
+		})
+		e.mu.Unlock()
+		for _, change := range changes {
+			e.recordWrite(change.Kind, change.Db, change.Collection, change.Before, change.After)
+		}
+		return StagedCommittedMsg{Count: len(changes)}
+	}
+}
@@ -0,0 +1,176 @@
+package mongoengine
+
+import (
+	"context"
+	"github.com/kreulenk/mongotui/pkg/components/modal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+func TestStage(t *testing.T) {
+	edit := func(id any, name string) func(*testing.T, *Engine, Backend) {
+		return func(t *testing.T, e *Engine, backend Backend) {
+			loaded := loadDoc(t, backend, id)
+			if err := e.UpdateDocument(loaded, bson.M{"_id": id, "name": name}); err != nil {
+				t.Fatalf("UpdateDocument() error = %v", err)
+			}
+		}
+	}
+	insert := func(id any, name string) func(*testing.T, *Engine, Backend) {
+		return func(t *testing.T, e *Engine, _ Backend) {
+			if err := e.InsertDocument(bson.M{"_id": id, "name": name}); err != nil {
+				t.Fatalf("InsertDocument() error = %v", err)
+			}
+		}
+	}
+	removeDoc := func(doc bson.M) func(*testing.T, *Engine, Backend) {
+		return func(t *testing.T, e *Engine, _ Backend) {
+			if msg := e.DeleteDocument(&doc)(); msg != (RedrawMessage{}) {
+				t.Fatalf("DeleteDocument() = %#v", msg)
+			}
+		}
+	}
+	remove := func(id any) func(*testing.T, *Engine, Backend) {
+		return func(t *testing.T, e *Engine, backend Backend) {
+			removeDoc(loadDoc(t, backend, id))(t, e, backend)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		writes    []func(*testing.T, *Engine, Backend)
+		wantKinds []WriteKind
+		wantName  any // Name of the last staged change's doc after it is made
+	}{
+		{name: "separate docs", writes: []func(*testing.T, *Engine, Backend){edit(1, "Kev"), remove(2), insert(4, "Ann")}, wantKinds: []WriteKind{DocEdited, DocDeleted, DocInserted}, wantName: "Ann"},
+		{name: "edits of the same doc are merged", writes: []func(*testing.T, *Engine, Backend){edit(1, "Kev"), edit(1, "K")}, wantKinds: []WriteKind{DocEdited}, wantName: "K"},
+		{name: "an edit then a delete is a delete", writes: []func(*testing.T, *Engine, Backend){edit(1, "Kev"), remove(1)}, wantKinds: []WriteKind{DocDeleted}},
+		{name: "a delete then an insert is an edit", writes: []func(*testing.T, *Engine, Backend){remove(1), insert(1, "New")}, wantKinds: []WriteKind{DocEdited}, wantName: "New"},
+		{name: "an insert that is deleted again is dropped", writes: []func(*testing.T, *Engine, Backend){insert(4, "Ann"), removeDoc(bson.M{"_id": 4, "name": "Ann"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSeededBackend(t)
+			e := New(backend)
+			e.SetSelectedCollection("shop", "users")
+			if cmd := e.ToggleStaging(); cmd != nil {
+				t.Fatalf("ToggleStaging() = %#v", cmd())
+			}
+
+			for _, write := range tt.writes {
+				write(t, e, backend)
+			}
+			if n, _ := backend.CountDocuments(context.Background(), "shop", "users", bson.D{}, 0); n != 3 {
+				t.Errorf("CountDocuments() = %d, want the staged writes not to be made", n)
+			}
+			staged := e.GetStagedChanges()
+			var kinds []WriteKind
+			for _, change := range staged {
+				kinds = append(kinds, change.Kind)
+			}
+			if len(kinds) != len(tt.wantKinds) {
+				t.Fatalf("kinds of the staged changes = %v, want %v", kinds, tt.wantKinds)
+			}
+			for i := range kinds {
+				if kinds[i] != tt.wantKinds[i] {
+					t.Fatalf("kinds of the staged changes = %v, want %v", kinds, tt.wantKinds)
+				}
+			}
+			if len(staged) > 0 && staged[len(staged)-1].After["name"] != tt.wantName {
+				t.Errorf("last staged change = %v, want the name %v", staged[len(staged)-1].After, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCommitStaged(t *testing.T) {
+	ctx := context.Background()
+	backend := newSeededBackend(t)
+	e := New(backend)
+	e.SetSelectedCollection("shop", "users")
+	e.ToggleStaging()
+
+	if _, ok := e.CommitStaged()().(modal.ErrModalMsg); !ok {
+		t.Errorf("CommitStaged() should fail when nothing is staged")
+	}
+
+	// A conflict with any change aborts the whole transaction
+	loaded := loadDoc(t, backend, 2)
+	if err := e.InsertDocument(bson.M{"_id": 4, "name": "Ann"}); err != nil {
+		t.Fatalf("InsertDocument() error = %v", err)
+	}
+	if err := e.UpdateDocument(loaded, bson.M{"_id": 2, "name": "Sal"}); err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	if _, _, err := backend.UpdateMany(ctx, "shop", "users", bson.D{{Key: "_id", Value: 2}}, bson.D{{Key: "$set", Value: bson.D{{Key: "age", Value: 26}}}}); err != nil {
+		t.Fatalf("UpdateMany() error = %v", err)
+	}
+	if _, ok := e.CommitStaged()().(modal.ErrModalMsg); !ok {
+		t.Fatalf("CommitStaged() of a conflicting change should fail")
+	}
+	if n, _ := backend.CountDocuments(ctx, "shop", "users", bson.D{}, 0); n != 3 {
+		t.Errorf("CountDocuments() = %d, want the insert rolled back", n)
+	}
+	if len(e.GetStagedChanges()) != 2 {
+		t.Errorf("GetStagedChanges() = %v, want the changes kept after a failed commit", e.GetStagedChanges())
+	}
+	if cmd := e.ToggleStaging(); cmd == nil {
+		t.Errorf("ToggleStaging() should fail while changes are staged")
+	}
+
+	// Once the edit is staged against the current version, both changes are committed
+	e.UnstageChange(e.GetStagedChanges()[1].Id)
+	if err := e.UpdateDocument(loadDoc(t, backend, 2), bson.M{"_id": 2, "name": "Sal"}); err != nil {
+		t.Fatalf("UpdateDocument() error = %v", err)
+	}
+	if msg := e.CommitStaged()(); msg != (StagedCommittedMsg{Count: 2}) {
+		t.Fatalf("CommitStaged() = %#v", msg)
+	}
+	if got := loadDoc(t, backend, 2); got["name"] != "Sal" {
+		t.Errorf("doc after the commit = %v", got)
+	}
+	if n, _ := backend.CountDocuments(ctx, "shop", "users", bson.D{}, 0); n != 4 {
+		t.Errorf("CountDocuments() = %d, want the insert committed", n)
+	}
+	if len(e.GetStagedChanges()) != 0 || len(e.GetJournal()) != 2 {
+		t.Errorf("committed changes should move from the staged changes to the journal")
+	}
+}
+
+func TestCommitStagedCancellation(t *testing.T) {
+	tests := []struct {
+		name          string
+		interrupt     func(e *Engine)
+		wantCommitted bool
+	}{
+		{name: "query started during the commit", interrupt: func(e *Engine) { e.QueryCollection(Query{})() }, wantCommitted: true},
+		{name: "cancelled by the user", interrupt: func(e *Engine) { e.CancelOperation() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(newSeededBackend(t))
+			e.SetSelectedCollection("shop", "users")
+			e.ToggleStaging()
+			if err := e.InsertDocument(bson.M{"_id": 4, "name": "Ann"}); err != nil {
+				t.Fatalf("InsertDocument() error = %v", err)
+			}
+			cmd := e.CommitStaged()
+
+			tt.interrupt(e)
+			msg := cmd()
+			if tt.wantCommitted {
+				if msg != (StagedCommittedMsg{Count: 1}) {
+					t.Errorf("CommitStaged() = %#v, want the change committed", msg)
+				}
+				return
+			}
+			// The user must be told that nothing was committed rather than the commit silently stopping
+			if _, ok := msg.(modal.ErrModalMsg); !ok {
+				t.Errorf("CommitStaged() = %#v, want an error modal", msg)
+			}
+			if len(e.GetStagedChanges()) != 1 {
+				t.Errorf("GetStagedChanges() = %v, want the change kept", e.GetStagedChanges())
+			}
+		})
+	}
+}
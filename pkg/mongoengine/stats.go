@@ -0,0 +1,157 @@
+package mongoengine
+
+// The methods contained within this file fetch the statistics of the databases and collections displayed in the
+// dbcoltable component. The stats are cached alongside the collections of each database until the next
+// RefreshDbAndCollections. As they are only informational, a failure is stored with the stats rather than being
+// displayed in an error modal
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// DatabaseStats holds the output of the dbStats command. Sizes are in bytes
+type DatabaseStats struct {
+	Collections int64
+	Views       int64
+	Objects     int64
+	AvgObjSize  int64
+	DataSize    int64
+	StorageSize int64
+	IndexSize   int64
+
+	Loading bool
+	Err     error // Set when the stats failed to load
+}
+
+// CollectionStats holds the type of a collection from listCollections along with its $collStats storage stats.
+// Views have no storage stats. Sizes are in bytes
+type CollectionStats struct {
+	Type   string // collection, view or timeseries
+	Capped bool
+	ViewOn string // The collection a view is defined on
+
+	Count       int64
+	AvgObjSize  int64
+	Size        int64
+	StorageSize int64
+	IndexSize   int64
+
+	Loading bool
+	Err     error // Set when the stats failed to load
+}
+
+// LoadDatabaseStats fetches the stats of a database unless they are already cached or being fetched
+func (e *Engine) LoadDatabaseStats(dbName string) tea.Cmd {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	db, ok := e.server.databases[dbName]
+	if !ok || db.stats != nil {
+		return nil
+	}
+	db.stats = &DatabaseStats{Loading: true}
+	e.server.databases[dbName] = db
+	srv := e.server
+
+	return func() tea.Msg {
+		stats := e.fetchDatabaseStats(dbName)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if db, ok := srv.databases[dbName]; ok {
+			db.stats = stats
+			srv.databases[dbName] = db
+		}
+		return RedrawMessage{}
+	}
+}
+
+// LoadCollectionStats fetches the stats of a collection unless they are already cached or being fetched
+func (e *Engine) LoadCollectionStats(dbName, collName string) tea.Cmd {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	db, ok := e.server.databases[dbName]
+	if !ok || collName == "" || db.collectionStats[collName] != nil {
+		return nil
+	}
+	if db.collectionStats == nil {
+		db.collectionStats = make(map[string]*CollectionStats)
+	}
+	db.collectionStats[collName] = &CollectionStats{Loading: true}
+	e.server.databases[dbName] = db
+	srv := e.server
+
+	return func() tea.Msg {
+		stats := e.fetchCollectionStats(dbName, collName)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if db, ok := srv.databases[dbName]; ok {
+			db.collectionStats[collName] = stats
+		}
+		return RedrawMessage{}
+	}
+}
+
+func (e *Engine) fetchDatabaseStats(dbName string) *DatabaseStats {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	var reply bson.Raw
+	if err := e.backend.RunCommand(ctx, dbName, bson.D{{Key: "dbStats", Value: 1}}, &reply); err != nil {
+		return &DatabaseStats{Err: fmt.Errorf("could not fetch stats of %s: %w", dbName, err)}
+	}
+	return &DatabaseStats{
+		Collections: lookupInt64(reply, "collections"),
+		Views:       lookupInt64(reply, "views"),
+		Objects:     lookupInt64(reply, "objects"),
+		AvgObjSize:  lookupInt64(reply, "avgObjSize"),
+		DataSize:    lookupInt64(reply, "dataSize"),
+		StorageSize: lookupInt64(reply, "storageSize"),
+		IndexSize:   lookupInt64(reply, "indexSize"),
+	}
+}
+
+// fetchCollectionStats looks up the type of the collection first as $collStats can not be run against a view
+func (e *Engine) fetchCollectionStats(dbName, collName string) *CollectionStats {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	listCollections := bson.D{
+		{Key: "listCollections", Value: 1},
+		{Key: "filter", Value: bson.D{{Key: "name", Value: collName}}},
+	}
+	var reply bson.Raw
+	if err := e.backend.RunCommand(ctx, dbName, listCollections, &reply); err != nil {
+		return &CollectionStats{Err: fmt.Errorf("could not fetch info of %s.%s: %w", dbName, collName, err)}
+	}
+	info, ok := reply.Lookup("cursor", "firstBatch", "0").DocumentOK()
+	if !ok {
+		return &CollectionStats{Err: fmt.Errorf("collection %s.%s does not exist", dbName, collName)}
+	}
+	stats := &CollectionStats{Type: "collection"}
+	if collType, ok := info.Lookup("type").StringValueOK(); ok {
+		stats.Type = collType
+	}
+	stats.Capped, _ = info.Lookup("options", "capped").BooleanOK()
+	stats.ViewOn, _ = info.Lookup("options", "viewOn").StringValueOK()
+	if stats.Type == "view" {
+		return stats
+	}
+
+	pipeline := bson.A{bson.D{{Key: "$collStats", Value: bson.D{{Key: "storageStats", Value: bson.D{}}}}}}
+	var results []bson.Raw
+	if err := e.backend.Aggregate(ctx, dbName, collName, pipeline, &results); err != nil {
+		stats.Err = fmt.Errorf("could not fetch stats of %s.%s: %w", dbName, collName, err)
+		return stats
+	} else if len(results) == 0 {
+		stats.Err = fmt.Errorf("no stats were returned for %s.%s", dbName, collName)
+		return stats
+	}
+	stats.Count = lookupInt64(results[0], "storageStats", "count")
+	stats.AvgObjSize = lookupInt64(results[0], "storageStats", "avgObjSize")
+	stats.Size = lookupInt64(results[0], "storageStats", "size")
+	stats.StorageSize = lookupInt64(results[0], "storageStats", "storageSize")
+	stats.IndexSize = lookupInt64(results[0], "storageStats", "totalIndexSize")
+	return stats
+}
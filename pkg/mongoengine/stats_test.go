@@ -0,0 +1,90 @@
+package mongoengine
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"testing"
+)
+
+// viewBackend lists a single view alongside the collections of the memory backend
+type viewBackend struct {
+	*MemoryBackend
+}
+
+func (b *viewBackend) RunCommand(ctx context.Context, db string, cmd any, result any) error {
+	c, _ := toDoc(cmd)
+	if filter, _ := lookupField(c, "filter"); c[0].Key == "listCollections" && valuesEqual(filter, bson.D{{Key: "name", Value: "active_users"}}) {
+		view := bson.D{
+			{Key: "name", Value: "active_users"},
+			{Key: "type", Value: "view"},
+			{Key: "options", Value: bson.D{{Key: "viewOn", Value: "users"}, {Key: "pipeline", Value: bson.A{}}}},
+		}
+		data, err := bson.Marshal(bson.D{{Key: "cursor", Value: bson.D{{Key: "firstBatch", Value: bson.A{view}}}}})
+		if err != nil {
+			return err
+		}
+		return bson.Unmarshal(data, result)
+	}
+	return b.MemoryBackend.RunCommand(ctx, db, cmd, result)
+}
+
+func TestLoadStats(t *testing.T) {
+	e := New(&viewBackend{MemoryBackend: newSeededBackend(t)})
+	if err := e.RefreshDbAndCollections(); err != nil {
+		t.Fatalf("RefreshDbAndCollections() error = %v", err)
+	}
+
+	if _, ok := e.GetDatabaseStats("shop"); ok {
+		t.Fatalf("GetDatabaseStats() returned stats before they were loaded")
+	}
+	loadDb := e.LoadDatabaseStats("shop")
+	if stats, _ := e.GetDatabaseStats("shop"); !stats.Loading {
+		t.Errorf("GetDatabaseStats() is not loading after LoadDatabaseStats")
+	}
+	if e.LoadDatabaseStats("shop") != nil {
+		t.Errorf("LoadDatabaseStats() fetched stats that are already loading")
+	}
+	loadDb()
+	dbStats, _ := e.GetDatabaseStats("shop")
+	if dbStats.Loading || dbStats.Err != nil || dbStats.Collections != 1 || dbStats.Objects != 3 || dbStats.DataSize == 0 {
+		t.Errorf("GetDatabaseStats() = %+v", dbStats)
+	}
+
+	tests := []struct {
+		name       string
+		collName   string
+		wantType   string
+		wantViewOn string
+		wantCount  int64
+		wantErr    bool
+	}{
+		{name: "collection", collName: "users", wantType: "collection", wantCount: 3},
+		{name: "view", collName: "active_users", wantType: "view", wantViewOn: "users"},
+		{name: "missing collection", collName: "orders", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.LoadCollectionStats("shop", tt.collName)()
+			stats, ok := e.GetCollectionStats("shop", tt.collName)
+			if !ok || stats.Loading {
+				t.Fatalf("GetCollectionStats() = %+v, %v", stats, ok)
+			}
+			if (stats.Err != nil) != tt.wantErr {
+				t.Fatalf("GetCollectionStats() error = %v, wantErr %v", stats.Err, tt.wantErr)
+			}
+			if stats.Type != tt.wantType || stats.ViewOn != tt.wantViewOn || stats.Count != tt.wantCount {
+				t.Errorf("GetCollectionStats() = %+v, want type %q on %q and count %d", stats, tt.wantType, tt.wantViewOn, tt.wantCount)
+			}
+			if tt.wantType == "collection" && (stats.Size == 0 || stats.AvgObjSize != stats.Size/stats.Count) {
+				t.Errorf("GetCollectionStats() sizes = %+v", stats)
+			}
+		})
+	}
+
+	if err := e.RefreshDbAndCollections(); err != nil {
+		t.Fatalf("RefreshDbAndCollections() error = %v", err)
+	}
+	if _, ok := e.GetDatabaseStats("shop"); ok {
+		t.Errorf("GetDatabaseStats() returned stats after a refresh")
+	}
+}
@@ -1,5 +1,7 @@
 package renderutils
 
+import "fmt"
+
 func Max(a, b int) int {
 	if a > b {
 		return a
@@ -27,3 +29,18 @@ func Clamp(v, low, high int) int {
 	}
 	return Min(Max(v, low), high)
 }
+
+// FormatBytes renders a size in bytes using the largest unit that keeps the value above 1, such as 1.5 KB
+func FormatBytes(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -5,6 +5,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -26,9 +27,9 @@ type baseModel struct {
 	engine *mongoengine.Engine
 }
 
-func Initialize(client *mongo.Client) {
+func Initialize(client *mongo.Client, concerns mongoengine.Concerns) {
 	lipgloss.SetColorProfile(termenv.ANSI256)
-	p := tea.NewProgram(initialModel(client))
+	p := tea.NewProgram(initialModel(client, concerns))
 	defer client.Disconnect(context.Background())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
@@ -36,8 +37,9 @@ func Initialize(client *mongo.Client) {
 	}
 }
 
-func initialModel(client *mongo.Client) tea.Model {
-	engine := mongoengine.New(client)
+func initialModel(client *mongo.Client, concerns mongoengine.Concerns) tea.Model {
+	engine := mongoengine.New(mongoengine.NewMongoBackend(client))
+	engine.SetConcerns(concerns)
 
 	msgModal := modal.New()
 	mainView := mainview.New(engine)
@@ -61,7 +63,7 @@ func initialModel(client *mongo.Client) tea.Model {
 
 // Init initialises the baseModel on program load. It partly implements the tea.Model interface.
 func (m *baseModel) Init() tea.Cmd {
-	return nil
+	return m.mainView.Init()
 }
 
 // Update handles event and manages internal state. It partly implements the tea.Model interface.
@@ -69,31 +71,58 @@ func (m *baseModel) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := message.(type) {
 	// First see if we need to redirect to the msgModal
 	// TODO find a simpler way of finding all modal messages
-	case modal.ErrModalMsg, modal.DbCollInsertModalMsg, modal.CollDropModalMsg, modal.DbDropModalMsg, modal.DocDeleteModalMsg, modal.DocInsertModalMsg, modal.DocEditModalMsg:
+	case modal.ErrModalMsg, modal.DbCollInsertModalMsg, modal.CollCreateModalMsg, modal.CollDropModalMsg, modal.DbDropModalMsg, modal.DocDeleteModalMsg, modal.DocDeleteManyModalMsg, modal.DocUpdateManyModalMsg, modal.DocInsertModalMsg, modal.DocEditModalMsg, modal.DocConflictModalMsg, modal.UndoModalMsg, modal.StagedChangesModalMsg, modal.IndexCreateModalMsg, modal.IndexDropModalMsg, modal.FormModalMsg:
 		mod, modCmd := m.msgModal.Update(message)
 		m.msgModal = mod
 		return m, modCmd
 	case modal.ExecDocEdit: // Edit does not require cursor updates so it can be executed from top tui component
-		if err := m.engine.UpdateDocument(msg.OldDoc, msg.NewDoc); err != nil {
+		var conflict *mongoengine.ConflictError
+		if err := m.engine.UpdateDocument(msg.OldDoc, msg.NewDoc); errors.As(err, &conflict) {
+			return m, modal.DisplayDocConflictModal(msg.OldDoc, conflict.Current, msg.NewDoc)
+		} else if err != nil {
 			return m, modal.DisplayErrorModal(err)
 		}
 		return m, m.engine.RerunLastCollectionQuery()
+	case modal.ExecUndo: // Like edits, undoing a write only requires the docs to be queried again
+		return m, m.engine.Undo(msg.Id)
+	case modal.ExecStagedCommit:
+		return m, m.engine.CommitStaged()
+	case modal.ExecStagedDiscard:
+		return m, m.engine.DiscardStaged()
+	case modal.ExecDocReload:
+		return m, m.engine.RerunLastCollectionQuery()
 	case modal.ExecDocInsert: // Insert does not require cursor updates so it can be executed from top tui component
 		if err := m.engine.InsertDocument(msg.Doc); err != nil {
 			return m, modal.DisplayErrorModal(err)
 		}
 		return m, m.engine.RerunLastCollectionQuery()
+	case modal.ExecIndexCreate: // Like inserts, index creation does not require cursor updates
+		return m, m.engine.CreateIndex(msg.Spec)
 	case modal.ExecDbCollInsert:
-		if err := m.engine.InsertDatabaseAndCollection(msg.DatabaseName, msg.CollectionName); err != nil {
+		if err := m.engine.InsertDatabaseAndCollection(msg.DatabaseName, msg.CollectionName, msg.Options); err != nil {
 			return m, modal.DisplayErrorModal(err)
 		}
 		if err := m.engine.RefreshDbAndCollections(); err != nil {
 			return m, modal.DisplayErrorModal(fmt.Errorf("error refreshing data after database and collection insertion: %w", err))
 		}
+		return m, func() tea.Msg { return mongoengine.DatabasesRefreshedMsg{} }
+	case mongoengine.JobProgressMsg, mongoengine.JobFinishedMsg: // Jobs keep running while a modal is displayed
+		mv, mvCmd := m.mainView.Update(message)
+		m.mainView = mv
+		return m, mvCmd
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
+		case "ctrl+x": // A query is cancelled before the job running in the background
+			if !m.engine.CancelOperation() {
+				m.engine.CancelJob()
+			}
+			return m, nil
+		case "ctrl+r": // Like cancelling, the concerns can be changed from any view
+			if !m.msgModal.(*modal.Model).IsModalDisplaying() {
+				return m, m.concernsForm()
+			}
 		case "q":
 			if !m.mainView.(*mainview.Model).IsDbCollFilterOrSearchQueryFocused() && !m.msgModal.(*modal.Model).IsTextInputFocused() {
 				return m, tea.Quit
@@ -118,3 +147,24 @@ func (m *baseModel) View() string {
 	}
 	return m.mainView.View()
 }
+
+// concernsForm asks for the read preference, read concern and write concern that every following query and write
+// is sent with
+func (m *baseModel) concernsForm() tea.Cmd {
+	readPreference, readConcern, w, journal, writeTimeout := m.engine.GetConcerns().Strings()
+	fields := []modal.FormField{
+		{Label: "Read preference", Value: readPreference, Hint: "primary, primaryPreferred, secondary, secondaryPreferred or nearest"},
+		{Label: "Read concern", Value: readConcern, Hint: "local, available, majority, linearizable or snapshot"},
+		{Label: "Write concern", Value: w, Hint: "number of members, majority or a tag set name"},
+		{Label: "Journal", Value: journal, Hint: "yes waits for writes to reach the journal"},
+		{Label: "Write timeout", Value: writeTimeout, Hint: "milliseconds to wait for a write, which may still be applied after"},
+	}
+	return modal.DisplayFormModal("Read and write concerns", fields, func(values []string) tea.Cmd {
+		concerns, err := m.engine.GetConcerns().Update(values[0], values[1], values[2], values[3], values[4])
+		if err != nil {
+			return modal.DisplayErrorModal(err)
+		}
+		m.engine.SetConcerns(concerns)
+		return func() tea.Msg { return mongoengine.ConcernsChangedMsg{Concerns: concerns} }
+	})
+}